@@ -0,0 +1,76 @@
+package main
+
+import (
+	"demo-app-go/storage"
+	"demo-app-go/task"
+	"github.com/jmoiron/sqlx"
+)
+
+// services are the application layer, built on top of repositories of the chosen storage backend.
+type services struct {
+	tasks       *task.Service
+	projects    *task.ProjectService
+	templates   *task.TemplateService
+	time        *task.TimeService
+	comments    *task.CommentService
+	attachments *task.AttachmentService
+}
+
+// serviceOptions configure services regardless of the storage backend.
+type serviceOptions struct {
+	deletePolicy     task.DeletePolicy
+	attachmentPolicy task.AttachmentPolicy
+	blobStore        task.BlobStore
+}
+
+// databaseServices keep data in the database. Events are relayed from its outbox by relayOutbox.
+func databaseServices(db *sqlx.DB, options serviceOptions) services {
+	taskRepository := storage.NewTaskRepository(db)
+	projectRepository := storage.NewProjectRepository(db)
+	taskService := task.NewService(
+		taskRepository,
+		storage.NewRevisionRepository(db),
+		projectRepository,
+		options.deletePolicy,
+	)
+
+	return services{
+		tasks:     taskService,
+		projects:  task.NewProjectService(projectRepository, taskRepository),
+		templates: task.NewTemplateService(storage.NewTemplateRepository(db), taskService),
+		time:      task.NewTimeService(storage.NewTimeEntryRepository(db), taskRepository),
+		comments:  task.NewCommentService(storage.NewCommentRepository(db), taskRepository),
+		attachments: task.NewAttachmentService(
+			storage.NewAttachmentRepository(db),
+			taskRepository,
+			options.blobStore,
+			options.attachmentPolicy,
+		),
+	}
+}
+
+// memoryServices keep data only until the process exits. Events are published by the memory right after changes.
+func memoryServices(memory *storage.Memory, options serviceOptions) services {
+	taskRepository := storage.NewMemoryTaskRepository(memory)
+	projectRepository := storage.NewMemoryProjectRepository(memory)
+	taskService := task.NewService(
+		taskRepository,
+		storage.NewMemoryRevisionRepository(memory),
+		projectRepository,
+		options.deletePolicy,
+	)
+
+	return services{
+		tasks:     taskService,
+		projects:  task.NewProjectService(projectRepository, taskRepository),
+		templates: task.NewTemplateService(storage.NewMemoryTemplateRepository(memory), taskService),
+		time:      task.NewTimeService(storage.NewMemoryTimeEntryRepository(memory), taskRepository),
+		comments:  task.NewCommentService(storage.NewMemoryCommentRepository(memory), taskRepository),
+		attachments: task.NewAttachmentService(
+			storage.NewMemoryAttachmentRepository(memory),
+			taskRepository,
+			options.blobStore,
+			options.attachmentPolicy,
+		),
+	}
+}
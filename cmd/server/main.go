@@ -1,17 +1,20 @@
 package main
 
 import (
+	"demo-app-go/eventbus"
 	"demo-app-go/fakestore"
 	"demo-app-go/handlers"
 	"demo-app-go/storage"
+	"demo-app-go/task"
+	"fmt"
 	"github.com/go-playground/validator/v10"
-	_ "github.com/go-sql-driver/mysql"
-	"github.com/jmoiron/sqlx"
 	"github.com/joho/godotenv"
 	"github.com/labstack/echo/v4"
 	"log"
 	"net/http"
 	"os"
+	"strconv"
+	"strings"
 	"time"
 )
 
@@ -25,12 +28,89 @@ func main() {
 	fakeStoreAPI := fakestore.NewAPI(os.Getenv("FAKESTOREAPI_BASEURL"), httpClient)
 	productsHandler := handlers.ProductsHandler{FakeStoreAPI: fakeStoreAPI}
 
-	db, err := sqlx.Connect("mysql", os.Getenv("DATABASE_DSN"))
+	if len(os.Args) > 1 && os.Args[1] == "migrate" {
+		db, err := storage.Open(os.Getenv("DATABASE_DSN"))
+		if err != nil {
+			log.Fatalf("Failed connecting to the database: %s", err)
+		}
+		err = migrate(db, os.Args[2:])
+		if err != nil {
+			log.Fatalf("Failed migrating: %s", err)
+		}
+		return
+	}
+
+	deletePolicy, err := task.ParseDeletePolicy(os.Getenv("TASK_DELETE_POLICY"))
+	if err != nil {
+		log.Fatalf("Invalid TASK_DELETE_POLICY: %s", err)
+	}
+	attachmentPolicy, err := getAttachmentPolicy()
+	if err != nil {
+		log.Fatalf("Invalid attachment limits: %s", err)
+	}
+	blobStore, err := storage.NewLocalBlobStore(os.Getenv("ATTACHMENTS_DIR"))
+	if err != nil {
+		log.Fatalf("Failed preparing attachments directory: %s", err)
+	}
+	options := serviceOptions{deletePolicy: deletePolicy, attachmentPolicy: attachmentPolicy, blobStore: blobStore}
+	events := eventbus.NewBus()
+	events.SubscribeAsync(logTaskEvent)
+
+	var services services
+	switch backend := os.Getenv("STORAGE_BACKEND"); backend {
+	case "database":
+		db, err := storage.Open(os.Getenv("DATABASE_DSN"))
+		if err != nil {
+			log.Fatalf("Failed connecting to the database: %s", err)
+		}
+		migrateOnStart, err := strconv.ParseBool(os.Getenv("DATABASE_MIGRATE_ON_START"))
+		if err != nil {
+			log.Fatalf("Invalid DATABASE_MIGRATE_ON_START: %s", err)
+		}
+		if migrateOnStart {
+			err = migrate(db, []string{"up"})
+			if err != nil {
+				log.Fatalf("Failed migrating: %s", err)
+			}
+		}
+		services = databaseServices(db, options)
+		outboxRelay := storage.NewOutboxRelay(db)
+		outboxRelay.Subscribe(func(event eventbus.Event) error {
+			events.Publish(event)
+			return nil
+		})
+		go relayOutbox(outboxRelay)
+		outboxRetention, err := time.ParseDuration(os.Getenv("OUTBOX_RETENTION"))
+		if err != nil {
+			log.Fatalf("Invalid OUTBOX_RETENTION: %s", err)
+		}
+		go pruneOutbox(outboxRelay, outboxRetention)
+	case "memory":
+		log.Printf("Keeping data in memory, it is lost once the server stops")
+		services = memoryServices(storage.NewMemory(events), options)
+	default:
+		log.Fatalf("Invalid STORAGE_BACKEND: %q is neither database nor memory", backend)
+	}
+
+	trashRetention, err := time.ParseDuration(os.Getenv("TASK_TRASH_RETENTION"))
 	if err != nil {
-		log.Fatalf("Failed connecting to the database: %s", err)
+		log.Fatalf("Invalid TASK_TRASH_RETENTION: %s", err)
 	}
-	taskRepository := storage.NewTaskRepository(db)
-	taskHandler := handlers.NewTaskHandler(taskRepository)
+	go purgeTrash(services.tasks, trashRetention)
+	go sweepBlobs(services.attachments)
+	requireIfMatch, err := strconv.ParseBool(os.Getenv("TASK_REQUIRE_IF_MATCH"))
+	if err != nil {
+		log.Fatalf("Invalid TASK_REQUIRE_IF_MATCH: %s", err)
+	}
+	taskHandler := handlers.NewTaskHandler(services.tasks, requireIfMatch)
+	tagHandler := handlers.NewTagHandler(services.tasks)
+	projectHandler := handlers.NewProjectHandler(services.projects)
+	templateHandler := handlers.NewTemplateHandler(services.templates)
+	historyHandler := handlers.NewHistoryHandler(services.tasks)
+	checklistHandler := handlers.NewChecklistHandler(services.tasks)
+	timeHandler := handlers.NewTimeHandler(services.time)
+	commentHandler := handlers.NewCommentHandler(services.comments)
+	attachmentHandler := handlers.NewAttachmentHandler(services.attachments, attachmentPolicy.MaxSize())
 
 	e := echo.New()
 	e.Validator = &RequestValidator{validator: validator.New()}
@@ -44,14 +124,172 @@ func main() {
 	e.PUT("/products/:id", productsHandler.UpdateProduct)
 	e.DELETE("/products/:id", productsHandler.DeleteProduct)
 	e.GET("/tasks", taskHandler.List)
+	e.GET("/tasks/actionable", taskHandler.Actionable)
+	e.GET("/tasks/trash", taskHandler.Trash)
 	e.POST("/tasks", taskHandler.Add)
 	e.GET("/tasks/:id", taskHandler.Get)
 	e.PUT("/tasks/:id", taskHandler.Update)
 	e.DELETE("/tasks/:id", taskHandler.Delete)
+	e.POST("/tasks/:id/transitions", taskHandler.Transition)
+	e.POST("/tasks/:id/restore", taskHandler.Restore)
+	e.POST("/tasks/:id/move", taskHandler.Move)
+	e.POST("/tasks/:id/clone", taskHandler.Clone)
+	e.GET("/tasks/:id/subtasks", taskHandler.Subtasks)
+	e.GET("/tasks/:id/tree", taskHandler.Tree)
+	e.GET("/tasks/:id/occurrences", taskHandler.Occurrences)
+	e.GET("/tasks/:id/blockers", taskHandler.Blockers)
+	e.POST("/tasks/:id/blockers", taskHandler.AddBlocker)
+	e.DELETE("/tasks/:id/blockers/:blockerId", taskHandler.RemoveBlocker)
+	e.GET("/tasks/:id/dependents", taskHandler.Dependents)
+	e.GET("/tasks/:id/checklist", checklistHandler.Get)
+	e.POST("/tasks/:id/checklist", checklistHandler.Add)
+	e.POST("/tasks/:id/checklist/:itemId/toggle", checklistHandler.Toggle)
+	e.PUT("/tasks/:id/checklist/:itemId/position", checklistHandler.Move)
+	e.DELETE("/tasks/:id/checklist/:itemId", checklistHandler.Delete)
+	e.GET("/tasks/:id/time-entries", timeHandler.List)
+	e.POST("/tasks/:id/time-entries", timeHandler.Log)
+	e.POST("/tasks/:id/timer/start", timeHandler.Start)
+	e.POST("/tasks/:id/timer/stop", timeHandler.Stop)
+	e.GET("/tasks/:id/history", historyHandler.List)
+	e.GET("/tasks/:id/history/diff", historyHandler.Diff)
+	e.GET("/tasks/:id/history/:rev", historyHandler.Get)
+	e.POST("/tasks/:id/history/:rev/revert", historyHandler.Revert)
+	e.GET("/tasks/:id/comments", commentHandler.List)
+	e.POST("/tasks/:id/comments", commentHandler.Add)
+	e.GET("/tasks/:id/comments/:commentId", commentHandler.Get)
+	e.PUT("/tasks/:id/comments/:commentId", commentHandler.Update)
+	e.DELETE("/tasks/:id/comments/:commentId", commentHandler.Delete)
+	e.GET("/tasks/:id/attachments", attachmentHandler.List)
+	e.POST("/tasks/:id/attachments", attachmentHandler.Add)
+	e.GET("/tasks/:id/attachments/:attachmentId", attachmentHandler.Get)
+	e.GET("/tasks/:id/attachments/:attachmentId/content", attachmentHandler.Download)
+	e.DELETE("/tasks/:id/attachments/:attachmentId", attachmentHandler.Delete)
+	e.GET("/time-report", timeHandler.Report)
+	e.GET("/projects", projectHandler.List)
+	e.POST("/projects", projectHandler.Add)
+	e.GET("/projects/:id", projectHandler.Get)
+	e.PUT("/projects/:id", projectHandler.Update)
+	e.DELETE("/projects/:id", projectHandler.Delete)
+	e.GET("/projects/:id/board", projectHandler.Board)
+
+	e.GET("/templates", templateHandler.List)
+	e.POST("/templates", templateHandler.Add)
+	e.GET("/templates/:id", templateHandler.Get)
+	e.PUT("/templates/:id", templateHandler.Update)
+	e.DELETE("/templates/:id", templateHandler.Delete)
+	e.POST("/templates/:id/instantiate", templateHandler.Instantiate)
+	e.GET("/tags", tagHandler.List)
+	e.PUT("/tags/:name", tagHandler.Rename)
+	e.POST("/tags/:name/merge", tagHandler.Merge)
 
 	e.Logger.Fatal(e.Start(":8000"))
 }
 
+// purgeInterval is how often the trash, the outbox and attachment contents are checked for data to remove.
+const purgeInterval = time.Hour
+
+// purgeTrash permanently removes tasks which stay in the trash longer than the retention. It never returns.
+func purgeTrash(service *task.Service, retention time.Duration) {
+	ticker := time.NewTicker(purgeInterval)
+	defer ticker.Stop()
+	for ; true; <-ticker.C {
+		count, err := service.PurgeTrash(time.Now().Add(-retention))
+		if err != nil {
+			log.Printf("Failed purging trash: %s", err)
+			continue
+		}
+		if count > 0 {
+			log.Printf("Purged %d tasks from trash", count)
+		}
+	}
+}
+
+// pruneOutbox removes events which were delivered longer than the retention ago. It never returns.
+func pruneOutbox(relay *storage.OutboxRelay, retention time.Duration) {
+	ticker := time.NewTicker(purgeInterval)
+	defer ticker.Stop()
+	for ; true; <-ticker.C {
+		count, err := relay.Prune(time.Now().Add(-retention))
+		if err != nil {
+			log.Printf("Failed pruning outbox: %s", err)
+			continue
+		}
+		if count > 0 {
+			log.Printf("Pruned %d delivered events from outbox", count)
+		}
+	}
+}
+
+// blobGracePeriod keeps contents of attachments which are being uploaded from being swept. Uploads are expected
+// to take much less.
+const blobGracePeriod = time.Hour
+
+// sweepBlobs removes contents of attachments which were deleted, or failed to be added. It never returns.
+func sweepBlobs(service *task.AttachmentService) {
+	ticker := time.NewTicker(purgeInterval)
+	defer ticker.Stop()
+	for ; true; <-ticker.C {
+		count, err := service.SweepBlobs(time.Now().Add(-blobGracePeriod))
+		if err != nil {
+			log.Printf("Failed sweeping attachment contents: %s", err)
+			continue
+		}
+		if count > 0 {
+			log.Printf("Swept %d unused attachment contents", count)
+		}
+	}
+}
+
+// outboxInterval is how often the outbox is checked for events to relay.
+const outboxInterval = time.Second
+
+// relayOutbox keeps delivering events queued in the outbox. It never returns.
+func relayOutbox(relay *storage.OutboxRelay) {
+	ticker := time.NewTicker(outboxInterval)
+	defer ticker.Stop()
+	for range ticker.C {
+		for {
+			count, err := relay.Relay()
+			if err != nil {
+				log.Printf("Failed relaying events: %s", err)
+			}
+			// Failed events are not due until their retry, so they do not keep the loop going.
+			if count == 0 {
+				break
+			}
+		}
+	}
+}
+
+// logTaskEvent leaves a trace of task changes in the log.
+func logTaskEvent(event eventbus.Event) {
+	switch e := event.(type) {
+	case task.TaskCreated:
+		log.Printf("Task %d created by %s", e.Task.Id(), e.Actor)
+	case task.TaskUpdated:
+		fields := make([]string, len(e.Changes))
+		for i, change := range e.Changes {
+			fields[i] = change.Field()
+		}
+		log.Printf("Task %d updated by %s: %s", e.Task.Id(), e.Actor, strings.Join(fields, ", "))
+	case task.TaskDeleted:
+		if e.Permanent {
+			log.Printf("Task %d purged", e.TaskID)
+		} else {
+			log.Printf("Task %d deleted by %s", e.TaskID, e.Actor)
+		}
+	}
+}
+
+// getAttachmentPolicy reads ATTACHMENT_MAX_SIZE in bytes and comma separated ATTACHMENT_CONTENT_TYPES.
+func getAttachmentPolicy() (task.AttachmentPolicy, error) {
+	maxSize, err := strconv.ParseInt(os.Getenv("ATTACHMENT_MAX_SIZE"), 10, 64)
+	if err != nil {
+		return task.AttachmentPolicy{}, fmt.Errorf("ATTACHMENT_MAX_SIZE: %w", err)
+	}
+	return task.NewAttachmentPolicy(maxSize, strings.Split(os.Getenv("ATTACHMENT_CONTENT_TYPES"), ","))
+}
+
 type RequestValidator struct {
 	validator *validator.Validate
 }
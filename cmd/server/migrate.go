@@ -0,0 +1,68 @@
+package main
+
+import (
+	"demo-app-go/storage"
+	"errors"
+	"fmt"
+	"github.com/jmoiron/sqlx"
+	"log"
+	"strconv"
+)
+
+const migrateUsage = "usage: server migrate up|down|status|to <version>"
+
+// migrate runs the migrate subcommand, e.g. `server migrate to 3`.
+func migrate(db *sqlx.DB, args []string) error {
+	migrator, err := storage.NewMigrator(db)
+	if err != nil {
+		return err
+	}
+	if len(args) == 0 {
+		return errors.New(migrateUsage)
+	}
+
+	var migrations []storage.Migration
+	switch {
+	case args[0] == "up" && len(args) == 1:
+		migrations, err = migrator.Up()
+	case args[0] == "down" && len(args) == 1:
+		migrations, err = migrator.Down()
+	case args[0] == "to" && len(args) == 2:
+		version, parseErr := strconv.Atoi(args[1])
+		if parseErr != nil {
+			return fmt.Errorf("invalid version %q", args[1])
+		}
+		migrations, err = migrator.To(version)
+	case args[0] == "status" && len(args) == 1:
+		return printMigrationStatus(migrator)
+	default:
+		return errors.New(migrateUsage)
+	}
+	logMigrations(migrations)
+	if err == nil && len(migrations) == 0 {
+		log.Printf("Nothing to migrate")
+	}
+	return err
+}
+
+func printMigrationStatus(migrator *storage.Migrator) error {
+	statuses, err := migrator.Status()
+	if err != nil {
+		return err
+	}
+	for _, status := range statuses {
+		appliedAt := "pending"
+		if status.AppliedAt != nil {
+			appliedAt = "applied at " + status.AppliedAt.Format("2006-01-02 15:04:05")
+		}
+		fmt.Printf("%04d %-30s %s\n", status.Version, status.Name, appliedAt)
+	}
+	return nil
+}
+
+// logMigrations lists migrations which were applied or reverted, even if a later one failed.
+func logMigrations(migrations []storage.Migration) {
+	for _, migration := range migrations {
+		log.Printf("Migrated %04d %s", migration.Version, migration.Name)
+	}
+}
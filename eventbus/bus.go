@@ -0,0 +1,181 @@
+package eventbus
+
+import (
+	"hash/fnv"
+	"log"
+	"runtime/debug"
+	"sync"
+)
+
+// asyncWorkers is how many goroutines deliver events to a single asynchronous subscriber.
+// Events are spread over them by key, so events with the same key are always handled by the same goroutine.
+const asyncWorkers = 8
+
+// Event is anything published on the Bus. Key groups events which have to be delivered in order,
+// usually it identifies the aggregate the event is about.
+type Event interface {
+	Key() string
+}
+
+type Handler func(event Event)
+
+// Bus delivers published events to subscribers within the process.
+// Synchronous subscribers are called by Publish, before it returns. Asynchronous ones are called from background
+// goroutines, so they do not slow down the publisher. Either way, events with the same key are delivered in the order
+// they were published, and a panicking subscriber neither affects other subscribers nor the publisher.
+type Bus struct {
+	mutex  sync.RWMutex
+	sync   []Handler
+	async  []*asyncSubscriber
+	closed bool
+}
+
+func NewBus() *Bus {
+	return &Bus{}
+}
+
+// Subscribe registers a handler called synchronously by Publish.
+func (b *Bus) Subscribe(handler Handler) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	b.sync = append(b.sync, handler)
+}
+
+// SubscribeAsync registers a handler called in the background. It receives only events published after it subscribed.
+func (b *Bus) SubscribeAsync(handler Handler) {
+	subscriber := newAsyncSubscriber(handler)
+
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	if b.closed {
+		subscriber.close()
+		return
+	}
+	b.async = append(b.async, subscriber)
+}
+
+// Publish delivers the event to synchronous subscribers and queues it for asynchronous ones.
+// Once the bus is closed, events are delivered to synchronous subscribers only.
+func (b *Bus) Publish(event Event) {
+	b.mutex.RLock()
+	handlers := b.sync
+	subscribers := b.async
+	b.mutex.RUnlock()
+
+	for _, handler := range handlers {
+		deliver(handler, event)
+	}
+	worker := workerFor(event.Key())
+	for _, subscriber := range subscribers {
+		subscriber.queues[worker].push(event)
+	}
+}
+
+// Close waits until asynchronous subscribers handle all queued events.
+func (b *Bus) Close() {
+	b.mutex.Lock()
+	subscribers := b.async
+	b.async = nil
+	b.closed = true
+	b.mutex.Unlock()
+
+	for _, subscriber := range subscribers {
+		subscriber.close()
+	}
+}
+
+func workerFor(key string) int {
+	hash := fnv.New32a()
+	_, _ = hash.Write([]byte(key))
+	return int(hash.Sum32() % asyncWorkers)
+}
+
+// deliver calls the handler, recovering from its panic.
+func deliver(handler Handler, event Event) {
+	defer func() {
+		if r := recover(); r != nil {
+			log.Printf("Event handler panicked on %T with key %q: %v\n%s", event, event.Key(), r, debug.Stack())
+		}
+	}()
+	handler(event)
+}
+
+type asyncSubscriber struct {
+	queues []*queue
+	done   sync.WaitGroup
+}
+
+func newAsyncSubscriber(handler Handler) *asyncSubscriber {
+	subscriber := &asyncSubscriber{queues: make([]*queue, asyncWorkers)}
+	subscriber.done.Add(asyncWorkers)
+	for i := range subscriber.queues {
+		subscriber.queues[i] = newQueue()
+		go func(queue *queue) {
+			defer subscriber.done.Done()
+			for {
+				events, ok := queue.pop()
+				if !ok {
+					return
+				}
+				for _, event := range events {
+					deliver(handler, event)
+				}
+			}
+		}(subscriber.queues[i])
+	}
+	return subscriber
+}
+
+func (s *asyncSubscriber) close() {
+	for _, queue := range s.queues {
+		queue.close()
+	}
+	s.done.Wait()
+}
+
+// queue is unbounded, so publishing never blocks, not even when a subscriber publishes events itself.
+type queue struct {
+	mutex  sync.Mutex
+	ready  *sync.Cond
+	events []Event
+	closed bool
+}
+
+func newQueue() *queue {
+	q := &queue{}
+	q.ready = sync.NewCond(&q.mutex)
+	return q
+}
+
+// push drops the event when the queue is closed.
+func (q *queue) push(event Event) {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+	if q.closed {
+		return
+	}
+	q.events = append(q.events, event)
+	q.ready.Signal()
+}
+
+// pop waits for queued events and takes all of them. It returns false once the queue is closed and empty.
+func (q *queue) pop() ([]Event, bool) {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+	for len(q.events) == 0 && !q.closed {
+		q.ready.Wait()
+	}
+	if len(q.events) == 0 {
+		return nil, false
+	}
+	events := q.events
+	q.events = nil
+	return events, true
+}
+
+func (q *queue) close() {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+	q.closed = true
+	q.ready.Broadcast()
+}
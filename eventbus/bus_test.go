@@ -0,0 +1,115 @@
+package eventbus_test
+
+import (
+	"demo-app-go/eventbus"
+	"github.com/stretchr/testify/require"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+)
+
+type testEvent struct {
+	key      string
+	sequence int
+}
+
+func (e testEvent) Key() string {
+	return e.key
+}
+
+func TestBus(t *testing.T) {
+	t.Run("delivers to synchronous subscribers before Publish returns", func(t *testing.T) {
+		t.Parallel()
+		bus := eventbus.NewBus()
+		var received []eventbus.Event
+		bus.Subscribe(func(event eventbus.Event) {
+			received = append(received, event)
+		})
+
+		bus.Publish(testEvent{key: "1", sequence: 1})
+		require.Equal(t, []eventbus.Event{testEvent{key: "1", sequence: 1}}, received)
+	})
+	t.Run("delivers to asynchronous subscribers in order per key", func(t *testing.T) {
+		t.Parallel()
+		bus := eventbus.NewBus()
+		var mutex sync.Mutex
+		received := map[string][]int{}
+		bus.SubscribeAsync(func(event eventbus.Event) {
+			mutex.Lock()
+			defer mutex.Unlock()
+			e := event.(testEvent)
+			received[e.key] = append(received[e.key], e.sequence)
+		})
+
+		var publishers sync.WaitGroup
+		for key := 0; key < 10; key++ {
+			publishers.Add(1)
+			go func(key string) {
+				defer publishers.Done()
+				for sequence := 0; sequence < 100; sequence++ {
+					bus.Publish(testEvent{key: key, sequence: sequence})
+				}
+			}(strconv.Itoa(key))
+		}
+		publishers.Wait()
+		bus.Close()
+
+		require.Len(t, received, 10)
+		for key, sequences := range received {
+			require.Len(t, sequences, 100, key)
+			for i, sequence := range sequences {
+				require.Equal(t, i, sequence, key)
+			}
+		}
+	})
+	t.Run("isolates panicking subscribers", func(t *testing.T) {
+		t.Parallel()
+		bus := eventbus.NewBus()
+		panicking := func(event eventbus.Event) {
+			panic("subscriber failed")
+		}
+		bus.Subscribe(panicking)
+		bus.SubscribeAsync(panicking)
+		syncCount := 0
+		bus.Subscribe(func(event eventbus.Event) {
+			syncCount++
+		})
+		asyncCount := 0
+		bus.SubscribeAsync(func(event eventbus.Event) {
+			asyncCount++
+		})
+
+		require.NotPanics(t, func() {
+			bus.Publish(testEvent{key: "1"})
+			bus.Publish(testEvent{key: "1"})
+		})
+		bus.Close()
+		require.Equal(t, 2, syncCount)
+		require.Equal(t, 2, asyncCount)
+	})
+	t.Run("allows subscribers to publish", func(t *testing.T) {
+		t.Parallel()
+		bus := eventbus.NewBus()
+		var mutex sync.Mutex
+		var received []int
+		bus.SubscribeAsync(func(event eventbus.Event) {
+			e := event.(testEvent)
+			mutex.Lock()
+			received = append(received, e.sequence)
+			mutex.Unlock()
+			if e.sequence < 3 {
+				bus.Publish(testEvent{key: e.key, sequence: e.sequence + 1})
+			}
+		})
+
+		bus.Publish(testEvent{key: "1", sequence: 1})
+		require.Eventually(t, func() bool {
+			mutex.Lock()
+			defer mutex.Unlock()
+			return len(received) == 3
+		}, time.Second, time.Millisecond)
+		bus.Close()
+		require.Equal(t, []int{1, 2, 3}, received)
+	})
+}
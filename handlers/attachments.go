@@ -0,0 +1,179 @@
+package handlers
+
+import (
+	"demo-app-go/task"
+	"errors"
+	"fmt"
+	"github.com/labstack/echo/v4"
+	"io"
+	"mime"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+type attachmentService interface {
+	ListAttachments(taskID task.ID) ([]task.Attachment, error)
+	GetAttachment(taskID task.ID, id task.AttachmentID) (task.Attachment, error)
+	AddAttachment(taskID task.ID, fileName string, content io.Reader) (task.Attachment, bool, error)
+	OpenAttachment(taskID task.ID, id task.AttachmentID) (task.Attachment, io.ReadSeekCloser, error)
+	DeleteAttachment(taskID task.ID, id task.AttachmentID) error
+}
+
+type AttachmentHandler struct {
+	service attachmentService
+	// maxSize rejects too large uploads before they are read, see task.AttachmentPolicy.
+	maxSize int64
+}
+
+func NewAttachmentHandler(service attachmentService, maxSize int64) *AttachmentHandler {
+	return &AttachmentHandler{service: service, maxSize: maxSize}
+}
+
+type attachmentResponse struct {
+	Id          task.AttachmentID `json:"id"`
+	TaskId      task.ID           `json:"taskId"`
+	FileName    string            `json:"fileName"`
+	ContentType string            `json:"contentType"`
+	Size        int64             `json:"size"`
+	Hash        string            `json:"hash"`
+	CreatedAt   time.Time         `json:"createdAt"`
+}
+
+func (h *AttachmentHandler) List(c echo.Context) error {
+	taskId, err := getTaskId(c)
+	if err != nil {
+		return err
+	}
+
+	attachments, err := h.service.ListAttachments(taskId)
+	if err != nil {
+		return taskError(c, err)
+	}
+
+	result := make([]attachmentResponse, len(attachments))
+	for i, attachment := range attachments {
+		result[i] = createAttachmentResponse(attachment)
+	}
+
+	return c.JSON(http.StatusOK, result)
+}
+
+func (h *AttachmentHandler) Get(c echo.Context) error {
+	taskId, id, err := getAttachmentId(c)
+	if err != nil {
+		return err
+	}
+
+	attachment, err := h.service.GetAttachment(taskId, id)
+	if err != nil {
+		return taskError(c, err)
+	}
+
+	return c.JSON(http.StatusOK, createAttachmentResponse(attachment))
+}
+
+// multipartOverhead leaves room for boundaries and headers of the multipart form around the file.
+const multipartOverhead = 64 << 10
+
+// Add expects multipart form with the file in "file" field.
+// Uploading the same content to the task again returns the existing attachment with 200 instead of 201.
+func (h *AttachmentHandler) Add(c echo.Context) error {
+	taskId, err := getTaskId(c)
+	if err != nil {
+		return err
+	}
+
+	c.Request().Body = http.MaxBytesReader(c.Response(), c.Request().Body, h.maxSize+multipartOverhead)
+	header, err := c.FormFile("file")
+	var tooLarge *http.MaxBytesError
+	if errors.As(err, &tooLarge) {
+		return taskError(c, fmt.Errorf("%w: maximum size is %d bytes", task.ErrAttachmentTooLarge, h.maxSize))
+	}
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+	if header.Size > h.maxSize {
+		return taskError(c, fmt.Errorf("%w: maximum size is %d bytes", task.ErrAttachmentTooLarge, h.maxSize))
+	}
+	file, err := header.Open()
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	attachment, created, err := h.service.AddAttachment(taskId, header.Filename, file)
+	if err != nil {
+		return taskError(c, err)
+	}
+
+	status := http.StatusOK
+	if created {
+		status = http.StatusCreated
+	}
+	return c.JSON(status, createAttachmentResponse(attachment))
+}
+
+// Download sends the content. Range requests and conditional requests are handled by http.ServeContent,
+// with the content hash serving as ETag.
+func (h *AttachmentHandler) Download(c echo.Context) error {
+	taskId, id, err := getAttachmentId(c)
+	if err != nil {
+		return err
+	}
+
+	attachment, content, err := h.service.OpenAttachment(taskId, id)
+	if err != nil {
+		return taskError(c, err)
+	}
+	defer content.Close()
+
+	header := c.Response().Header()
+	header.Set(echo.HeaderContentType, attachment.ContentType())
+	header.Set(
+		echo.HeaderContentDisposition,
+		mime.FormatMediaType("attachment", map[string]string{"filename": attachment.FileName()}),
+	)
+	header.Set("ETag", strconv.Quote(attachment.Hash()))
+	http.ServeContent(c.Response(), c.Request(), attachment.FileName(), attachment.CreatedAt(), content)
+
+	return nil
+}
+
+func (h *AttachmentHandler) Delete(c echo.Context) error {
+	taskId, id, err := getAttachmentId(c)
+	if err != nil {
+		return err
+	}
+
+	err = h.service.DeleteAttachment(taskId, id)
+	if err != nil {
+		return taskError(c, err)
+	}
+
+	return c.NoContent(http.StatusNoContent)
+}
+
+func getAttachmentId(c echo.Context) (task.ID, task.AttachmentID, error) {
+	taskId, err := getTaskId(c)
+	if err != nil {
+		return 0, 0, err
+	}
+	id, err := strconv.ParseUint(c.Param("attachmentId"), 10, 64)
+	if err != nil {
+		return 0, 0, err
+	}
+	return taskId, task.AttachmentID(id), nil
+}
+
+func createAttachmentResponse(attachment task.Attachment) attachmentResponse {
+	return attachmentResponse{
+		Id:          attachment.Id(),
+		TaskId:      attachment.TaskID(),
+		FileName:    attachment.FileName(),
+		ContentType: attachment.ContentType(),
+		Size:        attachment.Size(),
+		Hash:        attachment.Hash(),
+		CreatedAt:   attachment.CreatedAt(),
+	}
+}
@@ -0,0 +1,182 @@
+package handlers
+
+import (
+	"demo-app-go/task"
+	"github.com/labstack/echo/v4"
+	"net/http"
+	"strconv"
+)
+
+type checklistService interface {
+	GetTask(id task.ID) (task.Task, error)
+	AddChecklistItem(id task.ID, text string, actor string) (task.Task, task.ChecklistItem, error)
+	ToggleChecklistItem(id task.ID, itemID task.ChecklistItemID, actor string) (task.Task, error)
+	MoveChecklistItem(id task.ID, itemID task.ChecklistItemID, position int, actor string) (task.Task, error)
+	RemoveChecklistItem(id task.ID, itemID task.ChecklistItemID, actor string) (task.Task, error)
+}
+
+// ChecklistHandler responds with the whole checklist of the task, as any change of an item affects its completion.
+type ChecklistHandler struct {
+	service checklistService
+}
+
+func NewChecklistHandler(service checklistService) *ChecklistHandler {
+	return &ChecklistHandler{service: service}
+}
+
+type checklistItemResponse struct {
+	Id       task.ChecklistItemID `json:"id"`
+	Text     string               `json:"text"`
+	Done     bool                 `json:"done"`
+	Position int                  `json:"position"`
+}
+
+type checklistResponse struct {
+	Items []checklistItemResponse `json:"items"`
+	// Completion is percentage of done items, null when there are no items.
+	Completion *int `json:"completion"`
+}
+
+func (h *ChecklistHandler) Get(c echo.Context) error {
+	id, err := getTaskId(c)
+	if err != nil {
+		return err
+	}
+
+	entity, err := h.service.GetTask(id)
+	if err != nil {
+		return taskError(c, err)
+	}
+	setETag(c, entity)
+
+	return c.JSON(http.StatusOK, createChecklistResponse(entity))
+}
+
+type checklistItemRequest struct {
+	Text string `json:"text" validate:"required"`
+}
+
+func (h *ChecklistHandler) Add(c echo.Context) error {
+	id, err := getTaskId(c)
+	if err != nil {
+		return err
+	}
+
+	data := &checklistItemRequest{}
+	err = c.Bind(data)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+	err = c.Validate(data)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+
+	entity, _, err := h.service.AddChecklistItem(id, data.Text, getUser(c))
+	if err != nil {
+		return taskError(c, err)
+	}
+	setETag(c, entity)
+
+	return c.JSON(http.StatusCreated, createChecklistResponse(entity))
+}
+
+func (h *ChecklistHandler) Toggle(c echo.Context) error {
+	id, itemId, err := getChecklistItemId(c)
+	if err != nil {
+		return err
+	}
+
+	entity, err := h.service.ToggleChecklistItem(id, itemId, getUser(c))
+	if err != nil {
+		return taskError(c, err)
+	}
+	setETag(c, entity)
+
+	return c.JSON(http.StatusOK, createChecklistResponse(entity))
+}
+
+type moveChecklistItemRequest struct {
+	// Position is a pointer, so the first position passes the required validation.
+	Position *int `json:"position" validate:"required"`
+}
+
+func (h *ChecklistHandler) Move(c echo.Context) error {
+	id, itemId, err := getChecklistItemId(c)
+	if err != nil {
+		return err
+	}
+
+	data := &moveChecklistItemRequest{}
+	err = c.Bind(data)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+	err = c.Validate(data)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+
+	entity, err := h.service.MoveChecklistItem(id, itemId, *data.Position, getUser(c))
+	if err != nil {
+		return taskError(c, err)
+	}
+	setETag(c, entity)
+
+	return c.JSON(http.StatusOK, createChecklistResponse(entity))
+}
+
+func (h *ChecklistHandler) Delete(c echo.Context) error {
+	id, itemId, err := getChecklistItemId(c)
+	if err != nil {
+		return err
+	}
+
+	entity, err := h.service.RemoveChecklistItem(id, itemId, getUser(c))
+	if err != nil {
+		return taskError(c, err)
+	}
+	setETag(c, entity)
+
+	return c.NoContent(http.StatusNoContent)
+}
+
+func getChecklistItemId(c echo.Context) (task.ID, task.ChecklistItemID, error) {
+	id, err := getTaskId(c)
+	if err != nil {
+		return 0, 0, err
+	}
+	itemId, err := strconv.ParseUint(c.Param("itemId"), 10, 64)
+	if err != nil {
+		return 0, 0, err
+	}
+	return id, task.ChecklistItemID(itemId), nil
+}
+
+func createChecklistResponse(entity task.Task) checklistResponse {
+	return checklistResponse{
+		Items:      createChecklistItemResponses(entity.Checklist()),
+		Completion: checklistCompletion(entity),
+	}
+}
+
+func createChecklistItemResponses(items []task.ChecklistItem) []checklistItemResponse {
+	result := make([]checklistItemResponse, len(items))
+	for i, item := range items {
+		result[i] = checklistItemResponse{
+			Id:       item.Id(),
+			Text:     item.Text(),
+			Done:     item.Done(),
+			Position: item.Position(),
+		}
+	}
+	return result
+}
+
+func checklistCompletion(entity task.Task) *int {
+	completion, ok := entity.ChecklistCompletion()
+	if !ok {
+		return nil
+	}
+	return &completion
+}
@@ -0,0 +1,202 @@
+package handlers
+
+import (
+	"demo-app-go/task"
+	"fmt"
+	"github.com/labstack/echo/v4"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// userHeader identifies the user making the request. There is no authentication, so it is trusted as it is.
+	userHeader    = "X-User"
+	anonymousUser = "anonymous"
+)
+
+type commentService interface {
+	ListComments(taskID task.ID, page task.Page) ([]task.Comment, int, error)
+	GetComment(taskID task.ID, id task.CommentID) (task.Comment, error)
+	AddComment(command task.AddCommentCommand) (task.Comment, error)
+	EditComment(taskID task.ID, id task.CommentID, author string, body string) (task.Comment, error)
+	DeleteComment(taskID task.ID, id task.CommentID, author string) error
+}
+
+type CommentHandler struct {
+	service commentService
+}
+
+func NewCommentHandler(service commentService) *CommentHandler {
+	return &CommentHandler{service: service}
+}
+
+type commentResponse struct {
+	Id        task.CommentID `json:"id"`
+	TaskId    task.ID        `json:"taskId"`
+	Author    string         `json:"author"`
+	Body      string         `json:"body"`
+	CreatedAt time.Time      `json:"createdAt"`
+	EditedAt  *time.Time     `json:"editedAt"`
+}
+
+// List returns comments oldest first, e.g. ?offset=20&limit=20. Count of all comments is sent in X-Total-Count header.
+func (h *CommentHandler) List(c echo.Context) error {
+	taskId, err := getTaskId(c)
+	if err != nil {
+		return err
+	}
+	page, err := getPage(c)
+	if err != nil {
+		return taskError(c, err)
+	}
+
+	comments, total, err := h.service.ListComments(taskId, page)
+	if err != nil {
+		return taskError(c, err)
+	}
+
+	result := make([]commentResponse, len(comments))
+	for i, comment := range comments {
+		result[i] = createCommentResponse(comment)
+	}
+	c.Response().Header().Set("X-Total-Count", strconv.Itoa(total))
+
+	return c.JSON(http.StatusOK, result)
+}
+
+func (h *CommentHandler) Get(c echo.Context) error {
+	taskId, id, err := getCommentId(c)
+	if err != nil {
+		return err
+	}
+
+	comment, err := h.service.GetComment(taskId, id)
+	if err != nil {
+		return taskError(c, err)
+	}
+
+	return c.JSON(http.StatusOK, createCommentResponse(comment))
+}
+
+type commentRequest struct {
+	Body string `json:"body" validate:"required"`
+}
+
+func (h *CommentHandler) Add(c echo.Context) error {
+	taskId, err := getTaskId(c)
+	if err != nil {
+		return err
+	}
+
+	data := &commentRequest{}
+	err = c.Bind(data)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+	err = c.Validate(data)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+
+	command, err := task.NewAddCommentCommand(taskId, getUser(c), data.Body)
+	if err != nil {
+		return taskError(c, err)
+	}
+	comment, err := h.service.AddComment(command)
+	if err != nil {
+		return taskError(c, err)
+	}
+
+	return c.JSON(http.StatusCreated, createCommentResponse(comment))
+}
+
+func (h *CommentHandler) Update(c echo.Context) error {
+	taskId, id, err := getCommentId(c)
+	if err != nil {
+		return err
+	}
+
+	data := &commentRequest{}
+	err = c.Bind(data)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+	err = c.Validate(data)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+
+	comment, err := h.service.EditComment(taskId, id, getUser(c), data.Body)
+	if err != nil {
+		return taskError(c, err)
+	}
+
+	return c.JSON(http.StatusOK, createCommentResponse(comment))
+}
+
+func (h *CommentHandler) Delete(c echo.Context) error {
+	taskId, id, err := getCommentId(c)
+	if err != nil {
+		return err
+	}
+
+	err = h.service.DeleteComment(taskId, id, getUser(c))
+	if err != nil {
+		return taskError(c, err)
+	}
+
+	return c.NoContent(http.StatusNoContent)
+}
+
+func getCommentId(c echo.Context) (task.ID, task.CommentID, error) {
+	taskId, err := getTaskId(c)
+	if err != nil {
+		return 0, 0, err
+	}
+	id, err := strconv.ParseUint(c.Param("commentId"), 10, 64)
+	if err != nil {
+		return 0, 0, err
+	}
+	return taskId, task.CommentID(id), nil
+}
+
+func getUser(c echo.Context) string {
+	user := strings.TrimSpace(c.Request().Header.Get(userHeader))
+	if user == "" {
+		return anonymousUser
+	}
+	return user
+}
+
+// getPage reads task.Page from offset and limit query parameters, both optional.
+func getPage(c echo.Context) (task.Page, error) {
+	offset := 0
+	limit := task.DefaultPageLimit
+	var err error
+	if value := c.QueryParam("offset"); value != "" {
+		offset, err = strconv.Atoi(value)
+		if err != nil {
+			return task.Page{}, fmt.Errorf("%w: offset must be a number", task.ErrValidation)
+		}
+	}
+	if value := c.QueryParam("limit"); value != "" {
+		limit, err = strconv.Atoi(value)
+		if err != nil {
+			return task.Page{}, fmt.Errorf("%w: limit must be a number", task.ErrValidation)
+		}
+	}
+	return task.NewPage(offset, limit)
+}
+
+func createCommentResponse(comment task.Comment) commentResponse {
+	return commentResponse{
+		Id:        comment.Id(),
+		TaskId:    comment.TaskID(),
+		Author:    comment.Author(),
+		Body:      comment.Body(),
+		CreatedAt: comment.CreatedAt(),
+		EditedAt:  comment.EditedAt(),
+	}
+}
@@ -0,0 +1,182 @@
+package handlers
+
+import (
+	"demo-app-go/task"
+	"fmt"
+	"github.com/labstack/echo/v4"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+type historyService interface {
+	ListRevisions(id task.ID) ([]task.Revision, error)
+	GetRevision(id task.ID, number task.Version) (task.Revision, error)
+	DiffRevisions(id task.ID, from task.Version, to task.Version) ([]task.FieldChange, error)
+	RevertTask(id task.ID, number task.Version, actor string) (task.Task, error)
+}
+
+type HistoryHandler struct {
+	service historyService
+}
+
+func NewHistoryHandler(service historyService) *HistoryHandler {
+	return &HistoryHandler{service: service}
+}
+
+type fieldChangeResponse struct {
+	Field  string `json:"field"`
+	Before any    `json:"before"`
+	After  any    `json:"after"`
+}
+
+type revisionStateResponse struct {
+	Title       string          `json:"title"`
+	Description string          `json:"description"`
+	Status      string          `json:"status"`
+	DueAt       *time.Time      `json:"dueAt"`
+	ParentId    *task.ID        `json:"parentId"`
+	ProjectId   *task.ProjectID `json:"projectId"`
+	ColumnId    *task.ColumnID  `json:"columnId"`
+	Tags        []string        `json:"tags"`
+	Recurrence  *string         `json:"recurrence"`
+	Checklist   []string        `json:"checklist"`
+	Deleted     bool            `json:"deleted"`
+}
+
+type revisionResponse struct {
+	Number    task.Version          `json:"number"`
+	Actor     string                `json:"actor"`
+	CreatedAt time.Time             `json:"createdAt"`
+	Changes   []fieldChangeResponse `json:"changes"`
+	State     revisionStateResponse `json:"state"`
+}
+
+// List returns revisions of the task oldest first.
+func (h *HistoryHandler) List(c echo.Context) error {
+	id, err := getTaskId(c)
+	if err != nil {
+		return err
+	}
+
+	revisions, err := h.service.ListRevisions(id)
+	if err != nil {
+		return taskError(c, err)
+	}
+
+	result := make([]revisionResponse, len(revisions))
+	for i, revision := range revisions {
+		result[i] = createRevisionResponse(revision)
+	}
+
+	return c.JSON(http.StatusOK, result)
+}
+
+func (h *HistoryHandler) Get(c echo.Context) error {
+	id, err := getTaskId(c)
+	if err != nil {
+		return err
+	}
+	number, err := getRevisionNumber(c.Param("rev"))
+	if err != nil {
+		return taskError(c, err)
+	}
+
+	revision, err := h.service.GetRevision(id, number)
+	if err != nil {
+		return taskError(c, err)
+	}
+
+	return c.JSON(http.StatusOK, createRevisionResponse(revision))
+}
+
+// Diff compares two revisions field by field, e.g. ?from=2&to=5.
+func (h *HistoryHandler) Diff(c echo.Context) error {
+	id, err := getTaskId(c)
+	if err != nil {
+		return err
+	}
+	from, err := getRevisionNumber(c.QueryParam("from"))
+	if err != nil {
+		return taskError(c, err)
+	}
+	to, err := getRevisionNumber(c.QueryParam("to"))
+	if err != nil {
+		return taskError(c, err)
+	}
+
+	changes, err := h.service.DiffRevisions(id, from, to)
+	if err != nil {
+		return taskError(c, err)
+	}
+
+	return c.JSON(http.StatusOK, createFieldChangeResponses(changes))
+}
+
+// Revert brings fields of the task back to the given revision, recording it as a new one.
+func (h *HistoryHandler) Revert(c echo.Context) error {
+	id, err := getTaskId(c)
+	if err != nil {
+		return err
+	}
+	number, err := getRevisionNumber(c.Param("rev"))
+	if err != nil {
+		return taskError(c, err)
+	}
+
+	entity, err := h.service.RevertTask(id, number, getUser(c))
+	if err != nil {
+		return taskError(c, err)
+	}
+	setETag(c, entity)
+
+	return c.JSON(http.StatusOK, createTaskResponse(entity))
+}
+
+func getRevisionNumber(value string) (task.Version, error) {
+	number, err := strconv.ParseUint(value, 10, 64)
+	if err != nil || number == 0 {
+		return 0, fmt.Errorf("%w: revision must be a positive number", task.ErrValidation)
+	}
+	return task.Version(number), nil
+}
+
+func createRevisionResponse(revision task.Revision) revisionResponse {
+	state := revision.State()
+	tags := state.Tags
+	if tags == nil {
+		tags = []string{}
+	}
+	checklist := state.Checklist
+	if checklist == nil {
+		checklist = []string{}
+	}
+
+	return revisionResponse{
+		Number:    revision.Number(),
+		Actor:     revision.Actor(),
+		CreatedAt: revision.CreatedAt(),
+		Changes:   createFieldChangeResponses(revision.Changes()),
+		State: revisionStateResponse{
+			Title:       state.Title,
+			Description: state.Description,
+			Status:      string(state.Status),
+			DueAt:       state.DueAt,
+			ParentId:    state.ParentID,
+			ProjectId:   state.ProjectID,
+			ColumnId:    state.ColumnID,
+			Tags:        tags,
+			Recurrence:  state.Recurrence,
+			Checklist:   checklist,
+			Deleted:     state.Deleted,
+		},
+	}
+}
+
+func createFieldChangeResponses(changes []task.FieldChange) []fieldChangeResponse {
+	result := make([]fieldChangeResponse, len(changes))
+	for i, change := range changes {
+		result[i] = fieldChangeResponse{Field: change.Field(), Before: change.Before(), After: change.After()}
+	}
+	return result
+}
@@ -0,0 +1,219 @@
+package handlers
+
+import (
+	"demo-app-go/task"
+	"github.com/labstack/echo/v4"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+type projectService interface {
+	ListProjects() ([]task.Project, error)
+	GetProject(id task.ProjectID) (task.Project, error)
+	AddProject(command task.AddProjectCommand) (task.Project, error)
+	UpdateProject(command task.UpdateProjectCommand) (task.Project, error)
+	DeleteProject(id task.ProjectID) error
+	GetBoard(id task.ProjectID) (task.Board, error)
+}
+
+type ProjectHandler struct {
+	service projectService
+}
+
+func NewProjectHandler(service projectService) *ProjectHandler {
+	return &ProjectHandler{service: service}
+}
+
+type columnResponse struct {
+	Id   task.ColumnID `json:"id"`
+	Name string        `json:"name"`
+	// Limit is the maximum number of tasks in the column, 0 when there is none.
+	Limit int `json:"limit"`
+}
+
+type projectResponse struct {
+	Id          task.ProjectID   `json:"id"`
+	Name        string           `json:"name"`
+	Description string           `json:"description"`
+	Columns     []columnResponse `json:"columns"`
+	CreatedAt   time.Time        `json:"createdAt"`
+	UpdatedAt   *time.Time       `json:"updatedAt"`
+}
+
+func (h *ProjectHandler) List(c echo.Context) error {
+	projects, err := h.service.ListProjects()
+	if err != nil {
+		return err
+	}
+
+	result := make([]projectResponse, len(projects))
+	for i, project := range projects {
+		result[i] = createProjectResponse(project)
+	}
+
+	return c.JSON(http.StatusOK, result)
+}
+
+func (h *ProjectHandler) Get(c echo.Context) error {
+	id, err := getProjectId(c)
+	if err != nil {
+		return err
+	}
+
+	project, err := h.service.GetProject(id)
+	if err != nil {
+		return taskError(c, err)
+	}
+
+	return c.JSON(http.StatusOK, createProjectResponse(project))
+}
+
+type columnRequest struct {
+	// Id refers to an existing column on update. New columns have no id.
+	Id    task.ColumnID `json:"id"`
+	Name  string        `json:"name"`
+	Limit int           `json:"limit"`
+}
+
+// projectRequest lists all columns of the project in board order.
+type projectRequest struct {
+	Name        string          `json:"name" validate:"required"`
+	Description string          `json:"description"`
+	Columns     []columnRequest `json:"columns" validate:"required"`
+}
+
+func (r *projectRequest) columns() []task.Column {
+	result := make([]task.Column, len(r.Columns))
+	for i, column := range r.Columns {
+		result[i] = task.NewColumn(column.Id, column.Name, column.Limit)
+	}
+	return result
+}
+
+func (h *ProjectHandler) Add(c echo.Context) error {
+	data := &projectRequest{}
+	err := c.Bind(data)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+	err = c.Validate(data)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+
+	command, err := task.NewAddProjectCommand(data.Name, data.Description, data.columns())
+	if err != nil {
+		return taskError(c, err)
+	}
+	project, err := h.service.AddProject(command)
+	if err != nil {
+		return taskError(c, err)
+	}
+
+	return c.JSON(http.StatusCreated, createProjectResponse(project))
+}
+
+// Update replaces the project along with its columns. Columns left out are removed, which requires them to be empty.
+func (h *ProjectHandler) Update(c echo.Context) error {
+	id, err := getProjectId(c)
+	if err != nil {
+		return err
+	}
+
+	data := &projectRequest{}
+	err = c.Bind(data)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+	err = c.Validate(data)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+
+	command, err := task.NewUpdateProjectCommand(id, data.Name, data.Description, data.columns())
+	if err != nil {
+		return taskError(c, err)
+	}
+	project, err := h.service.UpdateProject(command)
+	if err != nil {
+		return taskError(c, err)
+	}
+
+	return c.JSON(http.StatusOK, createProjectResponse(project))
+}
+
+func (h *ProjectHandler) Delete(c echo.Context) error {
+	id, err := getProjectId(c)
+	if err != nil {
+		return err
+	}
+
+	err = h.service.DeleteProject(id)
+	if err != nil {
+		return taskError(c, err)
+	}
+
+	return c.NoContent(http.StatusNoContent)
+}
+
+type boardColumnResponse struct {
+	columnResponse
+	Tasks []taskResponse `json:"tasks"`
+}
+
+type boardResponse struct {
+	Project projectResponse       `json:"project"`
+	Columns []boardColumnResponse `json:"columns"`
+}
+
+// Board returns columns of the project with their tasks, in the manual order.
+func (h *ProjectHandler) Board(c echo.Context) error {
+	id, err := getProjectId(c)
+	if err != nil {
+		return err
+	}
+
+	board, err := h.service.GetBoard(id)
+	if err != nil {
+		return taskError(c, err)
+	}
+
+	columns := make([]boardColumnResponse, len(board.Columns()))
+	for i, column := range board.Columns() {
+		columns[i] = boardColumnResponse{
+			columnResponse: createColumnResponse(column.Column()),
+			Tasks:          createTaskResponses(column.Tasks()),
+		}
+	}
+
+	return c.JSON(http.StatusOK, boardResponse{Project: createProjectResponse(board.Project()), Columns: columns})
+}
+
+func getProjectId(c echo.Context) (task.ProjectID, error) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		return 0, err
+	}
+	return task.ProjectID(id), nil
+}
+
+func createProjectResponse(project task.Project) projectResponse {
+	columns := make([]columnResponse, len(project.Columns()))
+	for i, column := range project.Columns() {
+		columns[i] = createColumnResponse(column)
+	}
+
+	return projectResponse{
+		Id:          project.Id(),
+		Name:        project.Name(),
+		Description: project.Description(),
+		Columns:     columns,
+		CreatedAt:   project.CreatedAt(),
+		UpdatedAt:   project.UpdatedAt(),
+	}
+}
+
+func createColumnResponse(column task.Column) columnResponse {
+	return columnResponse{Id: column.Id(), Name: column.Name(), Limit: column.Limit()}
+}
@@ -0,0 +1,87 @@
+package handlers
+
+import (
+	"demo-app-go/task"
+	"github.com/labstack/echo/v4"
+	"net/http"
+)
+
+type tagService interface {
+	ListTags() ([]task.TagUsage, error)
+	RenameTag(from string, to string) error
+	MergeTags(from string, into string) error
+}
+
+type TagHandler struct {
+	service tagService
+}
+
+func NewTagHandler(service tagService) *TagHandler {
+	return &TagHandler{service: service}
+}
+
+type tagResponse struct {
+	Name  string `json:"name"`
+	Count int    `json:"count"`
+}
+
+func (h *TagHandler) List(c echo.Context) error {
+	tags, err := h.service.ListTags()
+	if err != nil {
+		return err
+	}
+
+	result := make([]tagResponse, len(tags))
+	for i, tag := range tags {
+		result[i] = tagResponse{Name: tag.Name(), Count: tag.Count()}
+	}
+
+	return c.JSON(http.StatusOK, result)
+}
+
+type renameTagRequest struct {
+	Name string `json:"name" validate:"required"`
+}
+
+func (h *TagHandler) Rename(c echo.Context) error {
+	data := &renameTagRequest{}
+	err := c.Bind(data)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+	err = c.Validate(data)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+
+	err = h.service.RenameTag(c.Param("name"), data.Name)
+	if err != nil {
+		return taskError(c, err)
+	}
+
+	return c.NoContent(http.StatusNoContent)
+}
+
+type mergeTagRequest struct {
+	Into string `json:"into" validate:"required"`
+}
+
+// Merge moves all tasks labeled with the tag from path to the one from request body.
+func (h *TagHandler) Merge(c echo.Context) error {
+	data := &mergeTagRequest{}
+	err := c.Bind(data)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+	err = c.Validate(data)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+
+	err = h.service.MergeTags(c.Param("name"), data.Into)
+	if err != nil {
+		return taskError(c, err)
+	}
+
+	return c.NoContent(http.StatusNoContent)
+}
@@ -1,9 +1,9 @@
 package handlers
 
 import (
-	"demo-app-go/storage"
 	"demo-app-go/task"
 	"errors"
+	"fmt"
 	"github.com/labstack/echo/v4"
 	"net/http"
 	"strconv"
@@ -11,34 +11,75 @@ import (
 	"time"
 )
 
+type taskService interface {
+	ListTasks(filter task.ListFilter) ([]task.Task, error)
+	GetTask(id task.ID) (task.Task, error)
+	AddTask(command task.AddTaskCommand, actor string) (task.Task, error)
+	UpdateTask(command task.UpdateTaskCommand, actor string) (task.Task, error)
+	TransitionTask(id task.ID, status task.Status, actor string) (task.Task, error)
+	ListSubtasks(id task.ID) ([]task.Task, error)
+	GetTaskTree(id task.ID) (task.Tree, error)
+	PreviewOccurrences(id task.ID, limit int) ([]time.Time, error)
+	AddDependency(dependency task.Dependency) error
+	RemoveDependency(dependency task.Dependency) error
+	ListBlockers(id task.ID) ([]task.Task, error)
+	ListDependents(id task.ID) ([]task.Task, error)
+	ListActionableTasks() ([]task.Task, error)
+	DeleteTask(id task.ID, actor string) error
+	ListTrash() ([]task.Task, error)
+	RestoreTask(id task.ID, actor string) (task.Task, error)
+	MoveTask(command task.MoveTaskCommand, actor string) (task.Task, error)
+	CloneTask(id task.ID, options task.CloneOptions, actor string) (task.Clone, error)
+}
+
 type TaskHandler struct {
-	repository *storage.TaskRepository
+	service taskService
+	// requireIfMatch makes If-Match header mandatory for updates, so clients cannot overwrite changes unknowingly.
+	requireIfMatch bool
 }
 
-func NewTaskHandler(repository *storage.TaskRepository) *TaskHandler {
-	return &TaskHandler{repository: repository}
+func NewTaskHandler(service taskService, requireIfMatch bool) *TaskHandler {
+	return &TaskHandler{service: service, requireIfMatch: requireIfMatch}
 }
 
 type taskResponse struct {
 	Id          task.ID    `json:"id"`
 	Title       string     `json:"title"`
 	Description string     `json:"description"`
-	CreatedAt   time.Time  `json:"createdAt"`
-	UpdatedAt   *time.Time `json:"updatedAt"`
+	Status      string     `json:"status"`
+	DueAt       *time.Time `json:"dueAt"`
+	Timezone    *string    `json:"timezone"`
+	Overdue     bool       `json:"overdue"`
+	ParentId    *task.ID   `json:"parentId"`
+	// ProjectId and ColumnId are null for tasks outside of projects.
+	ProjectId  *task.ProjectID `json:"projectId"`
+	ColumnId   *task.ColumnID  `json:"columnId"`
+	Tags       []string        `json:"tags"`
+	Recurrence *string         `json:"recurrence"`
+	// Rank orders tasks manually, lists are sorted by it. It is meant for comparison only.
+	Rank      task.Rank               `json:"rank"`
+	Checklist []checklistItemResponse `json:"checklist"`
+	// ChecklistCompletion is percentage of done checklist items, null when there are none.
+	ChecklistCompletion *int         `json:"checklistCompletion"`
+	CommentCount        int          `json:"commentCount"`
+	Version             task.Version `json:"version"`
+	CreatedAt           time.Time    `json:"createdAt"`
+	UpdatedAt           *time.Time   `json:"updatedAt"`
+	DeletedAt           *time.Time   `json:"deletedAt"`
 }
 
 func (h *TaskHandler) List(c echo.Context) error {
-	entities, err := h.repository.List()
+	filter, err := getListFilter(c)
 	if err != nil {
-		return err
+		return taskError(c, err)
 	}
 
-	result := make([]taskResponse, len(entities))
-	for i, entity := range entities {
-		result[i] = createTaskResponse(entity)
+	entities, err := h.service.ListTasks(filter)
+	if err != nil {
+		return err
 	}
 
-	return c.JSON(http.StatusOK, result)
+	return c.JSON(http.StatusOK, createTaskResponses(entities))
 }
 
 func (h *TaskHandler) Get(c echo.Context) error {
@@ -47,20 +88,52 @@ func (h *TaskHandler) Get(c echo.Context) error {
 		return err
 	}
 
-	entity, err := h.repository.GetByID(id)
-	if errors.Is(err, storage.ErrResourceNotFound) {
-		return c.NoContent(http.StatusNotFound)
-	}
+	entity, err := h.service.GetTask(id)
 	if err != nil {
-		return err
+		return taskError(c, err)
 	}
+	setETag(c, entity)
 
 	return c.JSON(http.StatusOK, createTaskResponse(entity))
 }
 
 type taskRequest struct {
-	Title       string `json:"title" validate:"required,min=1"`
-	Description string `json:"description"`
+	Title       string     `json:"title" validate:"required,min=1"`
+	Description string     `json:"description"`
+	DueAt       *time.Time `json:"dueAt"`
+	// Timezone is IANA name, e.g. Europe/Warsaw. When omitted, offset of dueAt is kept.
+	Timezone string   `json:"timezone"`
+	ParentId *task.ID `json:"parentId"`
+	// ProjectId puts the task on the project's board, into ColumnId or the first column by default.
+	ProjectId *task.ProjectID `json:"projectId"`
+	ColumnId  *task.ColumnID  `json:"columnId"`
+	Tags      []string        `json:"tags"`
+	// Recurrence is RFC 5545 RRULE, e.g. FREQ=WEEKLY;BYDAY=MO. It requires due date.
+	Recurrence string `json:"recurrence"`
+}
+
+// dueAt returns due date in requested timezone.
+func (r *taskRequest) dueAt() (*time.Time, error) {
+	if r.DueAt == nil || r.Timezone == "" {
+		return r.DueAt, nil
+	}
+	location, err := time.LoadLocation(r.Timezone)
+	if err != nil {
+		return nil, echo.NewHTTPError(http.StatusBadRequest, "unknown timezone")
+	}
+	dueAt := r.DueAt.In(location)
+	return &dueAt, nil
+}
+
+func (r *taskRequest) recurrenceRule() (*task.RecurrenceRule, error) {
+	if r.Recurrence == "" {
+		return nil, nil
+	}
+	rule, err := task.ParseRecurrenceRule(r.Recurrence)
+	if err != nil {
+		return nil, err
+	}
+	return &rule, nil
 }
 
 func (h *TaskHandler) Add(c echo.Context) error {
@@ -77,20 +150,47 @@ func (h *TaskHandler) Add(c echo.Context) error {
 		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
 	}
 
-	command := task.NewAddTaskCommand(data.Title, data.Description)
-	entity, err := h.repository.Add(command)
+	dueAt, err := data.dueAt()
 	if err != nil {
 		return err
 	}
+	recurrenceRule, err := data.recurrenceRule()
+	if err != nil {
+		return taskError(c, err)
+	}
+	command, err := task.NewAddTaskCommand(
+		data.Title,
+		data.Description,
+		dueAt,
+		data.ParentId,
+		data.Tags,
+		recurrenceRule,
+		data.ProjectId,
+		data.ColumnId,
+	)
+	if err != nil {
+		return taskError(c, err)
+	}
+	entity, err := h.service.AddTask(command, getUser(c))
+	if err != nil {
+		return taskError(c, err)
+	}
+	setETag(c, entity)
 
 	return c.JSON(http.StatusCreated, createTaskResponse(entity))
 }
 
+// Update expects ETag of the task in If-Match header, e.g. If-Match: "3", or a list of them.
+// The header is optional, unless strict.
 func (h *TaskHandler) Update(c echo.Context) error {
 	id, err := getTaskId(c)
 	if err != nil {
 		return err
 	}
+	expectedVersions, err := h.getExpectedVersions(c)
+	if err != nil {
+		return taskError(c, err)
+	}
 
 	data := &taskRequest{}
 	err = c.Bind(data)
@@ -105,42 +205,332 @@ func (h *TaskHandler) Update(c echo.Context) error {
 		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
 	}
 
-	entity, err := h.repository.GetByID(id)
-	if errors.Is(err, storage.ErrResourceNotFound) {
-		return c.NoContent(http.StatusNotFound)
+	dueAt, err := data.dueAt()
+	if err != nil {
+		return err
+	}
+	recurrenceRule, err := data.recurrenceRule()
+	if err != nil {
+		return taskError(c, err)
+	}
+	command, err := task.NewUpdateTaskCommand(
+		id,
+		data.Title,
+		data.Description,
+		dueAt,
+		data.ParentId,
+		data.Tags,
+		recurrenceRule,
+		data.ProjectId,
+		data.ColumnId,
+		expectedVersions,
+	)
+	if err != nil {
+		return taskError(c, err)
+	}
+	entity, err := h.service.UpdateTask(command, getUser(c))
+	if err != nil {
+		return taskError(c, err)
 	}
+	setETag(c, entity)
+
+	return c.JSON(http.StatusOK, createTaskResponse(entity))
+}
+
+type transitionRequest struct {
+	Status string `json:"status" validate:"required"`
+}
+
+func (h *TaskHandler) Transition(c echo.Context) error {
+	id, err := getTaskId(c)
 	if err != nil {
 		return err
 	}
-	entity.Update(data.Title, data.Description)
-	err = h.repository.Save(entity)
-	if errors.Is(err, storage.ErrResourceNotFound) {
-		return c.NoContent(http.StatusNotFound)
+
+	data := &transitionRequest{}
+	err = c.Bind(data)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+	err = c.Validate(data)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
 	}
+
+	status, err := task.ParseStatus(strings.TrimSpace(data.Status))
+	if err != nil {
+		return taskError(c, err)
+	}
+	entity, err := h.service.TransitionTask(id, status, getUser(c))
+	if err != nil {
+		return taskError(c, err)
+	}
+	setETag(c, entity)
+
+	return c.JSON(http.StatusOK, createTaskResponse(entity))
+}
+
+// moveRequest needs a column or at least one anchor. The task is put right after the After task,
+// right before the Before one, or between both when they are adjacent.
+type moveRequest struct {
+	Column *task.ColumnID `json:"column"`
+	After  *task.ID       `json:"after"`
+	Before *task.ID       `json:"before"`
+}
+
+// Move changes the manual order of the task, or moves it to another column of its project.
+// Only moving to another column changes the version of the task.
+func (h *TaskHandler) Move(c echo.Context) error {
+	id, err := getTaskId(c)
 	if err != nil {
 		return err
 	}
 
+	data := &moveRequest{}
+	err = c.Bind(data)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+
+	command, err := task.NewMoveTaskCommand(id, data.Column, data.After, data.Before)
+	if err != nil {
+		return taskError(c, err)
+	}
+	entity, err := h.service.MoveTask(command, getUser(c))
+	if err != nil {
+		return taskError(c, err)
+	}
+	setETag(c, entity)
+
 	return c.JSON(http.StatusOK, createTaskResponse(entity))
 }
 
-func (h *TaskHandler) Delete(c echo.Context) error {
+// cloneRequest chooses what is copied along with the title, due date, recurrence and placement. All default to false.
+type cloneRequest struct {
+	Description bool `json:"description"`
+	Tags        bool `json:"tags"`
+	Checklist   bool `json:"checklist"`
+	Subtasks    bool `json:"subtasks"`
+	Comments    bool `json:"comments"`
+	Attachments bool `json:"attachments"`
+}
+
+type cloneResponse struct {
+	Task taskTreeResponse `json:"task"`
+	// Ids maps ids of the original tasks to ids of their copies.
+	Ids map[task.ID]task.ID `json:"ids"`
+}
+
+// Clone copies the task, along with its subtasks when requested.
+func (h *TaskHandler) Clone(c echo.Context) error {
 	id, err := getTaskId(c)
 	if err != nil {
 		return err
 	}
 
-	err = h.repository.Delete(id)
-	if errors.Is(err, storage.ErrResourceNotFound) {
-		return c.NoContent(http.StatusNotFound)
+	data := &cloneRequest{}
+	err = c.Bind(data)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+
+	clone, err := h.service.CloneTask(id, task.CloneOptions{
+		Description: data.Description,
+		Tags:        data.Tags,
+		Checklist:   data.Checklist,
+		Subtasks:    data.Subtasks,
+		Comments:    data.Comments,
+		Attachments: data.Attachments,
+	}, getUser(c))
+	if err != nil {
+		return taskError(c, err)
 	}
+	setETag(c, clone.Tree().Task())
+
+	return c.JSON(http.StatusCreated, cloneResponse{Task: createTaskTreeResponse(clone.Tree()), Ids: clone.IDs()})
+}
+
+func (h *TaskHandler) Subtasks(c echo.Context) error {
+	id, err := getTaskId(c)
 	if err != nil {
 		return err
 	}
 
+	entities, err := h.service.ListSubtasks(id)
+	if err != nil {
+		return taskError(c, err)
+	}
+
+	return c.JSON(http.StatusOK, createTaskResponses(entities))
+}
+
+type taskTreeResponse struct {
+	taskResponse
+	Progress float64            `json:"progress"`
+	Subtasks []taskTreeResponse `json:"subtasks"`
+}
+
+func (h *TaskHandler) Tree(c echo.Context) error {
+	id, err := getTaskId(c)
+	if err != nil {
+		return err
+	}
+
+	tree, err := h.service.GetTaskTree(id)
+	if err != nil {
+		return taskError(c, err)
+	}
+
+	return c.JSON(http.StatusOK, createTaskTreeResponse(tree))
+}
+
+const maxPreviewedOccurrences = 100
+
+// Occurrences previews due dates of upcoming occurrences of the recurring task, e.g. ?count=10.
+func (h *TaskHandler) Occurrences(c echo.Context) error {
+	id, err := getTaskId(c)
+	if err != nil {
+		return err
+	}
+	count := 5
+	if value := c.QueryParam("count"); value != "" {
+		count, err = strconv.Atoi(value)
+		if err != nil || count < 1 || count > maxPreviewedOccurrences {
+			return echo.NewHTTPError(
+				http.StatusBadRequest,
+				fmt.Sprintf("count must be a number from 1 to %d", maxPreviewedOccurrences),
+			)
+		}
+	}
+
+	occurrences, err := h.service.PreviewOccurrences(id, count)
+	if err != nil {
+		return taskError(c, err)
+	}
+
+	return c.JSON(http.StatusOK, occurrences)
+}
+
+func (h *TaskHandler) Delete(c echo.Context) error {
+	id, err := getTaskId(c)
+	if err != nil {
+		return err
+	}
+
+	err = h.service.DeleteTask(id, getUser(c))
+	if err != nil {
+		return taskError(c, err)
+	}
+
 	return c.NoContent(http.StatusNoContent)
 }
 
+// errIfMatchRequired is sent as 428 Precondition Required.
+var errIfMatchRequired = errors.New("If-Match header is required")
+
+// getExpectedVersions reads the versions from If-Match header, e.g. If-Match: "3", "4". Wildcard matches any
+// version. Weak entity tags, e.g. W/"3", never match, as If-Match compares tags strongly. Malformed values cannot
+// match any version either, so they are reported as ErrVersionMismatch.
+func (h *TaskHandler) getExpectedVersions(c echo.Context) ([]task.Version, error) {
+	value := strings.TrimSpace(c.Request().Header.Get("If-Match"))
+	if value == "" {
+		if h.requireIfMatch {
+			return nil, errIfMatchRequired
+		}
+		return nil, nil
+	}
+	if value == "*" {
+		return nil, nil
+	}
+	tags, err := parseEntityTags(value)
+	if err != nil {
+		return nil, fmt.Errorf("%w: malformed If-Match", task.ErrVersionMismatch)
+	}
+	var versions []task.Version
+	for _, tag := range tags {
+		if tag.weak {
+			continue
+		}
+		version, err := strconv.ParseUint(tag.value, 10, 64)
+		if err != nil {
+			// Not an ETag of this server, so it matches no version.
+			continue
+		}
+		versions = append(versions, task.Version(version))
+	}
+	if len(versions) == 0 {
+		return nil, fmt.Errorf("%w: If-Match has no strong entity tag of a version", task.ErrVersionMismatch)
+	}
+	return versions, nil
+}
+
+type entityTag struct {
+	value string
+	weak  bool
+}
+
+// parseEntityTags reads comma separated list of entity tags, e.g. "3", W/"4".
+func parseEntityTags(value string) ([]entityTag, error) {
+	var tags []entityTag
+	for {
+		value = strings.TrimLeft(value, " \t,")
+		if value == "" {
+			break
+		}
+		tag := entityTag{}
+		if strings.HasPrefix(value, "W/") {
+			tag.weak = true
+			value = value[len("W/"):]
+		}
+		if !strings.HasPrefix(value, `"`) {
+			return nil, errors.New("entity tag must be quoted")
+		}
+		end := strings.IndexByte(value[1:], '"')
+		if end < 0 {
+			return nil, errors.New("entity tag is not closed")
+		}
+		tag.value = value[1 : end+1]
+		value = strings.TrimLeft(value[end+2:], " \t")
+		if value != "" && value[0] != ',' {
+			return nil, errors.New("entity tags must be separated by comma")
+		}
+		tags = append(tags, tag)
+	}
+	if len(tags) == 0 {
+		return nil, errors.New("no entity tag")
+	}
+	return tags, nil
+}
+
+func setETag(c echo.Context, entity task.Task) {
+	c.Response().Header().Set("ETag", strconv.Quote(strconv.FormatUint(uint64(entity.Version()), 10)))
+}
+
+// Trash lists deleted tasks, which can be restored until they are purged.
+func (h *TaskHandler) Trash(c echo.Context) error {
+	entities, err := h.service.ListTrash()
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, createTaskResponses(entities))
+}
+
+func (h *TaskHandler) Restore(c echo.Context) error {
+	id, err := getTaskId(c)
+	if err != nil {
+		return err
+	}
+
+	entity, err := h.service.RestoreTask(id, getUser(c))
+	if err != nil {
+		return taskError(c, err)
+	}
+	setETag(c, entity)
+
+	return c.JSON(http.StatusOK, createTaskResponse(entity))
+}
+
 func getTaskId(c echo.Context) (task.ID, error) {
 	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
 	if err != nil {
@@ -149,12 +539,169 @@ func getTaskId(c echo.Context) (task.ID, error) {
 	return task.ID(id), nil
 }
 
+// getListFilter reads task.ListFilter from query parameters,
+// e.g. ?status=todo&status=in_progress&dueBefore=2023-01-31T00:00:00Z&overdue=true&tag=a&tag=b&tagMatch=any.
+// Tags are matched all at once by default. Tasks of a project, or of its column, are matched by ?project=1&column=2.
+func getListFilter(c echo.Context) (task.ListFilter, error) {
+	filter := task.ListFilter{}
+	for _, value := range c.QueryParams()["status"] {
+		status, err := task.ParseStatus(value)
+		if err != nil {
+			return task.ListFilter{}, err
+		}
+		filter.Statuses = append(filter.Statuses, status)
+	}
+
+	var err error
+	filter.DueBefore, err = getTimeQueryParam(c, "dueBefore")
+	if err != nil {
+		return task.ListFilter{}, err
+	}
+	filter.DueAfter, err = getTimeQueryParam(c, "dueAfter")
+	if err != nil {
+		return task.ListFilter{}, err
+	}
+	if value := c.QueryParam("overdue"); value != "" {
+		overdue, err := strconv.ParseBool(value)
+		if err != nil {
+			return task.ListFilter{}, fmt.Errorf("%w: overdue must be a boolean", task.ErrValidation)
+		}
+		if overdue {
+			now := time.Now()
+			filter.OverdueAt = &now
+		}
+	}
+
+	if value := c.QueryParam("project"); value != "" {
+		id, err := strconv.ParseUint(value, 10, 64)
+		if err != nil {
+			return task.ListFilter{}, fmt.Errorf("%w: project must be a number", task.ErrValidation)
+		}
+		projectID := task.ProjectID(id)
+		filter.ProjectID = &projectID
+	}
+	if value := c.QueryParam("column"); value != "" {
+		id, err := strconv.ParseUint(value, 10, 64)
+		if err != nil {
+			return task.ListFilter{}, fmt.Errorf("%w: column must be a number", task.ErrValidation)
+		}
+		columnID := task.ColumnID(id)
+		filter.ColumnID = &columnID
+	}
+
+	filter.Tags, err = task.NormalizeTags(c.QueryParams()["tag"])
+	if err != nil {
+		return task.ListFilter{}, err
+	}
+	switch c.QueryParam("tagMatch") {
+	case "", "all":
+	case "any":
+		filter.AnyTag = true
+	default:
+		return task.ListFilter{}, fmt.Errorf("%w: tagMatch must be either all or any", task.ErrValidation)
+	}
+
+	return filter, nil
+}
+
+func getTimeQueryParam(c echo.Context, name string) (*time.Time, error) {
+	value := c.QueryParam(name)
+	if value == "" {
+		return nil, nil
+	}
+	result, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s must be RFC 3339 time", task.ErrValidation, name)
+	}
+	return &result, nil
+}
+
+// taskError translates domain errors into HTTP responses. Unknown errors are passed to Echo as they are.
+func taskError(c echo.Context, err error) error {
+	switch {
+	case errors.Is(err, task.ErrNotFound):
+		return c.NoContent(http.StatusNotFound)
+	case errors.Is(err, task.ErrValidation):
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	case errors.Is(err, task.ErrInvalidTransition),
+		errors.Is(err, task.ErrHasSubtasks),
+		errors.Is(err, task.ErrBlocked),
+		errors.Is(err, task.ErrTagExists),
+		errors.Is(err, task.ErrTimerRunning),
+		errors.Is(err, task.ErrColumnFull),
+		errors.Is(err, task.ErrHasTasks):
+		return echo.NewHTTPError(http.StatusConflict, err.Error())
+	case errors.Is(err, task.ErrNotAuthor):
+		return echo.NewHTTPError(http.StatusForbidden, err.Error())
+	case errors.Is(err, task.ErrVersionMismatch):
+		return echo.NewHTTPError(http.StatusPreconditionFailed, err.Error())
+	case errors.Is(err, errIfMatchRequired):
+		return echo.NewHTTPError(http.StatusPreconditionRequired, err.Error())
+	case errors.Is(err, task.ErrAttachmentTooLarge):
+		return echo.NewHTTPError(http.StatusRequestEntityTooLarge, err.Error())
+	case errors.Is(err, task.ErrContentTypeNotAllowed):
+		return echo.NewHTTPError(http.StatusUnsupportedMediaType, err.Error())
+	default:
+		return err
+	}
+}
+
 func createTaskResponse(entity task.Task) taskResponse {
+	var recurrence *string
+	if entity.Recurrence() != nil {
+		rule := entity.Recurrence().Rule().String()
+		recurrence = &rule
+	}
+	var timezone *string
+	if entity.DueAt() != nil {
+		name := entity.DueAt().Location().String()
+		if name == "" {
+			name = entity.DueAt().Format("-07:00")
+		}
+		timezone = &name
+	}
+
 	return taskResponse{
-		Id:          entity.Id(),
-		Title:       entity.Title(),
-		Description: entity.Description(),
-		CreatedAt:   entity.CreatedAt(),
-		UpdatedAt:   entity.UpdatedAt(),
+		Id:                  entity.Id(),
+		Title:               entity.Title(),
+		Description:         entity.Description(),
+		Status:              string(entity.Status()),
+		DueAt:               entity.DueAt(),
+		Timezone:            timezone,
+		Overdue:             entity.IsOverdue(time.Now()),
+		ParentId:            entity.ParentID(),
+		ProjectId:           entity.ProjectID(),
+		ColumnId:            entity.ColumnID(),
+		Tags:                entity.Tags(),
+		Recurrence:          recurrence,
+		Rank:                entity.Rank(),
+		Checklist:           createChecklistItemResponses(entity.Checklist()),
+		ChecklistCompletion: checklistCompletion(entity),
+		CommentCount:        entity.CommentCount(),
+		Version:             entity.Version(),
+		CreatedAt:           entity.CreatedAt(),
+		UpdatedAt:           entity.UpdatedAt(),
+		DeletedAt:           entity.DeletedAt(),
+	}
+}
+
+func createTaskResponses(entities []task.Task) []taskResponse {
+	result := make([]taskResponse, len(entities))
+	for i, entity := range entities {
+		result[i] = createTaskResponse(entity)
+	}
+	return result
+}
+
+func createTaskTreeResponse(tree task.Tree) taskTreeResponse {
+	subtasks := make([]taskTreeResponse, len(tree.Subtasks()))
+	for i, subtask := range tree.Subtasks() {
+		subtasks[i] = createTaskTreeResponse(subtask)
+	}
+
+	return taskTreeResponse{
+		taskResponse: createTaskResponse(tree.Task()),
+		Progress:     tree.Progress(),
+		Subtasks:     subtasks,
 	}
 }
@@ -0,0 +1,101 @@
+package handlers
+
+import (
+	"demo-app-go/task"
+	"github.com/labstack/echo/v4"
+	"net/http"
+	"strconv"
+)
+
+type dependencyRequest struct {
+	BlockerId task.ID `json:"blockerId" validate:"required"`
+}
+
+// AddBlocker makes the task from request body block the task from path.
+func (h *TaskHandler) AddBlocker(c echo.Context) error {
+	id, err := getTaskId(c)
+	if err != nil {
+		return err
+	}
+
+	data := &dependencyRequest{}
+	err = c.Bind(data)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+	err = c.Validate(data)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+
+	dependency, err := task.NewDependency(data.BlockerId, id)
+	if err != nil {
+		return taskError(c, err)
+	}
+	err = h.service.AddDependency(dependency)
+	if err != nil {
+		return taskError(c, err)
+	}
+
+	return c.NoContent(http.StatusNoContent)
+}
+
+func (h *TaskHandler) RemoveBlocker(c echo.Context) error {
+	id, err := getTaskId(c)
+	if err != nil {
+		return err
+	}
+	blockerId, err := strconv.ParseUint(c.Param("blockerId"), 10, 64)
+	if err != nil {
+		return err
+	}
+
+	dependency, err := task.NewDependency(task.ID(blockerId), id)
+	if err != nil {
+		return taskError(c, err)
+	}
+	err = h.service.RemoveDependency(dependency)
+	if err != nil {
+		return taskError(c, err)
+	}
+
+	return c.NoContent(http.StatusNoContent)
+}
+
+func (h *TaskHandler) Blockers(c echo.Context) error {
+	id, err := getTaskId(c)
+	if err != nil {
+		return err
+	}
+
+	entities, err := h.service.ListBlockers(id)
+	if err != nil {
+		return taskError(c, err)
+	}
+
+	return c.JSON(http.StatusOK, createTaskResponses(entities))
+}
+
+func (h *TaskHandler) Dependents(c echo.Context) error {
+	id, err := getTaskId(c)
+	if err != nil {
+		return err
+	}
+
+	entities, err := h.service.ListDependents(id)
+	if err != nil {
+		return taskError(c, err)
+	}
+
+	return c.JSON(http.StatusOK, createTaskResponses(entities))
+}
+
+// Actionable lists open tasks which are not blocked, i.e. what can be worked on now.
+func (h *TaskHandler) Actionable(c echo.Context) error {
+	entities, err := h.service.ListActionableTasks()
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, createTaskResponses(entities))
+}
@@ -0,0 +1,110 @@
+package handlers_test
+
+import (
+	"demo-app-go/eventbus"
+	"demo-app-go/handlers"
+	"demo-app-go/storage"
+	"demo-app-go/task"
+	"errors"
+	"fmt"
+	"github.com/go-playground/validator/v10"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+type requestValidator struct {
+	validator *validator.Validate
+}
+
+func (v *requestValidator) Validate(i interface{}) error {
+	return v.validator.Struct(i)
+}
+
+func TestTaskHandler_Update(t *testing.T) {
+	setup := func(t *testing.T) (*handlers.TaskHandler, task.Task) {
+		memory := storage.NewMemory(eventbus.NewBus())
+		service := task.NewService(
+			storage.NewMemoryTaskRepository(memory),
+			storage.NewMemoryRevisionRepository(memory),
+			storage.NewMemoryProjectRepository(memory),
+			task.DeleteRefuse,
+		)
+		command, err := task.NewAddTaskCommand("title", "", nil, nil, nil, nil, nil, nil)
+		require.NoError(t, err, "create command")
+		entity, err := service.AddTask(command, "alice")
+		require.NoError(t, err, "add task")
+		return handlers.NewTaskHandler(service, true), entity
+	}
+	update := func(handler *handlers.TaskHandler, entity task.Task, ifMatch string) int {
+		e := echo.New()
+		e.Validator = &requestValidator{validator: validator.New()}
+		request := httptest.NewRequest(http.MethodPut, "/tasks/"+strconv.Itoa(int(entity.Id())), strings.NewReader(`{"title":"changed"}`))
+		request.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		request.Header.Set("If-Match", ifMatch)
+		recorder := httptest.NewRecorder()
+		c := e.NewContext(request, recorder)
+		c.SetParamNames("id")
+		c.SetParamValues(strconv.Itoa(int(entity.Id())))
+		err := handler.Update(c)
+		var httpError *echo.HTTPError
+		if errors.As(err, &httpError) {
+			return httpError.Code
+		}
+		return recorder.Code
+	}
+
+	samples := []struct {
+		name    string
+		ifMatch func(version task.Version) string
+		status  int
+	}{
+		{
+			name:    "updates on matching entity tag",
+			ifMatch: func(version task.Version) string { return fmt.Sprintf(`"%d"`, version) },
+			status:  http.StatusOK,
+		},
+		{
+			name:    "updates when any entity tag in the list matches",
+			ifMatch: func(version task.Version) string { return fmt.Sprintf(`"%d", "%d"`, version+5, version) },
+			status:  http.StatusOK,
+		},
+		{
+			name:    "updates when a strong entity tag in the list matches after a weak one",
+			ifMatch: func(version task.Version) string { return fmt.Sprintf(`W/"%d", "%d"`, version, version) },
+			status:  http.StatusOK,
+		},
+		{
+			name:    "rejects a weak entity tag",
+			ifMatch: func(version task.Version) string { return fmt.Sprintf(`W/"%d"`, version) },
+			status:  http.StatusPreconditionFailed,
+		},
+		{
+			name:    "rejects a list without matching strong entity tag",
+			ifMatch: func(version task.Version) string { return fmt.Sprintf(`"%d",W/"%d"`, version+5, version) },
+			status:  http.StatusPreconditionFailed,
+		},
+		{
+			name:    "rejects a malformed list",
+			ifMatch: func(version task.Version) string { return fmt.Sprintf(`"%d" "%d"`, version, version) },
+			status:  http.StatusPreconditionFailed,
+		},
+		{
+			name:    "updates on wildcard",
+			ifMatch: func(task.Version) string { return "*" },
+			status:  http.StatusOK,
+		},
+	}
+	for _, sample := range samples {
+		sample := sample
+		t.Run(sample.name, func(t *testing.T) {
+			t.Parallel()
+			handler, entity := setup(t)
+			require.Equal(t, sample.status, update(handler, entity, sample.ifMatch(entity.Version())))
+		})
+	}
+}
@@ -0,0 +1,275 @@
+package handlers
+
+import (
+	"demo-app-go/task"
+	"github.com/labstack/echo/v4"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+type templateService interface {
+	ListTemplates() ([]task.Template, error)
+	GetTemplate(id task.TemplateID) (task.Template, error)
+	AddTemplate(command task.AddTemplateCommand) (task.Template, error)
+	UpdateTemplate(command task.UpdateTemplateCommand) (task.Template, error)
+	DeleteTemplate(id task.TemplateID) error
+	InstantiateTemplate(command task.InstantiateTemplateCommand, actor string) (task.Tree, error)
+}
+
+type TemplateHandler struct {
+	service templateService
+}
+
+func NewTemplateHandler(service templateService) *TemplateHandler {
+	return &TemplateHandler{service: service}
+}
+
+// templateTaskResponse has the same shape as templateTaskRequest.
+type templateTaskResponse struct {
+	Title       string                 `json:"title"`
+	Description string                 `json:"description"`
+	Tags        []string               `json:"tags"`
+	Checklist   []string               `json:"checklist"`
+	DueIn       *string                `json:"dueIn"`
+	Subtasks    []templateTaskResponse `json:"subtasks"`
+}
+
+type templateResponse struct {
+	Id   task.TemplateID      `json:"id"`
+	Name string               `json:"name"`
+	Task templateTaskResponse `json:"task"`
+	// Variables lists names which have to be given when instantiating the template.
+	Variables []string   `json:"variables"`
+	CreatedAt time.Time  `json:"createdAt"`
+	UpdatedAt *time.Time `json:"updatedAt"`
+}
+
+func (h *TemplateHandler) List(c echo.Context) error {
+	templates, err := h.service.ListTemplates()
+	if err != nil {
+		return err
+	}
+
+	result := make([]templateResponse, len(templates))
+	for i, template := range templates {
+		result[i] = createTemplateResponse(template)
+	}
+
+	return c.JSON(http.StatusOK, result)
+}
+
+func (h *TemplateHandler) Get(c echo.Context) error {
+	id, err := getTemplateId(c)
+	if err != nil {
+		return err
+	}
+
+	template, err := h.service.GetTemplate(id)
+	if err != nil {
+		return taskError(c, err)
+	}
+
+	return c.JSON(http.StatusOK, createTemplateResponse(template))
+}
+
+// templateTaskRequest may use variables like {{name}} in all texts.
+type templateTaskRequest struct {
+	Title       string   `json:"title"`
+	Description string   `json:"description"`
+	Tags        []string `json:"tags"`
+	// Checklist lists texts of the items in order.
+	Checklist []string `json:"checklist"`
+	// DueIn is a duration from the moment the task gets created, e.g. 72h.
+	DueIn    string                `json:"dueIn"`
+	Subtasks []templateTaskRequest `json:"subtasks"`
+}
+
+func (r *templateTaskRequest) templateTask() (task.TemplateTask, error) {
+	var dueIn *time.Duration
+	if r.DueIn != "" {
+		value, err := time.ParseDuration(r.DueIn)
+		if err != nil {
+			return task.TemplateTask{}, echo.NewHTTPError(http.StatusBadRequest, "invalid dueIn")
+		}
+		dueIn = &value
+	}
+	subtasks := make([]task.TemplateTask, len(r.Subtasks))
+	for i := range r.Subtasks {
+		var err error
+		subtasks[i], err = r.Subtasks[i].templateTask()
+		if err != nil {
+			return task.TemplateTask{}, err
+		}
+	}
+
+	return task.NewTemplateTask(r.Title, r.Description, r.Tags, r.Checklist, dueIn, subtasks), nil
+}
+
+type templateRequest struct {
+	Name string              `json:"name" validate:"required"`
+	Task templateTaskRequest `json:"task"`
+}
+
+func (h *TemplateHandler) Add(c echo.Context) error {
+	data := &templateRequest{}
+	err := c.Bind(data)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+	err = c.Validate(data)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+
+	templateTask, err := data.Task.templateTask()
+	if err != nil {
+		return err
+	}
+	command, err := task.NewAddTemplateCommand(data.Name, templateTask)
+	if err != nil {
+		return taskError(c, err)
+	}
+	template, err := h.service.AddTemplate(command)
+	if err != nil {
+		return taskError(c, err)
+	}
+
+	return c.JSON(http.StatusCreated, createTemplateResponse(template))
+}
+
+// Update replaces the template along with all of its tasks.
+func (h *TemplateHandler) Update(c echo.Context) error {
+	id, err := getTemplateId(c)
+	if err != nil {
+		return err
+	}
+
+	data := &templateRequest{}
+	err = c.Bind(data)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+	err = c.Validate(data)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+
+	templateTask, err := data.Task.templateTask()
+	if err != nil {
+		return err
+	}
+	command, err := task.NewUpdateTemplateCommand(id, data.Name, templateTask)
+	if err != nil {
+		return taskError(c, err)
+	}
+	template, err := h.service.UpdateTemplate(command)
+	if err != nil {
+		return taskError(c, err)
+	}
+
+	return c.JSON(http.StatusOK, createTemplateResponse(template))
+}
+
+func (h *TemplateHandler) Delete(c echo.Context) error {
+	id, err := getTemplateId(c)
+	if err != nil {
+		return err
+	}
+
+	err = h.service.DeleteTemplate(id)
+	if err != nil {
+		return taskError(c, err)
+	}
+
+	return c.NoContent(http.StatusNoContent)
+}
+
+type instantiateRequest struct {
+	Variables map[string]string `json:"variables"`
+	// ParentId, ProjectId and ColumnId place the root task, like they do for a new task.
+	ParentId  *task.ID        `json:"parentId"`
+	ProjectId *task.ProjectID `json:"projectId"`
+	ColumnId  *task.ColumnID  `json:"columnId"`
+	// Timezone is IANA name, e.g. Europe/Warsaw, due dates are computed and returned in it. UTC by default.
+	Timezone string `json:"timezone"`
+}
+
+// Instantiate creates tasks described by the template and returns them as a tree.
+func (h *TemplateHandler) Instantiate(c echo.Context) error {
+	id, err := getTemplateId(c)
+	if err != nil {
+		return err
+	}
+
+	data := &instantiateRequest{}
+	err = c.Bind(data)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+	location := time.UTC
+	if data.Timezone != "" {
+		location, err = time.LoadLocation(data.Timezone)
+		if err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, "unknown timezone")
+		}
+	}
+
+	command, err := task.NewInstantiateTemplateCommand(
+		id,
+		data.Variables,
+		data.ParentId,
+		data.ProjectId,
+		data.ColumnId,
+		location,
+	)
+	if err != nil {
+		return taskError(c, err)
+	}
+	tree, err := h.service.InstantiateTemplate(command, getUser(c))
+	if err != nil {
+		return taskError(c, err)
+	}
+
+	return c.JSON(http.StatusCreated, createTaskTreeResponse(tree))
+}
+
+func getTemplateId(c echo.Context) (task.TemplateID, error) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		return 0, err
+	}
+	return task.TemplateID(id), nil
+}
+
+func createTemplateResponse(template task.Template) templateResponse {
+	return templateResponse{
+		Id:        template.Id(),
+		Name:      template.Name(),
+		Task:      createTemplateTaskResponse(template.Task()),
+		Variables: template.Variables(),
+		CreatedAt: template.CreatedAt(),
+		UpdatedAt: template.UpdatedAt(),
+	}
+}
+
+func createTemplateTaskResponse(templateTask task.TemplateTask) templateTaskResponse {
+	var dueIn *string
+	if templateTask.DueIn() != nil {
+		value := templateTask.DueIn().String()
+		dueIn = &value
+	}
+	subtasks := make([]templateTaskResponse, len(templateTask.Subtasks()))
+	for i, subtask := range templateTask.Subtasks() {
+		subtasks[i] = createTemplateTaskResponse(subtask)
+	}
+
+	return templateTaskResponse{
+		Title:       templateTask.Title(),
+		Description: templateTask.Description(),
+		Tags:        templateTask.Tags(),
+		Checklist:   templateTask.Checklist(),
+		DueIn:       dueIn,
+		Subtasks:    subtasks,
+	}
+}
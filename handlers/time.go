@@ -0,0 +1,263 @@
+package handlers
+
+import (
+	"demo-app-go/task"
+	"encoding/csv"
+	"fmt"
+	"github.com/labstack/echo/v4"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type timeService interface {
+	ListTimeEntries(taskID task.ID) ([]task.TimeEntry, error)
+	StartTimer(taskID task.ID, user string, note string) (task.TimeEntry, error)
+	StopTimer(taskID task.ID, user string) (task.TimeEntry, error)
+	LogTime(command task.AddTimeEntryCommand) (task.TimeEntry, error)
+	ReportTime(query task.TimeReportQuery) ([]task.TimeReportRow, error)
+}
+
+type TimeHandler struct {
+	service timeService
+}
+
+func NewTimeHandler(service timeService) *TimeHandler {
+	return &TimeHandler{service: service}
+}
+
+type timeEntryResponse struct {
+	Id     task.TimeEntryID `json:"id"`
+	TaskId task.ID          `json:"taskId"`
+	User   string           `json:"user"`
+	Start  time.Time        `json:"start"`
+	End    *time.Time       `json:"end"`
+	// Duration is in seconds, 0 while the timer is running.
+	Duration  int64     `json:"duration"`
+	Note      string    `json:"note"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+func (h *TimeHandler) List(c echo.Context) error {
+	taskId, err := getTaskId(c)
+	if err != nil {
+		return err
+	}
+
+	entries, err := h.service.ListTimeEntries(taskId)
+	if err != nil {
+		return taskError(c, err)
+	}
+
+	result := make([]timeEntryResponse, len(entries))
+	for i, entry := range entries {
+		result[i] = createTimeEntryResponse(entry)
+	}
+
+	return c.JSON(http.StatusOK, result)
+}
+
+type logTimeRequest struct {
+	Start time.Time `json:"start" validate:"required"`
+	End   time.Time `json:"end" validate:"required"`
+	Note  string    `json:"note"`
+}
+
+// Log adds time entry for work which is already done.
+func (h *TimeHandler) Log(c echo.Context) error {
+	taskId, err := getTaskId(c)
+	if err != nil {
+		return err
+	}
+
+	data := &logTimeRequest{}
+	err = c.Bind(data)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+	err = c.Validate(data)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+
+	command, err := task.NewLogTimeCommand(taskId, getUser(c), data.Start, data.End, data.Note)
+	if err != nil {
+		return taskError(c, err)
+	}
+	entry, err := h.service.LogTime(command)
+	if err != nil {
+		return taskError(c, err)
+	}
+
+	return c.JSON(http.StatusCreated, createTimeEntryResponse(entry))
+}
+
+type startTimerRequest struct {
+	Note string `json:"note"`
+}
+
+func (h *TimeHandler) Start(c echo.Context) error {
+	taskId, err := getTaskId(c)
+	if err != nil {
+		return err
+	}
+
+	data := &startTimerRequest{}
+	err = c.Bind(data)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+
+	entry, err := h.service.StartTimer(taskId, getUser(c), data.Note)
+	if err != nil {
+		return taskError(c, err)
+	}
+
+	return c.JSON(http.StatusCreated, createTimeEntryResponse(entry))
+}
+
+func (h *TimeHandler) Stop(c echo.Context) error {
+	taskId, err := getTaskId(c)
+	if err != nil {
+		return err
+	}
+
+	entry, err := h.service.StopTimer(taskId, getUser(c))
+	if err != nil {
+		return taskError(c, err)
+	}
+
+	return c.JSON(http.StatusOK, createTimeEntryResponse(entry))
+}
+
+type timeReportRowResponse struct {
+	Key    string   `json:"key"`
+	TaskId *task.ID `json:"taskId,omitempty"`
+	Title  string   `json:"title,omitempty"`
+	// Duration is in seconds.
+	Duration int64 `json:"duration"`
+	Entries  int   `json:"entries"`
+}
+
+// Report aggregates logged time, e.g. ?from=2023-01-01T00:00:00Z&to=2023-02-01T00:00:00Z&groupBy=week.
+// Optional parameters are user, timezone (IANA name, UTC by default) and format, which is either json or csv.
+func (h *TimeHandler) Report(c echo.Context) error {
+	query, err := getTimeReportQuery(c)
+	if err != nil {
+		return taskError(c, err)
+	}
+	format := c.QueryParam("format")
+	if format != "" && format != "json" && format != "csv" {
+		return echo.NewHTTPError(http.StatusBadRequest, "format must be json or csv")
+	}
+
+	rows, err := h.service.ReportTime(query)
+	if err != nil {
+		return taskError(c, err)
+	}
+
+	if format == "csv" {
+		return writeTimeReportCSV(c, rows)
+	}
+	result := make([]timeReportRowResponse, len(rows))
+	for i, row := range rows {
+		result[i] = timeReportRowResponse{
+			Key:      row.Key(),
+			TaskId:   row.TaskID(),
+			Title:    row.Title(),
+			Duration: int64(row.Duration().Seconds()),
+			Entries:  row.Entries(),
+		}
+	}
+
+	return c.JSON(http.StatusOK, result)
+}
+
+func getTimeReportQuery(c echo.Context) (task.TimeReportQuery, error) {
+	from, err := getTimeQueryParam(c, "from")
+	if err != nil {
+		return task.TimeReportQuery{}, err
+	}
+	to, err := getTimeQueryParam(c, "to")
+	if err != nil {
+		return task.TimeReportQuery{}, err
+	}
+	if from == nil || to == nil {
+		return task.TimeReportQuery{}, fmt.Errorf("%w: from and to are required", task.ErrValidation)
+	}
+	groupBy := task.GroupByTask
+	if value := c.QueryParam("groupBy"); value != "" {
+		groupBy, err = task.ParseTimeGrouping(value)
+		if err != nil {
+			return task.TimeReportQuery{}, err
+		}
+	}
+	location := time.UTC
+	if value := c.QueryParam("timezone"); value != "" {
+		location, err = time.LoadLocation(value)
+		if err != nil {
+			return task.TimeReportQuery{}, fmt.Errorf("%w: unknown timezone %q", task.ErrValidation, value)
+		}
+	}
+	var user *string
+	if value := c.QueryParam("user"); value != "" {
+		user = &value
+	}
+
+	return task.TimeReportQuery{From: *from, To: *to, User: user, GroupBy: groupBy, Location: location}, nil
+}
+
+// writeTimeReportCSV has a column for each field of the JSON report, with hours added for spreadsheets.
+func writeTimeReportCSV(c echo.Context, rows []task.TimeReportRow) error {
+	c.Response().Header().Set(echo.HeaderContentType, "text/csv; charset=utf-8")
+	c.Response().Header().Set(echo.HeaderContentDisposition, `attachment; filename="time-report.csv"`)
+	c.Response().WriteHeader(http.StatusOK)
+
+	writer := csv.NewWriter(c.Response())
+	err := writer.Write([]string{"key", "task_id", "title", "duration", "hours", "entries"})
+	if err != nil {
+		return err
+	}
+	for _, row := range rows {
+		taskId := ""
+		if row.TaskID() != nil {
+			taskId = strconv.FormatUint(uint64(*row.TaskID()), 10)
+		}
+		err = writer.Write([]string{
+			spreadsheetText(row.Key()),
+			taskId,
+			spreadsheetText(row.Title()),
+			strconv.FormatInt(int64(row.Duration().Seconds()), 10),
+			strconv.FormatFloat(row.Duration().Hours(), 'f', 2, 64),
+			strconv.Itoa(row.Entries()),
+		})
+		if err != nil {
+			return err
+		}
+	}
+	writer.Flush()
+	return writer.Error()
+}
+
+// spreadsheetText keeps text from being taken for a formula when the CSV is opened in a spreadsheet,
+// by prefixing the characters formulas start with by an apostrophe, as spreadsheets do for text typed in.
+func spreadsheetText(value string) string {
+	if value != "" && strings.ContainsRune("=+-@\t\r", rune(value[0])) {
+		return "'" + value
+	}
+	return value
+}
+
+func createTimeEntryResponse(entry task.TimeEntry) timeEntryResponse {
+	return timeEntryResponse{
+		Id:        entry.Id(),
+		TaskId:    entry.TaskID(),
+		User:      entry.User(),
+		Start:     entry.Start(),
+		End:       entry.End(),
+		Duration:  int64(entry.Duration().Seconds()),
+		Note:      entry.Note(),
+		CreatedAt: entry.CreatedAt(),
+	}
+}
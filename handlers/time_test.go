@@ -0,0 +1,57 @@
+package handlers_test
+
+import (
+	"demo-app-go/eventbus"
+	"demo-app-go/handlers"
+	"demo-app-go/storage"
+	"demo-app-go/task"
+	"encoding/csv"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestTimeHandler_Report(t *testing.T) {
+	t.Run("keeps titles from being taken for formulas in CSV", func(t *testing.T) {
+		t.Parallel()
+		memory := storage.NewMemory(eventbus.NewBus())
+		tasks := storage.NewMemoryTaskRepository(memory)
+		service := task.NewTimeService(storage.NewMemoryTimeEntryRepository(memory), tasks)
+		end := time.Now().Add(-time.Hour).Truncate(time.Second)
+		titles := []string{"=HYPERLINK(\"http://example.com\")", "+1", "-1", "@SUM(A1)", "Plain - title"}
+		for _, title := range titles {
+			command, err := task.NewAddTaskCommand(title, "", nil, nil, nil, nil, nil, nil)
+			require.NoError(t, err, "create command")
+			entity, err := tasks.Add(command, func(task.Task) task.ChangeRecord { return task.ChangeRecord{} })
+			require.NoError(t, err, "add task")
+			logTime, err := task.NewLogTimeCommand(entity.Id(), "alice", end.Add(-time.Hour), end, "")
+			require.NoError(t, err, "create command")
+			_, err = service.LogTime(logTime)
+			require.NoError(t, err, "log time")
+		}
+
+		from := end.Add(-2 * time.Hour).UTC().Format(time.RFC3339)
+		to := end.Add(time.Hour).UTC().Format(time.RFC3339)
+		request := httptest.NewRequest(http.MethodGet, "/time-report?format=csv&from="+from+"&to="+to, nil)
+		recorder := httptest.NewRecorder()
+		err := handlers.NewTimeHandler(service).Report(echo.New().NewContext(request, recorder))
+		require.NoError(t, err, "unexpected error")
+
+		records, err := csv.NewReader(recorder.Body).ReadAll()
+		require.NoError(t, err, "read CSV")
+		reported := map[string]bool{}
+		for _, record := range records[1:] {
+			reported[record[2]] = true
+		}
+		require.Equal(t, map[string]bool{
+			"'=HYPERLINK(\"http://example.com\")": true,
+			"'+1":                                 true,
+			"'-1":                                 true,
+			"'@SUM(A1)":                           true,
+			"Plain - title":                       true,
+		}, reported)
+	})
+}
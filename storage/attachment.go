@@ -0,0 +1,134 @@
+package storage
+
+import (
+	"database/sql"
+	"demo-app-go/task"
+	"errors"
+	"github.com/jmoiron/sqlx"
+	"time"
+)
+
+type AttachmentRepository struct {
+	db *sqlx.DB
+}
+
+func NewAttachmentRepository(db *sqlx.DB) *AttachmentRepository {
+	return &AttachmentRepository{db: db}
+}
+
+type attachmentRecord struct {
+	Id          task.AttachmentID `db:"id"`
+	TaskId      task.ID           `db:"task_id"`
+	FileName    string            `db:"file_name"`
+	ContentType string            `db:"content_type"`
+	Size        int64             `db:"size"`
+	Hash        string            `db:"hash"`
+	CreatedAt   time.Time         `db:"created_at"`
+}
+
+func (r *AttachmentRepository) List(taskID task.ID) ([]task.Attachment, error) {
+	var records []attachmentRecord
+	err := r.db.Select(
+		&records,
+		r.db.Rebind("SELECT * FROM task_attachment WHERE task_id=? ORDER BY created_at, id;"),
+		taskID,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]task.Attachment, len(records))
+	for i, record := range records {
+		result[i] = createAttachment(record)
+	}
+
+	return result, nil
+}
+
+func (r *AttachmentRepository) GetByID(id task.AttachmentID) (task.Attachment, error) {
+	var record attachmentRecord
+	err := r.db.Get(&record, r.db.Rebind("SELECT * FROM task_attachment WHERE id=?;"), id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return task.Attachment{}, ErrResourceNotFound
+	}
+	if err != nil {
+		return task.Attachment{}, err
+	}
+
+	return createAttachment(record), nil
+}
+
+// CountByHash is expected to be served by the index on hash.
+func (r *AttachmentRepository) CountByHash(hash string) (int, error) {
+	var count int
+	err := r.db.Get(&count, r.db.Rebind("SELECT COUNT(*) FROM task_attachment WHERE hash=?;"), hash)
+	return count, err
+}
+
+func (r *AttachmentRepository) Add(addAttachment task.AddAttachmentCommand) (task.Attachment, error) {
+	rows, err := r.db.NamedQuery(
+		`INSERT INTO task_attachment (task_id, file_name, content_type, size, hash, created_at)
+		VALUES (:taskId, :fileName, :contentType, :size, :hash, :createdAt) RETURNING *;`,
+		map[string]any{
+			"taskId":      addAttachment.TaskID(),
+			"fileName":    addAttachment.FileName(),
+			"contentType": addAttachment.ContentType(),
+			"size":        addAttachment.Size(),
+			"hash":        addAttachment.Hash(),
+			"createdAt":   addAttachment.CreatedAt(),
+		},
+	)
+	if err != nil {
+		return task.Attachment{}, attachmentError(err)
+	}
+	defer rows.Close()
+
+	var record attachmentRecord
+	if rows.Next() == false {
+		return task.Attachment{}, attachmentError(noRowReturned(rows))
+	}
+	err = rows.StructScan(&record)
+	if err != nil {
+		return task.Attachment{}, err
+	}
+
+	return createAttachment(record), rows.Close()
+}
+
+func (r *AttachmentRepository) Delete(id task.AttachmentID) error {
+	result, err := r.db.Exec(r.db.Rebind("DELETE FROM task_attachment WHERE id=?;"), id)
+	if err != nil {
+		return err
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return ErrResourceNotFound
+	}
+
+	return nil
+}
+
+// attachmentError tells apart content the task has already, and a missing task, from other failures of adding
+// an attachment.
+func attachmentError(err error) error {
+	if isDuplicateEntry(err) {
+		return task.ErrAttachmentExists
+	}
+	return referenceError(err)
+}
+
+func createAttachment(record attachmentRecord) task.Attachment {
+	return task.NewAttachment(task.AttachmentSnapshot{
+		Id:          record.Id,
+		TaskID:      record.TaskId,
+		FileName:    record.FileName,
+		ContentType: record.ContentType,
+		Size:        record.Size,
+		Hash:        record.Hash,
+		CreatedAt:   record.CreatedAt,
+	})
+}
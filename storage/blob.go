@@ -0,0 +1,141 @@
+package storage
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// LocalBlobStore keeps blobs as files in a directory, spread over subdirectories named after key prefixes.
+type LocalBlobStore struct {
+	dir string
+}
+
+// NewLocalBlobStore creates the directory when it does not exist yet.
+func NewLocalBlobStore(dir string) (*LocalBlobStore, error) {
+	err := os.MkdirAll(dir, 0o750)
+	if err != nil {
+		return nil, err
+	}
+	return &LocalBlobStore{dir: dir}, nil
+}
+
+// Put writes into a temporary file first, so readers never see partially written blob.
+// Putting existing blob only refreshes its modification time, which is the time Delete and List go by.
+func (s *LocalBlobStore) Put(key string, content io.Reader) error {
+	path, err := s.path(key)
+	if err != nil {
+		return err
+	}
+	now := time.Now()
+	err = os.Chtimes(path, now, now)
+	if err == nil {
+		return nil
+	}
+	if !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+	err = os.MkdirAll(filepath.Dir(path), 0o750)
+	if err != nil {
+		return err
+	}
+
+	file, err := os.CreateTemp(filepath.Dir(path), key+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(file.Name())
+	_, err = io.Copy(file, content)
+	if err != nil {
+		file.Close()
+		return err
+	}
+	err = file.Close()
+	if err != nil {
+		return err
+	}
+
+	return os.Rename(file.Name(), path)
+}
+
+func (s *LocalBlobStore) Open(key string) (io.ReadSeekCloser, error) {
+	path, err := s.path(key)
+	if err != nil {
+		return nil, err
+	}
+	file, err := os.Open(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, ErrResourceNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return file, nil
+}
+
+// List skips temporary files, which have a dot in their names, unlike keys.
+func (s *LocalBlobStore) List(putBefore time.Time) ([]string, error) {
+	var keys []string
+	err := filepath.WalkDir(s.dir, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil || entry.IsDir() || strings.Contains(entry.Name(), ".") {
+			return err
+		}
+		info, err := entry.Info()
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if info.ModTime().Before(putBefore) {
+			keys = append(keys, entry.Name())
+		}
+		return nil
+	})
+	return keys, err
+}
+
+// Delete moves the blob aside before checking its modification time, so that a concurrent Put either refreshes it
+// in time for the blob to be kept, or finds it missing and writes it again. Moving the blob back may replace the one
+// written again, which is the same content.
+func (s *LocalBlobStore) Delete(key string, putBefore time.Time) error {
+	path, err := s.path(key)
+	if err != nil {
+		return err
+	}
+	removed := path + ".removed"
+	err = os.Rename(path, removed)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	info, err := os.Stat(removed)
+	if err != nil {
+		return err
+	}
+	if !info.ModTime().Before(putBefore) {
+		return os.Rename(removed, path)
+	}
+	return os.Remove(removed)
+}
+
+// path allows only lowercase letters and digits in keys, so they cannot point outside the directory.
+func (s *LocalBlobStore) path(key string) (string, error) {
+	if len(key) < 3 {
+		return "", fmt.Errorf("invalid blob key %q", key)
+	}
+	for _, char := range key {
+		if (char < 'a' || char > 'z') && (char < '0' || char > '9') {
+			return "", fmt.Errorf("invalid blob key %q", key)
+		}
+	}
+	return filepath.Join(s.dir, key[:2], key), nil
+}
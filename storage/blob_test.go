@@ -0,0 +1,92 @@
+package storage_test
+
+import (
+	"demo-app-go/storage"
+	"demo-app-go/task"
+	"github.com/stretchr/testify/require"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestLocalBlobStore(t *testing.T) {
+	setup := func(t *testing.T) *storage.LocalBlobStore {
+		store, err := storage.NewLocalBlobStore(t.TempDir() + "/blobs")
+		require.NoError(t, err, "create store")
+		return store
+	}
+
+	t.Run("stores and opens blob", func(t *testing.T) {
+		t.Parallel()
+		store := setup(t)
+
+		err := store.Put("abc123", strings.NewReader("content"))
+		require.NoError(t, err, "unexpected error")
+		err = store.Put("abc123", strings.NewReader("content"))
+		require.NoError(t, err, "storing the same key again")
+
+		blob, err := store.Open("abc123")
+		require.NoError(t, err, "unexpected error")
+		defer blob.Close()
+		_, err = blob.Seek(3, io.SeekStart)
+		require.NoError(t, err, "seek")
+		data, err := io.ReadAll(blob)
+		require.NoError(t, err, "read")
+		require.Equal(t, "tent", string(data))
+	})
+	t.Run("Open returns ErrNotFound", func(t *testing.T) {
+		t.Parallel()
+		store := setup(t)
+
+		_, err := store.Open("abc123")
+		require.ErrorIs(t, err, task.ErrNotFound)
+	})
+	t.Run("Delete removes blob unless it was put since", func(t *testing.T) {
+		t.Parallel()
+		store := setup(t)
+		err := store.Put("abc123", strings.NewReader("content"))
+		require.NoError(t, err, "put")
+
+		err = store.Delete("abc123", time.Now().Add(-time.Hour))
+		require.NoError(t, err, "unexpected error")
+		_, err = store.Open("abc123")
+		require.NoError(t, err, "blob put recently is expected to stay")
+		err = store.Delete("abc123", time.Now().Add(time.Second))
+		require.NoError(t, err, "unexpected error")
+		_, err = store.Open("abc123")
+		require.ErrorIs(t, err, task.ErrNotFound)
+		err = store.Delete("abc123", time.Now().Add(time.Second))
+		require.NoError(t, err, "deleting missing blob")
+	})
+	t.Run("lists blobs put before the time, putting again refreshes it", func(t *testing.T) {
+		t.Parallel()
+		dir := t.TempDir()
+		store, err := storage.NewLocalBlobStore(dir)
+		require.NoError(t, err, "create store")
+		for _, key := range []string{"abc123", "abd456"} {
+			require.NoError(t, store.Put(key, strings.NewReader("content")), "put")
+		}
+		old := time.Now().Add(-2 * time.Hour)
+		require.NoError(t, os.Chtimes(filepath.Join(dir, "ab", "abc123"), old, old), "make blob old")
+
+		keys, err := store.List(time.Now().Add(-time.Hour))
+		require.NoError(t, err, "unexpected error")
+		require.Equal(t, []string{"abc123"}, keys)
+		require.NoError(t, store.Put("abc123", strings.NewReader("content")), "put again")
+		keys, err = store.List(time.Now().Add(-time.Hour))
+		require.NoError(t, err, "unexpected error")
+		require.Empty(t, keys)
+	})
+	t.Run("rejects keys pointing outside the directory", func(t *testing.T) {
+		t.Parallel()
+		store := setup(t)
+
+		for _, key := range []string{"../../etc/passwd", "ab/cd", "AB12", ""} {
+			err := store.Put(key, strings.NewReader("content"))
+			require.Error(t, err, key)
+		}
+	})
+}
@@ -0,0 +1,88 @@
+package storage
+
+import (
+	"demo-app-go/task"
+	"github.com/jmoiron/sqlx"
+)
+
+type checklistItemRecord struct {
+	TaskId   task.ID              `db:"task_id" json:"-"`
+	Id       task.ChecklistItemID `db:"id" json:"id"`
+	Text     string               `db:"text" json:"text"`
+	Done     bool                 `db:"done" json:"done"`
+	Position int                  `db:"position" json:"position"`
+}
+
+// loadChecklists returns items of the tasks ordered by position.
+func loadChecklists(q sqlx.Ext, ids []task.ID) (map[task.ID][]task.ChecklistItem, error) {
+	result := make(map[task.ID][]task.ChecklistItem, len(ids))
+	if len(ids) == 0 {
+		return result, nil
+	}
+
+	query, args, err := sqlx.In(
+		"SELECT * FROM task_checklist_item WHERE task_id IN (?) ORDER BY task_id, position;",
+		ids,
+	)
+	if err != nil {
+		return nil, err
+	}
+	var records []checklistItemRecord
+	err = sqlx.Select(q, &records, q.Rebind(query), args...)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, record := range records {
+		result[record.TaskId] = append(result[record.TaskId], createChecklistItem(record))
+	}
+
+	return result, nil
+}
+
+// saveChecklist replaces items of the task.
+func saveChecklist(tx *sqlx.Tx, id task.ID, items []task.ChecklistItem) error {
+	_, err := tx.Exec(tx.Rebind("DELETE FROM task_checklist_item WHERE task_id=?;"), id)
+	if err != nil {
+		return err
+	}
+
+	for _, record := range createChecklistItemRecords(id, items) {
+		_, err = tx.NamedExec(
+			`INSERT INTO task_checklist_item (task_id, id, text, done, position)
+			VALUES (:task_id, :id, :text, :done, :position);`,
+			record,
+		)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func createChecklistItemRecords(id task.ID, items []task.ChecklistItem) []checklistItemRecord {
+	result := make([]checklistItemRecord, len(items))
+	for i, item := range items {
+		result[i] = checklistItemRecord{
+			TaskId:   id,
+			Id:       item.Id(),
+			Text:     item.Text(),
+			Done:     item.Done(),
+			Position: item.Position(),
+		}
+	}
+	return result
+}
+
+func createChecklistItem(record checklistItemRecord) task.ChecklistItem {
+	return task.NewChecklistItem(record.Id, record.Text, record.Done)
+}
+
+func createChecklist(records []checklistItemRecord) []task.ChecklistItem {
+	result := make([]task.ChecklistItem, len(records))
+	for i, record := range records {
+		result[i] = createChecklistItem(record)
+	}
+	return result
+}
@@ -0,0 +1,154 @@
+package storage
+
+import (
+	"database/sql"
+	"demo-app-go/task"
+	"errors"
+	"github.com/jmoiron/sqlx"
+	"time"
+)
+
+type CommentRepository struct {
+	db *sqlx.DB
+}
+
+func NewCommentRepository(db *sqlx.DB) *CommentRepository {
+	return &CommentRepository{db: db}
+}
+
+type commentRecord struct {
+	Id        task.CommentID `db:"id"`
+	TaskId    task.ID        `db:"task_id"`
+	Author    string         `db:"author"`
+	Body      string         `db:"body"`
+	CreatedAt time.Time      `db:"created_at"`
+	EditedAt  *time.Time     `db:"edited_at"`
+}
+
+func (r *CommentRepository) List(taskID task.ID, page task.Page) ([]task.Comment, int, error) {
+	var count int
+	err := r.db.Get(&count, r.db.Rebind("SELECT COUNT(*) FROM task_comment WHERE task_id=?;"), taskID)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	var records []commentRecord
+	err = r.db.Select(
+		&records,
+		r.db.Rebind("SELECT * FROM task_comment WHERE task_id=? ORDER BY created_at, id LIMIT ? OFFSET ?;"),
+		taskID,
+		page.Limit(),
+		page.Offset(),
+	)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	result := make([]task.Comment, len(records))
+	for i, record := range records {
+		result[i] = createComment(record)
+	}
+
+	return result, count, nil
+}
+
+func (r *CommentRepository) GetByID(id task.CommentID) (task.Comment, error) {
+	var record commentRecord
+	err := r.db.Get(&record, r.db.Rebind("SELECT * FROM task_comment WHERE id=?;"), id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return task.Comment{}, ErrResourceNotFound
+	}
+	if err != nil {
+		return task.Comment{}, err
+	}
+
+	return createComment(record), nil
+}
+
+func (r *CommentRepository) Add(addComment task.AddCommentCommand) (task.Comment, error) {
+	rows, err := r.db.NamedQuery(
+		`INSERT INTO task_comment (task_id, author, body, created_at)
+		VALUES (:taskId, :author, :body, :createdAt) RETURNING *;`,
+		map[string]any{
+			"taskId":    addComment.TaskID(),
+			"author":    addComment.Author(),
+			"body":      addComment.Body(),
+			"createdAt": addComment.CreatedAt(),
+		},
+	)
+	if err != nil {
+		return task.Comment{}, referenceError(err)
+	}
+	defer rows.Close()
+
+	var record commentRecord
+	if rows.Next() == false {
+		return task.Comment{}, referenceError(noRowReturned(rows))
+	}
+	err = rows.StructScan(&record)
+	if err != nil {
+		return task.Comment{}, err
+	}
+
+	return createComment(record), rows.Close()
+}
+
+func (r *CommentRepository) Save(comment task.Comment) error {
+	result, err := r.db.NamedExec(
+		"UPDATE task_comment SET body=:body, edited_at=:editedAt WHERE id=:id;",
+		map[string]any{
+			"id":       comment.Id(),
+			"body":     comment.Body(),
+			"editedAt": comment.EditedAt(),
+		},
+	)
+	if err != nil {
+		return err
+	}
+
+	// Edits within the same second may leave the row unchanged, which MySQL does not count as affected.
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		var count int
+		err = r.db.Get(&count, r.db.Rebind("SELECT COUNT(*) FROM task_comment WHERE id=?;"), comment.Id())
+		if err != nil {
+			return err
+		}
+		if count == 0 {
+			return ErrResourceNotFound
+		}
+	}
+
+	return nil
+}
+
+func (r *CommentRepository) Delete(id task.CommentID) error {
+	result, err := r.db.Exec(r.db.Rebind("DELETE FROM task_comment WHERE id=?;"), id)
+	if err != nil {
+		return err
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return ErrResourceNotFound
+	}
+
+	return nil
+}
+
+func createComment(record commentRecord) task.Comment {
+	return task.NewComment(task.CommentSnapshot{
+		Id:        record.Id,
+		TaskID:    record.TaskId,
+		Author:    record.Author,
+		Body:      record.Body,
+		CreatedAt: record.CreatedAt,
+		EditedAt:  record.EditedAt,
+	})
+}
@@ -0,0 +1,179 @@
+package storage
+
+import (
+	"demo-app-go/eventbus"
+	"demo-app-go/task"
+	"sort"
+	"sync"
+)
+
+// Memory keeps all data within the process, so the API runs without any database, e.g. for demos.
+// Repositories created from the same Memory share it like the SQL ones share the database, so that comment counts,
+// copying content of cloned tasks and removing tasks along with their content behave the same.
+// Nothing survives a restart.
+type Memory struct {
+	mutex        sync.RWMutex
+	events       *eventbus.Bus
+	tasks        map[task.ID]task.Task
+	dependencies []task.Dependency
+	tags         map[string]bool
+	revisions    map[task.ID][]task.Revision
+	projects     map[task.ProjectID]task.Project
+	templates    map[task.TemplateID]task.Template
+	comments     map[task.CommentID]task.Comment
+	timeEntries  map[task.TimeEntryID]task.TimeEntry
+	attachments  map[task.AttachmentID]task.Attachment
+	// sequences hold the last id given out, by kind of data, like auto increment columns do.
+	sequences map[string]uint
+	// pending are events of the change in progress, published once it is done.
+	pending []eventbus.Event
+}
+
+// NewMemory creates empty storage. There is no outbox, events of changes are published on the bus right after them.
+func NewMemory(events *eventbus.Bus) *Memory {
+	return &Memory{
+		events:      events,
+		tasks:       map[task.ID]task.Task{},
+		tags:        map[string]bool{},
+		revisions:   map[task.ID][]task.Revision{},
+		projects:    map[task.ProjectID]task.Project{},
+		templates:   map[task.TemplateID]task.Template{},
+		comments:    map[task.CommentID]task.Comment{},
+		timeEntries: map[task.TimeEntryID]task.TimeEntry{},
+		attachments: map[task.AttachmentID]task.Attachment{},
+		sequences:   map[string]uint{},
+	}
+}
+
+// change runs the function holding the write lock, then publishes events it recorded.
+// The function is expected to check everything before changing anything, as there is no transaction to roll back.
+func (m *Memory) change(run func() error) error {
+	m.mutex.Lock()
+	err := run()
+	events := m.pending
+	m.pending = nil
+	m.mutex.Unlock()
+	if err != nil {
+		return err
+	}
+
+	// Subscribers may read the storage, so events are published only after the lock is released.
+	for _, event := range events {
+		m.events.Publish(event)
+	}
+	return nil
+}
+
+func (m *Memory) next(kind string) uint {
+	m.sequences[kind]++
+	return m.sequences[kind]
+}
+
+// storeChangeRecord stores the revision and keeps events until the change is done, like the outbox does.
+func (m *Memory) storeChangeRecord(record task.ChangeRecord) {
+	if record.Revision != nil {
+		id := record.Revision.TaskID()
+		m.revisions[id] = append(m.revisions[id], *record.Revision)
+	}
+	m.pending = append(m.pending, record.Events...)
+}
+
+// copyContent copies comments and attachments of the source task, as chosen. It returns the number of comments copied.
+func (m *Memory) copyContent(source copySource, id task.ID) int {
+	comments := 0
+	if source.comments {
+		for _, comment := range m.taskComments(source.id) {
+			commentID := task.CommentID(m.next("comment"))
+			m.comments[commentID] = task.NewComment(task.CommentSnapshot{
+				Id:        commentID,
+				TaskID:    id,
+				Author:    comment.Author(),
+				Body:      comment.Body(),
+				CreatedAt: comment.CreatedAt(),
+				EditedAt:  comment.EditedAt(),
+			})
+			comments++
+		}
+	}
+	if source.attachments {
+		for _, attachment := range m.taskAttachments(source.id) {
+			attachmentID := task.AttachmentID(m.next("attachment"))
+			m.attachments[attachmentID] = task.NewAttachment(task.AttachmentSnapshot{
+				Id:          attachmentID,
+				TaskID:      id,
+				FileName:    attachment.FileName(),
+				ContentType: attachment.ContentType(),
+				Size:        attachment.Size(),
+				Hash:        attachment.Hash(),
+				CreatedAt:   attachment.CreatedAt(),
+			})
+		}
+	}
+
+	return comments
+}
+
+// taskComments returns comments of the task ordered by creation time, ties resolved by id.
+func (m *Memory) taskComments(id task.ID) []task.Comment {
+	result := []task.Comment{}
+	for _, comment := range m.comments {
+		if comment.TaskID() == id {
+			result = append(result, comment)
+		}
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if !result[i].CreatedAt().Equal(result[j].CreatedAt()) {
+			return result[i].CreatedAt().Before(result[j].CreatedAt())
+		}
+		return result[i].Id() < result[j].Id()
+	})
+	return result
+}
+
+// taskAttachments returns attachments of the task ordered by creation time, ties resolved by id.
+func (m *Memory) taskAttachments(id task.ID) []task.Attachment {
+	result := []task.Attachment{}
+	for _, attachment := range m.attachments {
+		if attachment.TaskID() == id {
+			result = append(result, attachment)
+		}
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if !result[i].CreatedAt().Equal(result[j].CreatedAt()) {
+			return result[i].CreatedAt().Before(result[j].CreatedAt())
+		}
+		return result[i].Id() < result[j].Id()
+	})
+	return result
+}
+
+// MemoryRevisionRepository reads revisions stored by MemoryTaskRepository.
+type MemoryRevisionRepository struct {
+	memory *Memory
+}
+
+func NewMemoryRevisionRepository(memory *Memory) *MemoryRevisionRepository {
+	return &MemoryRevisionRepository{memory: memory}
+}
+
+// List relies on revisions being stored in order of their numbers.
+func (r *MemoryRevisionRepository) List(taskID task.ID) ([]task.Revision, error) {
+	r.memory.mutex.RLock()
+	defer r.memory.mutex.RUnlock()
+
+	result := make([]task.Revision, len(r.memory.revisions[taskID]))
+	copy(result, r.memory.revisions[taskID])
+	return result, nil
+}
+
+func (r *MemoryRevisionRepository) Get(taskID task.ID, number task.Version) (task.Revision, error) {
+	r.memory.mutex.RLock()
+	defer r.memory.mutex.RUnlock()
+
+	for _, revision := range r.memory.revisions[taskID] {
+		if revision.Number() == number {
+			return revision, nil
+		}
+	}
+	return task.Revision{}, ErrResourceNotFound
+}
@@ -0,0 +1,267 @@
+package storage
+
+import (
+	"demo-app-go/task"
+	"fmt"
+	"sort"
+	"time"
+)
+
+type MemoryCommentRepository struct {
+	memory *Memory
+}
+
+func NewMemoryCommentRepository(memory *Memory) *MemoryCommentRepository {
+	return &MemoryCommentRepository{memory: memory}
+}
+
+func (r *MemoryCommentRepository) List(taskID task.ID, page task.Page) ([]task.Comment, int, error) {
+	r.memory.mutex.RLock()
+	defer r.memory.mutex.RUnlock()
+
+	comments := r.memory.taskComments(taskID)
+	from := page.Offset()
+	if from > len(comments) {
+		from = len(comments)
+	}
+	to := from + page.Limit()
+	if to > len(comments) {
+		to = len(comments)
+	}
+	return comments[from:to], len(comments), nil
+}
+
+func (r *MemoryCommentRepository) GetByID(id task.CommentID) (task.Comment, error) {
+	r.memory.mutex.RLock()
+	defer r.memory.mutex.RUnlock()
+
+	comment, ok := r.memory.comments[id]
+	if !ok {
+		return task.Comment{}, ErrResourceNotFound
+	}
+	return comment, nil
+}
+
+func (r *MemoryCommentRepository) Add(addComment task.AddCommentCommand) (task.Comment, error) {
+	var comment task.Comment
+	err := r.memory.change(func() error {
+		id := task.CommentID(r.memory.next("comment"))
+		comment = task.NewComment(task.CommentSnapshot{
+			Id:        id,
+			TaskID:    addComment.TaskID(),
+			Author:    addComment.Author(),
+			Body:      addComment.Body(),
+			CreatedAt: addComment.CreatedAt(),
+		})
+		r.memory.comments[id] = comment
+		return nil
+	})
+	return comment, err
+}
+
+func (r *MemoryCommentRepository) Save(comment task.Comment) error {
+	return r.memory.change(func() error {
+		stored, ok := r.memory.comments[comment.Id()]
+		if !ok {
+			return ErrResourceNotFound
+		}
+		r.memory.comments[comment.Id()] = task.NewComment(task.CommentSnapshot{
+			Id:        stored.Id(),
+			TaskID:    stored.TaskID(),
+			Author:    stored.Author(),
+			Body:      comment.Body(),
+			CreatedAt: stored.CreatedAt(),
+			EditedAt:  comment.EditedAt(),
+		})
+		return nil
+	})
+}
+
+func (r *MemoryCommentRepository) Delete(id task.CommentID) error {
+	return r.memory.change(func() error {
+		if _, ok := r.memory.comments[id]; !ok {
+			return ErrResourceNotFound
+		}
+		delete(r.memory.comments, id)
+		return nil
+	})
+}
+
+type MemoryAttachmentRepository struct {
+	memory *Memory
+}
+
+func NewMemoryAttachmentRepository(memory *Memory) *MemoryAttachmentRepository {
+	return &MemoryAttachmentRepository{memory: memory}
+}
+
+func (r *MemoryAttachmentRepository) List(taskID task.ID) ([]task.Attachment, error) {
+	r.memory.mutex.RLock()
+	defer r.memory.mutex.RUnlock()
+
+	return r.memory.taskAttachments(taskID), nil
+}
+
+func (r *MemoryAttachmentRepository) GetByID(id task.AttachmentID) (task.Attachment, error) {
+	r.memory.mutex.RLock()
+	defer r.memory.mutex.RUnlock()
+
+	attachment, ok := r.memory.attachments[id]
+	if !ok {
+		return task.Attachment{}, ErrResourceNotFound
+	}
+	return attachment, nil
+}
+
+func (r *MemoryAttachmentRepository) CountByHash(hash string) (int, error) {
+	r.memory.mutex.RLock()
+	defer r.memory.mutex.RUnlock()
+
+	count := 0
+	for _, attachment := range r.memory.attachments {
+		if attachment.Hash() == hash {
+			count++
+		}
+	}
+	return count, nil
+}
+
+// Add refuses content which the task has already, like the unique key on task and hash does.
+func (r *MemoryAttachmentRepository) Add(addAttachment task.AddAttachmentCommand) (task.Attachment, error) {
+	var attachment task.Attachment
+	err := r.memory.change(func() error {
+		for _, existing := range r.memory.taskAttachments(addAttachment.TaskID()) {
+			if existing.Hash() == addAttachment.Hash() {
+				return task.ErrAttachmentExists
+			}
+		}
+		id := task.AttachmentID(r.memory.next("attachment"))
+		attachment = task.NewAttachment(task.AttachmentSnapshot{
+			Id:          id,
+			TaskID:      addAttachment.TaskID(),
+			FileName:    addAttachment.FileName(),
+			ContentType: addAttachment.ContentType(),
+			Size:        addAttachment.Size(),
+			Hash:        addAttachment.Hash(),
+			CreatedAt:   addAttachment.CreatedAt(),
+		})
+		r.memory.attachments[id] = attachment
+		return nil
+	})
+	return attachment, err
+}
+
+func (r *MemoryAttachmentRepository) Delete(id task.AttachmentID) error {
+	return r.memory.change(func() error {
+		if _, ok := r.memory.attachments[id]; !ok {
+			return ErrResourceNotFound
+		}
+		delete(r.memory.attachments, id)
+		return nil
+	})
+}
+
+type MemoryTimeEntryRepository struct {
+	memory *Memory
+}
+
+func NewMemoryTimeEntryRepository(memory *Memory) *MemoryTimeEntryRepository {
+	return &MemoryTimeEntryRepository{memory: memory}
+}
+
+func (r *MemoryTimeEntryRepository) List(taskID task.ID) ([]task.TimeEntry, error) {
+	return r.list(func(entry task.TimeEntry) bool {
+		return entry.TaskID() == taskID
+	}), nil
+}
+
+func (r *MemoryTimeEntryRepository) GetRunning(user string) (task.TimeEntry, error) {
+	r.memory.mutex.RLock()
+	defer r.memory.mutex.RUnlock()
+
+	entry, ok := r.memory.runningEntry(user)
+	if !ok {
+		return task.TimeEntry{}, ErrResourceNotFound
+	}
+	return entry, nil
+}
+
+func (r *MemoryTimeEntryRepository) ListFinished(from time.Time, to time.Time, user *string) ([]task.TimeEntry, error) {
+	return r.list(func(entry task.TimeEntry) bool {
+		return !entry.Running() &&
+			!entry.Start().Before(from) &&
+			entry.Start().Before(to) &&
+			(user == nil || entry.User() == *user)
+	}), nil
+}
+
+// list returns matching entries ordered by start, ties resolved by id.
+func (r *MemoryTimeEntryRepository) list(matches func(entry task.TimeEntry) bool) []task.TimeEntry {
+	r.memory.mutex.RLock()
+	defer r.memory.mutex.RUnlock()
+
+	result := []task.TimeEntry{}
+	for _, entry := range r.memory.timeEntries {
+		if matches(entry) {
+			result = append(result, entry)
+		}
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if !result[i].Start().Equal(result[j].Start()) {
+			return result[i].Start().Before(result[j].Start())
+		}
+		return result[i].Id() < result[j].Id()
+	})
+	return result
+}
+
+func (r *MemoryTimeEntryRepository) Add(addTimeEntry task.AddTimeEntryCommand) (task.TimeEntry, error) {
+	var entry task.TimeEntry
+	err := r.memory.change(func() error {
+		if _, ok := r.memory.runningEntry(addTimeEntry.User()); ok && addTimeEntry.End() == nil {
+			return fmt.Errorf("%w: user %s", task.ErrTimerRunning, addTimeEntry.User())
+		}
+		id := task.TimeEntryID(r.memory.next("time_entry"))
+		entry = task.NewTimeEntry(task.TimeEntrySnapshot{
+			Id:        id,
+			TaskID:    addTimeEntry.TaskID(),
+			User:      addTimeEntry.User(),
+			Start:     addTimeEntry.Start(),
+			End:       addTimeEntry.End(),
+			Note:      addTimeEntry.Note(),
+			CreatedAt: addTimeEntry.CreatedAt(),
+		})
+		r.memory.timeEntries[id] = entry
+		return nil
+	})
+	return entry, err
+}
+
+// Save only stores the end, as the rest of the entry does not change.
+func (r *MemoryTimeEntryRepository) Save(entry task.TimeEntry) error {
+	return r.memory.change(func() error {
+		stored, ok := r.memory.timeEntries[entry.Id()]
+		if !ok {
+			return ErrResourceNotFound
+		}
+		r.memory.timeEntries[entry.Id()] = task.NewTimeEntry(task.TimeEntrySnapshot{
+			Id:        stored.Id(),
+			TaskID:    stored.TaskID(),
+			User:      stored.User(),
+			Start:     stored.Start(),
+			End:       entry.End(),
+			Note:      stored.Note(),
+			CreatedAt: stored.CreatedAt(),
+		})
+		return nil
+	})
+}
+
+func (m *Memory) runningEntry(user string) (task.TimeEntry, bool) {
+	for _, entry := range m.timeEntries {
+		if entry.User() == user && entry.Running() {
+			return entry, true
+		}
+	}
+	return task.TimeEntry{}, false
+}
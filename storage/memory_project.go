@@ -0,0 +1,178 @@
+package storage
+
+import (
+	"demo-app-go/task"
+	"sort"
+)
+
+type MemoryProjectRepository struct {
+	memory *Memory
+}
+
+func NewMemoryProjectRepository(memory *Memory) *MemoryProjectRepository {
+	return &MemoryProjectRepository{memory: memory}
+}
+
+func (r *MemoryProjectRepository) List() ([]task.Project, error) {
+	r.memory.mutex.RLock()
+	defer r.memory.mutex.RUnlock()
+
+	result := make([]task.Project, 0, len(r.memory.projects))
+	for _, project := range r.memory.projects {
+		result = append(result, project)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Id() < result[j].Id() })
+	return result, nil
+}
+
+func (r *MemoryProjectRepository) GetByID(id task.ProjectID) (task.Project, error) {
+	r.memory.mutex.RLock()
+	defer r.memory.mutex.RUnlock()
+
+	project, ok := r.memory.projects[id]
+	if !ok {
+		return task.Project{}, ErrResourceNotFound
+	}
+	return project, nil
+}
+
+func (r *MemoryProjectRepository) Add(addProject task.AddProjectCommand) (task.Project, error) {
+	var project task.Project
+	err := r.memory.change(func() error {
+		id := task.ProjectID(r.memory.next("project"))
+		project = task.NewProject(task.ProjectSnapshot{
+			Id:          id,
+			Name:        addProject.Name(),
+			Description: addProject.Description(),
+			Columns:     r.memory.identifyColumns(addProject.Columns()),
+			CreatedAt:   addProject.CreatedAt(),
+		})
+		r.memory.projects[id] = project
+		return nil
+	})
+	return project, err
+}
+
+func (r *MemoryProjectRepository) Save(project task.Project) (task.Project, error) {
+	var saved task.Project
+	err := r.memory.change(func() error {
+		stored, ok := r.memory.projects[project.Id()]
+		if !ok {
+			return ErrResourceNotFound
+		}
+		saved = task.NewProject(task.ProjectSnapshot{
+			Id:          project.Id(),
+			Name:        project.Name(),
+			Description: project.Description(),
+			Columns:     r.memory.identifyColumns(project.Columns()),
+			CreatedAt:   stored.CreatedAt(),
+			UpdatedAt:   project.UpdatedAt(),
+		})
+		r.memory.projects[project.Id()] = saved
+		return nil
+	})
+	return saved, err
+}
+
+// identifyColumns gives ids to columns with zero id, as they are new.
+func (m *Memory) identifyColumns(columns []task.Column) []task.Column {
+	result := make([]task.Column, len(columns))
+	for i, column := range columns {
+		id := column.Id()
+		if id == 0 {
+			id = task.ColumnID(m.next("column"))
+		}
+		result[i] = task.NewColumn(id, column.Name(), column.Limit())
+	}
+	return result
+}
+
+// Delete removes the project along with its columns. Tasks are expected to be removed from it first.
+func (r *MemoryProjectRepository) Delete(id task.ProjectID) error {
+	return r.memory.change(func() error {
+		if _, ok := r.memory.projects[id]; !ok {
+			return ErrResourceNotFound
+		}
+		delete(r.memory.projects, id)
+		return nil
+	})
+}
+
+type MemoryTemplateRepository struct {
+	memory *Memory
+}
+
+func NewMemoryTemplateRepository(memory *Memory) *MemoryTemplateRepository {
+	return &MemoryTemplateRepository{memory: memory}
+}
+
+func (r *MemoryTemplateRepository) List() ([]task.Template, error) {
+	r.memory.mutex.RLock()
+	defer r.memory.mutex.RUnlock()
+
+	result := make([]task.Template, 0, len(r.memory.templates))
+	for _, template := range r.memory.templates {
+		result = append(result, template)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Name() != result[j].Name() {
+			return result[i].Name() < result[j].Name()
+		}
+		return result[i].Id() < result[j].Id()
+	})
+	return result, nil
+}
+
+func (r *MemoryTemplateRepository) GetByID(id task.TemplateID) (task.Template, error) {
+	r.memory.mutex.RLock()
+	defer r.memory.mutex.RUnlock()
+
+	template, ok := r.memory.templates[id]
+	if !ok {
+		return task.Template{}, ErrResourceNotFound
+	}
+	return template, nil
+}
+
+func (r *MemoryTemplateRepository) Add(addTemplate task.AddTemplateCommand) (task.Template, error) {
+	var template task.Template
+	err := r.memory.change(func() error {
+		id := task.TemplateID(r.memory.next("template"))
+		template = task.NewTemplate(task.TemplateSnapshot{
+			Id:        id,
+			Name:      addTemplate.Name(),
+			Task:      addTemplate.Task(),
+			CreatedAt: addTemplate.CreatedAt(),
+		})
+		r.memory.templates[id] = template
+		return nil
+	})
+	return template, err
+}
+
+func (r *MemoryTemplateRepository) Save(template task.Template) error {
+	return r.memory.change(func() error {
+		stored, ok := r.memory.templates[template.Id()]
+		if !ok {
+			return ErrResourceNotFound
+		}
+		r.memory.templates[template.Id()] = task.NewTemplate(task.TemplateSnapshot{
+			Id:        template.Id(),
+			Name:      template.Name(),
+			Task:      template.Task(),
+			CreatedAt: stored.CreatedAt(),
+			UpdatedAt: template.UpdatedAt(),
+		})
+		return nil
+	})
+}
+
+func (r *MemoryTemplateRepository) Delete(id task.TemplateID) error {
+	return r.memory.change(func() error {
+		if _, ok := r.memory.templates[id]; !ok {
+			return ErrResourceNotFound
+		}
+		delete(r.memory.templates, id)
+		return nil
+	})
+}
@@ -0,0 +1,453 @@
+package storage
+
+import (
+	"demo-app-go/task"
+	"sort"
+)
+
+// MemoryTaskRepository is TaskRepository without a database. Like its SQL counterpart, it returns
+// ErrResourceNotFound for missing tasks and task.ErrVersionMismatch for tasks saved at another version.
+type MemoryTaskRepository struct {
+	memory *Memory
+}
+
+func NewMemoryTaskRepository(memory *Memory) *MemoryTaskRepository {
+	return &MemoryTaskRepository{memory: memory}
+}
+
+func (r *MemoryTaskRepository) List(filter task.ListFilter) ([]task.Task, error) {
+	r.memory.mutex.RLock()
+	defer r.memory.mutex.RUnlock()
+
+	counts := r.memory.commentCounts()
+	result := []task.Task{}
+	for _, stored := range r.memory.tasks {
+		entity := withCommentCount(stored, counts[stored.Id()])
+		if filter.Matches(entity) {
+			result = append(result, entity)
+		}
+	}
+	sortTasks(result)
+
+	return result, nil
+}
+
+// GetByID does not find tasks in the trash.
+func (r *MemoryTaskRepository) GetByID(id task.ID) (task.Task, error) {
+	return r.get(id, false)
+}
+
+func (r *MemoryTaskRepository) GetFromTrash(id task.ID) (task.Task, error) {
+	return r.get(id, true)
+}
+
+func (r *MemoryTaskRepository) get(id task.ID, deleted bool) (task.Task, error) {
+	r.memory.mutex.RLock()
+	defer r.memory.mutex.RUnlock()
+
+	stored, ok := r.memory.tasks[id]
+	if !ok || (stored.DeletedAt() != nil) != deleted {
+		return task.Task{}, ErrResourceNotFound
+	}
+
+	return withCommentCount(stored, r.memory.commentCounts()[id]), nil
+}
+
+func (r *MemoryTaskRepository) Add(
+	addTask task.AddTaskCommand,
+	record func(task.Task) task.ChangeRecord,
+) (task.Task, error) {
+	var entity task.Task
+	err := r.memory.change(func() error {
+		entity = r.memory.addTask(addTask, addTask.ParentID(), nil, record)
+		return nil
+	})
+	return entity, err
+}
+
+func (r *MemoryTaskRepository) AddTree(
+	addTree task.AddTaskTreeCommand,
+	record func(task.Task) task.ChangeRecord,
+) (task.Tree, error) {
+	var tree task.Tree
+	err := r.memory.change(func() error {
+		tree = r.memory.addTree(addTree, addTree.Task().ParentID(), record)
+		return nil
+	})
+	return tree, err
+}
+
+// addTree adds the task under the parent, followed by its subtasks.
+func (m *Memory) addTree(
+	addTree task.AddTaskTreeCommand,
+	parentID *task.ID,
+	record func(task.Task) task.ChangeRecord,
+) task.Tree {
+	var source *copySource
+	if addTree.Source() != 0 {
+		source = &copySource{
+			id:          addTree.Source(),
+			comments:    addTree.CopyComments(),
+			attachments: addTree.CopyAttachments(),
+		}
+	}
+	entity := m.addTask(addTree.Task(), parentID, source, record)
+	id := entity.Id()
+	subtasks := make([]task.Tree, len(addTree.Subtasks()))
+	for i, subtask := range addTree.Subtasks() {
+		subtasks[i] = m.addTree(subtask, &id, record)
+	}
+
+	return task.NewTree(entity, subtasks)
+}
+
+func (m *Memory) addTask(
+	addTask task.AddTaskCommand,
+	parentID *task.ID,
+	source *copySource,
+	record func(task.Task) task.ChangeRecord,
+) task.Task {
+	id := task.ID(m.next("task"))
+	comments := 0
+	if source != nil {
+		comments = m.copyContent(*source, id)
+	}
+	for _, tag := range addTask.Tags() {
+		m.tags[tag] = true
+	}
+	entity := task.NewTask(task.Snapshot{
+		Id:           id,
+		Title:        addTask.Title(),
+		Description:  addTask.Description(),
+		Status:       addTask.Status(),
+		DueAt:        addTask.DueAt(),
+		ParentID:     parentID,
+		ProjectID:    addTask.ProjectID(),
+		ColumnID:     addTask.ColumnID(),
+		Tags:         addTask.Tags(),
+		Recurrence:   addTask.Recurrence(),
+		Checklist:    addTask.Checklist(),
+		Rank:         addTask.Rank(),
+		CommentCount: comments,
+		Version:      1,
+		CreatedAt:    addTask.CreatedAt(),
+	})
+	m.tasks[id] = entity
+	m.storeChangeRecord(record(entity))
+
+	return entity
+}
+
+// Save keeps the rank and creation time of the stored task, as TaskRepository does not update them either.
+func (r *MemoryTaskRepository) Save(entity task.Task, record task.ChangeRecord) error {
+	return r.memory.change(func() error {
+		err := r.memory.checkVersion(entity)
+		if err != nil {
+			return err
+		}
+		r.memory.saveTask(entity, record)
+		return nil
+	})
+}
+
+func (r *MemoryTaskRepository) SaveAll(changes []task.TaskChange) error {
+	return r.memory.change(func() error {
+		for _, change := range changes {
+			err := r.memory.checkVersion(change.Task)
+			if err != nil {
+				return err
+			}
+		}
+		for _, change := range changes {
+			r.memory.saveTask(change.Task, change.Record)
+		}
+		return nil
+	})
+}
+
+func (r *MemoryTaskRepository) SaveAndAdd(
+	entity task.Task,
+	record task.ChangeRecord,
+	addTask task.AddTaskCommand,
+	recordAdded func(task.Task) task.ChangeRecord,
+) (task.Task, error) {
+	var added task.Task
+	err := r.memory.change(func() error {
+		err := r.memory.checkVersion(entity)
+		if err != nil {
+			return err
+		}
+		r.memory.saveTask(entity, record)
+		added = r.memory.addTask(addTask, addTask.ParentID(), nil, recordAdded)
+		return nil
+	})
+	return added, err
+}
+
+// checkVersion fails unless the task is stored at the version it is going to be saved at.
+func (m *Memory) checkVersion(entity task.Task) error {
+	stored, ok := m.tasks[entity.Id()]
+	if !ok {
+		return ErrResourceNotFound
+	}
+	if stored.Version() != entity.Version() {
+		return task.ErrVersionMismatch
+	}
+	return nil
+}
+
+// saveTask stores the task at the next version, once checkVersion passed.
+func (m *Memory) saveTask(entity task.Task, record task.ChangeRecord) {
+	stored := m.tasks[entity.Id()]
+	snapshot := entity.Snapshot()
+	snapshot.Rank = stored.Rank()
+	snapshot.CreatedAt = stored.CreatedAt()
+	snapshot.Version++
+	for _, tag := range snapshot.Tags {
+		m.tags[tag] = true
+	}
+	m.tasks[entity.Id()] = task.NewTask(snapshot)
+	m.storeChangeRecord(record)
+}
+
+// Delete removes the task permanently, along with dependencies it takes part in, its time entries, comments,
+// history and attachments. Its remaining subtasks become top level ones, without changing their version.
+func (r *MemoryTaskRepository) Delete(id task.ID, record task.ChangeRecord) error {
+	return r.memory.change(func() error {
+		m := r.memory
+		if _, ok := m.tasks[id]; !ok {
+			return ErrResourceNotFound
+		}
+
+		for subtaskID, subtask := range m.tasks {
+			if subtask.ParentID() != nil && *subtask.ParentID() == id {
+				snapshot := subtask.Snapshot()
+				snapshot.ParentID = nil
+				m.tasks[subtaskID] = task.NewTask(snapshot)
+			}
+		}
+		dependencies := make([]task.Dependency, 0, len(m.dependencies))
+		for _, dependency := range m.dependencies {
+			if dependency.BlockerID() != id && dependency.BlockedID() != id {
+				dependencies = append(dependencies, dependency)
+			}
+		}
+		m.dependencies = dependencies
+		for entryID, entry := range m.timeEntries {
+			if entry.TaskID() == id {
+				delete(m.timeEntries, entryID)
+			}
+		}
+		for commentID, comment := range m.comments {
+			if comment.TaskID() == id {
+				delete(m.comments, commentID)
+			}
+		}
+		delete(m.revisions, id)
+		for attachmentID, attachment := range m.attachments {
+			if attachment.TaskID() == id {
+				delete(m.attachments, attachmentID)
+			}
+		}
+		delete(m.tasks, id)
+		m.storeChangeRecord(record)
+		return nil
+	})
+}
+
+func (r *MemoryTaskRepository) LastRank() (task.Rank, error) {
+	r.memory.mutex.RLock()
+	defer r.memory.mutex.RUnlock()
+
+	var rank task.Rank
+	for _, stored := range r.memory.tasks {
+		if stored.Rank() > rank {
+			rank = stored.Rank()
+		}
+	}
+	return rank, nil
+}
+
+// SaveRanks changes only ranks, so it neither conflicts with concurrent saves nor changes versions.
+func (r *MemoryTaskRepository) SaveRanks(ranks map[task.ID]task.Rank) error {
+	return r.memory.change(func() error {
+		err := r.memory.checkRanks(ranks)
+		if err != nil {
+			return err
+		}
+		r.memory.saveRanks(ranks)
+		return nil
+	})
+}
+
+func (r *MemoryTaskRepository) SaveWithRanks(
+	entity task.Task,
+	record task.ChangeRecord,
+	ranks map[task.ID]task.Rank,
+) error {
+	return r.memory.change(func() error {
+		err := r.memory.checkVersion(entity)
+		if err != nil {
+			return err
+		}
+		err = r.memory.checkRanks(ranks)
+		if err != nil {
+			return err
+		}
+		r.memory.saveTask(entity, record)
+		r.memory.saveRanks(ranks)
+		return nil
+	})
+}
+
+// checkRanks fails unless all the tasks are stored.
+func (m *Memory) checkRanks(ranks map[task.ID]task.Rank) error {
+	for id := range ranks {
+		if _, ok := m.tasks[id]; !ok {
+			return ErrResourceNotFound
+		}
+	}
+	return nil
+}
+
+// saveRanks changes ranks of the tasks, once checkRanks passed.
+func (m *Memory) saveRanks(ranks map[task.ID]task.Rank) {
+	for id, rank := range ranks {
+		snapshot := m.tasks[id].Snapshot()
+		snapshot.Rank = rank
+		m.tasks[id] = task.NewTask(snapshot)
+	}
+}
+
+func (r *MemoryTaskRepository) ListDependencies() ([]task.Dependency, error) {
+	r.memory.mutex.RLock()
+	defer r.memory.mutex.RUnlock()
+
+	result := make([]task.Dependency, len(r.memory.dependencies))
+	copy(result, r.memory.dependencies)
+	return result, nil
+}
+
+// AddDependency does nothing when the dependency already exists.
+func (r *MemoryTaskRepository) AddDependency(dependency task.Dependency) error {
+	return r.memory.change(func() error {
+		for _, existing := range r.memory.dependencies {
+			if existing == dependency {
+				return nil
+			}
+		}
+		r.memory.dependencies = append(r.memory.dependencies, dependency)
+		return nil
+	})
+}
+
+func (r *MemoryTaskRepository) RemoveDependency(dependency task.Dependency) error {
+	return r.memory.change(func() error {
+		for i, existing := range r.memory.dependencies {
+			if existing == dependency {
+				r.memory.dependencies = append(r.memory.dependencies[:i:i], r.memory.dependencies[i+1:]...)
+				return nil
+			}
+		}
+		return ErrResourceNotFound
+	})
+}
+
+// ListTags returns all tags ordered by name, including the ones no longer used by any task.
+// Tasks in the trash are not counted.
+func (r *MemoryTaskRepository) ListTags() ([]task.TagUsage, error) {
+	r.memory.mutex.RLock()
+	defer r.memory.mutex.RUnlock()
+
+	counts := make(map[string]int, len(r.memory.tags))
+	for _, stored := range r.memory.tasks {
+		if stored.DeletedAt() != nil {
+			continue
+		}
+		for _, tag := range stored.Tags() {
+			counts[tag]++
+		}
+	}
+	names := make([]string, 0, len(r.memory.tags))
+	for name := range r.memory.tags {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	result := make([]task.TagUsage, len(names))
+	for i, name := range names {
+		result[i] = task.NewTagUsage(name, counts[name])
+	}
+	return result, nil
+}
+
+func (r *MemoryTaskRepository) RenameTag(from string, to string) error {
+	return r.memory.change(func() error {
+		if r.memory.tags[to] {
+			return task.ErrTagExists
+		}
+		if !r.memory.tags[from] {
+			return ErrResourceNotFound
+		}
+		r.memory.replaceTag(from, to)
+		return nil
+	})
+}
+
+// MergeTags moves all tasks from one tag to another, which is created when missing. Then the former is removed.
+func (r *MemoryTaskRepository) MergeTags(from string, into string) error {
+	return r.memory.change(func() error {
+		if !r.memory.tags[from] {
+			return ErrResourceNotFound
+		}
+		r.memory.replaceTag(from, into)
+		return nil
+	})
+}
+
+// replaceTag labels tasks with the other tag instead, keeping their tags sorted and unique. Versions do not change,
+// as tags are renamed for all tasks at once.
+func (m *Memory) replaceTag(from string, to string) {
+	for id, stored := range m.tasks {
+		if !stored.HasTag(from) {
+			continue
+		}
+		snapshot := stored.Snapshot()
+		tags := make([]string, 0, len(snapshot.Tags))
+		for _, tag := range snapshot.Tags {
+			if tag != from && tag != to {
+				tags = append(tags, tag)
+			}
+		}
+		snapshot.Tags = append(tags, to)
+		sort.Strings(snapshot.Tags)
+		m.tasks[id] = task.NewTask(snapshot)
+	}
+	delete(m.tags, from)
+	m.tags[to] = true
+}
+
+// commentCounts counts comments by task, as they are counted whenever tasks are loaded.
+func (m *Memory) commentCounts() map[task.ID]int {
+	result := map[task.ID]int{}
+	for _, comment := range m.comments {
+		result[comment.TaskID()]++
+	}
+	return result
+}
+
+func withCommentCount(entity task.Task, count int) task.Task {
+	snapshot := entity.Snapshot()
+	snapshot.CommentCount = count
+	return task.NewTask(snapshot)
+}
+
+// sortTasks orders tasks by rank, ties resolved by id, like the index on (sort_rank, id).
+func sortTasks(tasks []task.Task) {
+	sort.Slice(tasks, func(i, j int) bool {
+		if tasks[i].Rank() != tasks[j].Rank() {
+			return tasks[i].Rank() < tasks[j].Rank()
+		}
+		return tasks[i].Id() < tasks[j].Id()
+	})
+}
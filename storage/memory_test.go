@@ -0,0 +1,137 @@
+package storage_test
+
+import (
+	"demo-app-go/eventbus"
+	"demo-app-go/storage"
+	"demo-app-go/task"
+	"github.com/stretchr/testify/require"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestMemory(t *testing.T) {
+	type setup struct {
+		tasks    *task.Service
+		comments *task.CommentService
+		time     *task.TimeService
+		events   *[]eventbus.Event
+	}
+	newSetup := func() setup {
+		bus := eventbus.NewBus()
+		events := &[]eventbus.Event{}
+		var mutex sync.Mutex
+		bus.Subscribe(func(event eventbus.Event) {
+			mutex.Lock()
+			defer mutex.Unlock()
+			*events = append(*events, event)
+		})
+		memory := storage.NewMemory(bus)
+		tasks := storage.NewMemoryTaskRepository(memory)
+		return setup{
+			tasks: task.NewService(
+				tasks,
+				storage.NewMemoryRevisionRepository(memory),
+				storage.NewMemoryProjectRepository(memory),
+				task.DeleteOrphan,
+			),
+			comments: task.NewCommentService(storage.NewMemoryCommentRepository(memory), tasks),
+			time:     task.NewTimeService(storage.NewMemoryTimeEntryRepository(memory), tasks),
+			events:   events,
+		}
+	}
+	addTask := func(t *testing.T, service *task.Service, title string) task.Task {
+		command, err := task.NewAddTaskCommand(title, "", nil, nil, []string{"demo"}, nil, nil, nil)
+		require.NoError(t, err, "create command")
+		entity, err := service.AddTask(command, "alice")
+		require.NoError(t, err, "add task")
+		return entity
+	}
+
+	t.Run("versions tasks and publishes events after changes", func(t *testing.T) {
+		t.Parallel()
+		s := newSetup()
+
+		added := addTask(t, s.tasks, "Write docs")
+		require.Equal(t, task.Version(1), added.Version())
+		updated, err := s.tasks.TransitionTask(added.Id(), task.StatusInProgress, "bob")
+		require.NoError(t, err, "unexpected error")
+		require.Equal(t, task.Version(2), updated.Version())
+
+		revisions, err := s.tasks.ListRevisions(added.Id())
+		require.NoError(t, err, "unexpected error")
+		require.Len(t, revisions, 2)
+		require.Len(t, *s.events, 2)
+		require.IsType(t, task.TaskCreated{}, (*s.events)[0])
+		require.IsType(t, task.TaskUpdated{}, (*s.events)[1])
+	})
+	t.Run("purging task removes its comments", func(t *testing.T) {
+		t.Parallel()
+		s := newSetup()
+		entity := addTask(t, s.tasks, "Write docs")
+		command, err := task.NewAddCommentCommand(entity.Id(), "alice", "Started")
+		require.NoError(t, err, "create command")
+		comment, err := s.comments.AddComment(command)
+		require.NoError(t, err, "unexpected error")
+		loaded, err := s.tasks.GetTask(entity.Id())
+		require.NoError(t, err, "unexpected error")
+		require.Equal(t, 1, loaded.CommentCount())
+
+		require.NoError(t, s.tasks.DeleteTask(entity.Id(), "alice"), "delete")
+		count, err := s.tasks.PurgeTrash(time.Now().Add(time.Hour))
+		require.NoError(t, err, "unexpected error")
+		require.Equal(t, 1, count)
+
+		_, err = s.tasks.GetTask(entity.Id())
+		require.ErrorIs(t, err, task.ErrNotFound)
+		_, err = s.comments.GetComment(entity.Id(), comment.Id())
+		require.ErrorIs(t, err, task.ErrNotFound)
+	})
+	t.Run("keeps single running timer per user", func(t *testing.T) {
+		t.Parallel()
+		s := newSetup()
+		first := addTask(t, s.tasks, "Write docs")
+		second := addTask(t, s.tasks, "Review docs")
+
+		_, err := s.time.StartTimer(first.Id(), "alice", "")
+		require.NoError(t, err, "unexpected error")
+		_, err = s.time.StartTimer(second.Id(), "bob", "")
+		require.NoError(t, err, "unexpected error")
+		_, err = s.time.StartTimer(second.Id(), "alice", "")
+		require.ErrorIs(t, err, task.ErrTimerRunning)
+	})
+	t.Run("adds tasks concurrently", func(t *testing.T) {
+		t.Parallel()
+		s := newSetup()
+
+		// Assertions stop the test only on its own goroutine, so the errors are checked once all writers are done.
+		errs := make(chan error, 20)
+		var wg sync.WaitGroup
+		for i := 0; i < 20; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				command, err := task.NewAddTaskCommand("Concurrent", "", nil, nil, []string{"demo"}, nil, nil, nil)
+				if err != nil {
+					errs <- err
+					return
+				}
+				_, err = s.tasks.AddTask(command, "alice")
+				errs <- err
+			}()
+		}
+		wg.Wait()
+		close(errs)
+
+		for err := range errs {
+			require.NoError(t, err, "add task")
+		}
+
+		tasks, err := s.tasks.ListTasks(task.ListFilter{})
+		require.NoError(t, err, "unexpected error")
+		require.Len(t, tasks, 20)
+		tags, err := s.tasks.ListTags()
+		require.NoError(t, err, "unexpected error")
+		require.Equal(t, []task.TagUsage{task.NewTagUsage("demo", 20)}, tags)
+	})
+}
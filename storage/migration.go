@@ -0,0 +1,389 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"embed"
+	"errors"
+	"fmt"
+	"github.com/jmoiron/sqlx"
+	"io/fs"
+	"path"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// migrationLockTimeout is how long Migrator waits for another instance to finish migrating.
+const migrationLockTimeout = time.Minute
+
+// migrationLockPoll is how often Migrator tries to take the lock in Postgres, which cannot wait for it with a timeout.
+const migrationLockPoll = time.Second
+
+// migrationLockName is shared by all instances using the same database.
+const migrationLockName = "schema_migrations"
+
+var (
+	// ErrMigrationLocked is returned when another instance keeps migrating for longer than migrationLockTimeout.
+	ErrMigrationLocked = errors.New("migrations are locked by another instance")
+	// ErrUnknownMigration is returned when migrating to a version which does not exist.
+	ErrUnknownMigration = errors.New("unknown migration")
+)
+
+//go:embed migrations
+var migrationFiles embed.FS
+
+// schemaMigrationsTable keeps track of applied migrations, in each dialect.
+var schemaMigrationsTable = map[dialect]string{
+	dialectMySQL: `CREATE TABLE IF NOT EXISTS schema_migrations (
+		version INT NOT NULL PRIMARY KEY,
+		name VARCHAR(255) NOT NULL,
+		applied_at DATETIME(6) NOT NULL
+	);`,
+	dialectSQLite: `CREATE TABLE IF NOT EXISTS schema_migrations (
+		version INTEGER NOT NULL PRIMARY KEY,
+		name TEXT NOT NULL,
+		applied_at DATETIME NOT NULL
+	);`,
+	dialectPostgres: `CREATE TABLE IF NOT EXISTS schema_migrations (
+		version INTEGER NOT NULL PRIMARY KEY,
+		name VARCHAR(255) NOT NULL,
+		applied_at TIMESTAMPTZ NOT NULL
+	);`,
+}
+
+// migrationFileName is e.g. 0001_create_tasks.up.sql.
+var migrationFileName = regexp.MustCompile(`^(\d+)_(\w+)\.(up|down)\.sql$`)
+
+// Migration is a versioned change of the schema, along with SQL reverting it.
+type Migration struct {
+	Version int
+	Name    string
+	up      string
+	down    string
+}
+
+// MigrationStatus tells whether the migration is applied. AppliedAt is nil for pending migrations.
+type MigrationStatus struct {
+	Migration
+	AppliedAt *time.Time
+}
+
+// Migrator applies migrations embedded in the binary and keeps track of them in the schema_migrations table.
+// Each dialect has its own migrations, with the same versions and names.
+// Statements of a migration run in a transaction, but MariaDB commits DDL implicitly, so a migration failing
+// halfway may leave its first statements applied. Migrations are kept small for that reason.
+type Migrator struct {
+	db         *sqlx.DB
+	dialect    dialect
+	migrations []Migration
+}
+
+func NewMigrator(db *sqlx.DB) (*Migrator, error) {
+	dialect := dialectOf(db)
+	migrations, err := loadMigrations(migrationFiles, path.Join("migrations", string(dialect)))
+	if err != nil {
+		return nil, err
+	}
+	return &Migrator{db: db, dialect: dialect, migrations: migrations}, nil
+}
+
+// Migrations lists all migrations embedded in the binary, ordered by version.
+func (m *Migrator) Migrations() []Migration {
+	result := make([]Migration, len(m.migrations))
+	copy(result, m.migrations)
+	return result
+}
+
+// Status lists all known migrations in order, along with the time they were applied.
+func (m *Migrator) Status() ([]MigrationStatus, error) {
+	var result []MigrationStatus
+	err := m.locked(func(conn *sqlx.Conn) error {
+		applied, err := appliedMigrations(conn)
+		if err != nil {
+			return err
+		}
+		for _, migration := range m.migrations {
+			status := MigrationStatus{Migration: migration}
+			if appliedAt, ok := applied[migration.Version]; ok {
+				status.AppliedAt = &appliedAt
+			}
+			result = append(result, status)
+		}
+		return nil
+	})
+	return result, err
+}
+
+// Up applies all pending migrations. It returns the applied ones, none when the schema is up to date.
+func (m *Migrator) Up() ([]Migration, error) {
+	latest := 0
+	if len(m.migrations) > 0 {
+		latest = m.migrations[len(m.migrations)-1].Version
+	}
+	return m.migrate(func(int) int { return latest })
+}
+
+// Down reverts the latest applied migration.
+func (m *Migrator) Down() ([]Migration, error) {
+	return m.migrate(func(current int) int {
+		previous := 0
+		for _, migration := range m.migrations {
+			if migration.Version < current {
+				previous = migration.Version
+			}
+		}
+		return previous
+	})
+}
+
+// To applies or reverts migrations, so that the given version is the latest applied one. Version 0 reverts all.
+func (m *Migrator) To(version int) ([]Migration, error) {
+	if version != 0 && m.find(version) == nil {
+		return nil, fmt.Errorf("%w: %d", ErrUnknownMigration, version)
+	}
+	return m.migrate(func(int) int { return version })
+}
+
+// migrate applies pending migrations up to the target version, in order, then reverts the applied ones above it,
+// the latest first. The target is computed from the latest applied version.
+func (m *Migrator) migrate(target func(current int) int) ([]Migration, error) {
+	var result []Migration
+	err := m.locked(func(conn *sqlx.Conn) error {
+		applied, err := appliedMigrations(conn)
+		if err != nil {
+			return err
+		}
+		current := 0
+		for version := range applied {
+			if version > current {
+				current = version
+			}
+		}
+		version := target(current)
+
+		for _, migration := range m.migrations {
+			if _, ok := applied[migration.Version]; ok || migration.Version > version {
+				continue
+			}
+			err = apply(conn, migration, migration.up, func(tx *sqlx.Tx) error {
+				_, err := tx.Exec(
+					tx.Rebind("INSERT INTO schema_migrations (version, name, applied_at) VALUES (?, ?, ?);"),
+					migration.Version,
+					migration.Name,
+					time.Now(),
+				)
+				return err
+			})
+			if err != nil {
+				return err
+			}
+			result = append(result, migration)
+		}
+
+		reverted := make([]int, 0, len(applied))
+		for applied := range applied {
+			if applied > version {
+				reverted = append(reverted, applied)
+			}
+		}
+		sort.Sort(sort.Reverse(sort.IntSlice(reverted)))
+		for _, applied := range reverted {
+			migration := m.find(applied)
+			if migration == nil {
+				return fmt.Errorf("%w: %d is applied, but cannot be reverted", ErrUnknownMigration, applied)
+			}
+			err = apply(conn, *migration, migration.down, func(tx *sqlx.Tx) error {
+				_, err := tx.Exec(tx.Rebind("DELETE FROM schema_migrations WHERE version=?;"), migration.Version)
+				return err
+			})
+			if err != nil {
+				return err
+			}
+			result = append(result, *migration)
+		}
+		return nil
+	})
+	return result, err
+}
+
+func (m *Migrator) find(version int) *Migration {
+	for i := range m.migrations {
+		if m.migrations[i].Version == version {
+			return &m.migrations[i]
+		}
+	}
+	return nil
+}
+
+// locked runs the function holding the migration lock, on the connection which holds it.
+// The schema_migrations table is created first, when missing.
+func (m *Migrator) locked(run func(conn *sqlx.Conn) error) error {
+	ctx := context.Background()
+	conn, err := m.db.Connx(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	// SQLite has no named locks. It allows a single writer at a time and runs DDL in transactions, so an instance
+	// migrating concurrently fails on the migration already applied, rather than leaving it applied halfway.
+	// Named locks of MariaDB and advisory locks of Postgres belong to the session, so they are released along with
+	// the connection, should anything fail.
+	switch m.dialect {
+	case dialectMySQL:
+		var acquired sql.NullInt64
+		err = conn.GetContext(ctx, &acquired, "SELECT GET_LOCK(?, ?);", migrationLockName, migrationLockTimeout.Seconds())
+		if err != nil {
+			return err
+		}
+		if !acquired.Valid || acquired.Int64 != 1 {
+			return ErrMigrationLocked
+		}
+		defer conn.ExecContext(ctx, "SELECT RELEASE_LOCK(?);", migrationLockName)
+	case dialectPostgres:
+		err = lockPostgres(ctx, conn)
+		if err != nil {
+			return err
+		}
+		defer conn.ExecContext(ctx, "SELECT pg_advisory_unlock(hashtext($1));", migrationLockName)
+	}
+
+	_, err = conn.ExecContext(ctx, schemaMigrationsTable[m.dialect])
+	if err != nil {
+		return err
+	}
+
+	return run(conn)
+}
+
+// lockPostgres takes the advisory lock keyed by hash of migrationLockName, polling until migrationLockTimeout passes.
+func lockPostgres(ctx context.Context, conn *sqlx.Conn) error {
+	deadline := time.Now().Add(migrationLockTimeout)
+	for {
+		var acquired bool
+		err := conn.GetContext(ctx, &acquired, "SELECT pg_try_advisory_lock(hashtext($1));", migrationLockName)
+		if err != nil {
+			return err
+		}
+		if acquired {
+			return nil
+		}
+		if time.Now().After(deadline) {
+			return ErrMigrationLocked
+		}
+		time.Sleep(migrationLockPoll)
+	}
+}
+
+func appliedMigrations(conn *sqlx.Conn) (map[int]time.Time, error) {
+	var records []struct {
+		Version   int       `db:"version"`
+		AppliedAt time.Time `db:"applied_at"`
+	}
+	err := conn.SelectContext(context.Background(), &records, "SELECT version, applied_at FROM schema_migrations;")
+	if err != nil {
+		return nil, err
+	}
+
+	result := make(map[int]time.Time, len(records))
+	for _, record := range records {
+		result[record.Version] = record.AppliedAt
+	}
+	return result, nil
+}
+
+// apply runs statements of the migration one by one, as the driver does not accept more at once.
+// Then it records the change in schema_migrations.
+func apply(conn *sqlx.Conn, migration Migration, script string, record func(tx *sqlx.Tx) error) error {
+	tx, err := conn.BeginTxx(context.Background(), nil)
+	if err != nil {
+		return err
+	}
+	defer rollback(tx)
+
+	for _, statement := range splitStatements(script) {
+		_, err = tx.Exec(statement)
+		if err != nil {
+			return fmt.Errorf("migration %d %s: %w", migration.Version, migration.Name, err)
+		}
+	}
+	err = record(tx)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// splitStatements splits the script on semicolons ending a line. Lines starting with -- are comments.
+func splitStatements(script string) []string {
+	var result []string
+	var statement strings.Builder
+	for _, line := range strings.Split(script, "\n") {
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" || strings.HasPrefix(trimmed, "--") {
+			continue
+		}
+		statement.WriteString(line)
+		statement.WriteString("\n")
+		if strings.HasSuffix(trimmed, ";") {
+			result = append(result, strings.TrimSpace(statement.String()))
+			statement.Reset()
+		}
+	}
+	if rest := strings.TrimSpace(statement.String()); rest != "" {
+		result = append(result, rest)
+	}
+	return result
+}
+
+// loadMigrations reads migrations from the directory, ordered by version. Each version needs both up and down script.
+func loadMigrations(files fs.FS, dir string) ([]Migration, error) {
+	entries, err := fs.ReadDir(files, dir)
+	if err != nil {
+		return nil, err
+	}
+
+	byVersion := map[int]*Migration{}
+	for _, entry := range entries {
+		match := migrationFileName.FindStringSubmatch(entry.Name())
+		if entry.IsDir() || match == nil {
+			return nil, fmt.Errorf("unexpected migration file %s", entry.Name())
+		}
+		version, err := strconv.Atoi(match[1])
+		if err != nil || version == 0 {
+			return nil, fmt.Errorf("invalid migration version in %s", entry.Name())
+		}
+		content, err := fs.ReadFile(files, path.Join(dir, entry.Name()))
+		if err != nil {
+			return nil, err
+		}
+
+		migration, ok := byVersion[version]
+		if !ok {
+			migration = &Migration{Version: version, Name: match[2]}
+			byVersion[version] = migration
+		}
+		if migration.Name != match[2] {
+			return nil, fmt.Errorf("migration %d has two names, %s and %s", version, migration.Name, match[2])
+		}
+		if match[3] == "up" {
+			migration.up = string(content)
+		} else {
+			migration.down = string(content)
+		}
+	}
+
+	result := make([]Migration, 0, len(byVersion))
+	for _, migration := range byVersion {
+		if strings.TrimSpace(migration.up) == "" || strings.TrimSpace(migration.down) == "" {
+			return nil, fmt.Errorf("migration %d %s needs both up and down script", migration.Version, migration.Name)
+		}
+		result = append(result, *migration)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Version < result[j].Version })
+	return result, nil
+}
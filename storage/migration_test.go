@@ -0,0 +1,33 @@
+package storage_test
+
+import (
+	"demo-app-go/storage"
+	"github.com/jmoiron/sqlx"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestNewMigrator(t *testing.T) {
+	migrator, err := storage.NewMigrator(sqlx.NewDb(nil, "mysql"))
+	require.NoError(t, err, "embedded migrations are expected to load")
+
+	migrations := migrator.Migrations()
+	require.NotEmpty(t, migrations)
+	for i, migration := range migrations {
+		require.Equal(t, i+1, migration.Version, "versions are expected to have no gaps")
+		require.NotEmpty(t, migration.Name)
+	}
+	require.Equal(t, "create_tasks", migrations[0].Name)
+
+	t.Run("dialects share versions", func(t *testing.T) {
+		for _, driverName := range []string{"sqlite", "postgres"} {
+			other, err := storage.NewMigrator(sqlx.NewDb(nil, driverName))
+			require.NoError(t, err, "embedded migrations are expected to load")
+			require.Len(t, other.Migrations(), len(migrations))
+			for i, migration := range other.Migrations() {
+				require.Equal(t, migrations[i].Version, migration.Version)
+				require.Equal(t, migrations[i].Name, migration.Name)
+			}
+		}
+	})
+}
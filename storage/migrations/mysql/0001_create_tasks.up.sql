@@ -0,0 +1,58 @@
+-- Columns follow the order of taskRecord, so SELECT * reads them the same way as named columns.
+CREATE TABLE task (
+    id INT UNSIGNED NOT NULL AUTO_INCREMENT,
+    title TEXT NOT NULL,
+    description TEXT NOT NULL,
+    status VARCHAR(20) NOT NULL,
+    due_at DATETIME(6) NULL,
+    due_timezone VARCHAR(64) NULL,
+    parent_id INT UNSIGNED NULL,
+    recurrence_rule VARCHAR(255) NULL,
+    recurrence_start DATETIME(6) NULL,
+    -- Ranks are compared byte by byte, like Go compares strings.
+    sort_rank VARCHAR(64) CHARACTER SET ascii COLLATE ascii_bin NOT NULL DEFAULT '',
+    version INT UNSIGNED NOT NULL DEFAULT 1,
+    created_at DATETIME(6) NOT NULL,
+    updated_at DATETIME(6) NULL,
+    deleted_at DATETIME(6) NULL,
+    PRIMARY KEY (id),
+    INDEX task_sort_rank (sort_rank, id),
+    INDEX task_deleted_at_due_at (deleted_at, due_at),
+    CONSTRAINT task_parent FOREIGN KEY (parent_id) REFERENCES task (id) ON DELETE SET NULL
+) ENGINE = InnoDB DEFAULT CHARSET = utf8mb4 COLLATE = utf8mb4_unicode_ci;
+
+-- Tags are normalized by the application, so they are compared exactly.
+CREATE TABLE tag (
+    id INT UNSIGNED NOT NULL AUTO_INCREMENT,
+    name VARCHAR(50) CHARACTER SET utf8mb4 COLLATE utf8mb4_bin NOT NULL,
+    PRIMARY KEY (id),
+    UNIQUE INDEX tag_name (name)
+) ENGINE = InnoDB DEFAULT CHARSET = utf8mb4 COLLATE = utf8mb4_unicode_ci;
+
+CREATE TABLE task_tag (
+    task_id INT UNSIGNED NOT NULL,
+    tag_id INT UNSIGNED NOT NULL,
+    PRIMARY KEY (task_id, tag_id),
+    INDEX task_tag_tag_id (tag_id),
+    CONSTRAINT task_tag_task FOREIGN KEY (task_id) REFERENCES task (id),
+    CONSTRAINT task_tag_tag FOREIGN KEY (tag_id) REFERENCES tag (id)
+) ENGINE = InnoDB;
+
+CREATE TABLE task_dependency (
+    blocker_id INT UNSIGNED NOT NULL,
+    blocked_id INT UNSIGNED NOT NULL,
+    PRIMARY KEY (blocker_id, blocked_id),
+    INDEX task_dependency_blocked_id (blocked_id),
+    CONSTRAINT task_dependency_blocker FOREIGN KEY (blocker_id) REFERENCES task (id),
+    CONSTRAINT task_dependency_blocked FOREIGN KEY (blocked_id) REFERENCES task (id)
+) ENGINE = InnoDB;
+
+CREATE TABLE task_checklist_item (
+    task_id INT UNSIGNED NOT NULL,
+    id INT UNSIGNED NOT NULL,
+    text VARCHAR(500) NOT NULL,
+    done BOOLEAN NOT NULL DEFAULT FALSE,
+    position INT NOT NULL,
+    PRIMARY KEY (task_id, id),
+    CONSTRAINT task_checklist_item_task FOREIGN KEY (task_id) REFERENCES task (id)
+) ENGINE = InnoDB DEFAULT CHARSET = utf8mb4 COLLATE = utf8mb4_unicode_ci;
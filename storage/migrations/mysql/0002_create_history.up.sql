@@ -0,0 +1,28 @@
+-- Revisions hold JSON documents, see revisionStateRecord.
+CREATE TABLE task_revision (
+    task_id INT UNSIGNED NOT NULL,
+    number INT UNSIGNED NOT NULL,
+    actor VARCHAR(255) NOT NULL,
+    created_at DATETIME(6) NOT NULL,
+    state MEDIUMTEXT NOT NULL,
+    changes MEDIUMTEXT NOT NULL,
+    PRIMARY KEY (task_id, number),
+    CONSTRAINT task_revision_task FOREIGN KEY (task_id) REFERENCES task (id)
+) ENGINE = InnoDB DEFAULT CHARSET = utf8mb4 COLLATE = utf8mb4_unicode_ci;
+
+-- Events outlive the tasks they are about, so there is no foreign key.
+-- Events which failed too many times are given up on, they stay with failed_at set until looked into.
+CREATE TABLE outbox (
+    id BIGINT UNSIGNED NOT NULL AUTO_INCREMENT,
+    event_type VARCHAR(50) NOT NULL,
+    event_key VARCHAR(100) NOT NULL,
+    payload MEDIUMTEXT NOT NULL,
+    created_at DATETIME(6) NOT NULL,
+    attempts INT NOT NULL DEFAULT 0,
+    next_attempt_at DATETIME(6) NULL,
+    last_error TEXT NULL,
+    delivered_at DATETIME(6) NULL,
+    failed_at DATETIME(6) NULL,
+    PRIMARY KEY (id),
+    INDEX outbox_pending (delivered_at, failed_at, event_key, id)
+) ENGINE = InnoDB DEFAULT CHARSET = utf8mb4 COLLATE = utf8mb4_unicode_ci;
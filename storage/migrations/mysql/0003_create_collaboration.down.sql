@@ -0,0 +1,3 @@
+DROP TABLE task_time_entry;
+DROP TABLE task_attachment;
+DROP TABLE task_comment;
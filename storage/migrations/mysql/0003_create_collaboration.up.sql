@@ -0,0 +1,44 @@
+CREATE TABLE task_comment (
+    id INT UNSIGNED NOT NULL AUTO_INCREMENT,
+    task_id INT UNSIGNED NOT NULL,
+    author VARCHAR(255) NOT NULL,
+    body TEXT NOT NULL,
+    created_at DATETIME(6) NOT NULL,
+    edited_at DATETIME(6) NULL,
+    PRIMARY KEY (id),
+    INDEX task_comment_task_id (task_id, created_at),
+    CONSTRAINT task_comment_task FOREIGN KEY (task_id) REFERENCES task (id)
+) ENGINE = InnoDB DEFAULT CHARSET = utf8mb4 COLLATE = utf8mb4_unicode_ci;
+
+-- Attachments sharing content have the same hash, which is the key of the content in the blob store.
+CREATE TABLE task_attachment (
+    id INT UNSIGNED NOT NULL AUTO_INCREMENT,
+    task_id INT UNSIGNED NOT NULL,
+    file_name VARCHAR(255) NOT NULL,
+    content_type VARCHAR(255) NOT NULL,
+    size BIGINT NOT NULL,
+    hash CHAR(64) CHARACTER SET ascii NOT NULL,
+    created_at DATETIME(6) NOT NULL,
+    PRIMARY KEY (id),
+    INDEX task_attachment_task_id (task_id, created_at),
+    UNIQUE INDEX task_attachment_task_hash (task_id, hash),
+    INDEX task_attachment_hash (hash),
+    CONSTRAINT task_attachment_task FOREIGN KEY (task_id) REFERENCES task (id)
+) ENGINE = InnoDB DEFAULT CHARSET = utf8mb4 COLLATE = utf8mb4_unicode_ci;
+
+CREATE TABLE task_time_entry (
+    id INT UNSIGNED NOT NULL AUTO_INCREMENT,
+    task_id INT UNSIGNED NOT NULL,
+    user VARCHAR(255) NOT NULL,
+    start_at DATETIME(6) NOT NULL,
+    end_at DATETIME(6) NULL,
+    note TEXT NOT NULL,
+    created_at DATETIME(6) NOT NULL,
+    -- Running entries have no end. The unique key on their user allows a single running timer per user.
+    running_user VARCHAR(255) AS (IF(end_at IS NULL, user, NULL)) PERSISTENT,
+    PRIMARY KEY (id),
+    UNIQUE INDEX task_time_entry_running_user (running_user),
+    INDEX task_time_entry_task_id (task_id, start_at),
+    INDEX task_time_entry_start_at (start_at),
+    CONSTRAINT task_time_entry_task FOREIGN KEY (task_id) REFERENCES task (id)
+) ENGINE = InnoDB DEFAULT CHARSET = utf8mb4 COLLATE = utf8mb4_unicode_ci;
@@ -0,0 +1,27 @@
+CREATE TABLE project (
+    id INT UNSIGNED NOT NULL AUTO_INCREMENT,
+    name VARCHAR(100) NOT NULL,
+    description TEXT NOT NULL,
+    created_at DATETIME(6) NOT NULL,
+    updated_at DATETIME(6) NULL,
+    PRIMARY KEY (id)
+) ENGINE = InnoDB DEFAULT CHARSET = utf8mb4 COLLATE = utf8mb4_unicode_ci;
+
+-- Positions are not unique, as saving a project rewrites them one column at a time.
+CREATE TABLE project_column (
+    id INT UNSIGNED NOT NULL AUTO_INCREMENT,
+    project_id INT UNSIGNED NOT NULL,
+    name VARCHAR(50) NOT NULL,
+    wip_limit INT NOT NULL DEFAULT 0,
+    position INT NOT NULL,
+    PRIMARY KEY (id),
+    INDEX project_column_project_id (project_id, position),
+    CONSTRAINT project_column_project FOREIGN KEY (project_id) REFERENCES project (id)
+) ENGINE = InnoDB DEFAULT CHARSET = utf8mb4 COLLATE = utf8mb4_unicode_ci;
+
+-- Columns still holding tasks cannot be removed.
+ALTER TABLE task
+    ADD COLUMN project_id INT UNSIGNED NULL AFTER parent_id,
+    ADD COLUMN column_id INT UNSIGNED NULL AFTER project_id,
+    ADD CONSTRAINT task_project FOREIGN KEY (project_id) REFERENCES project (id) ON DELETE RESTRICT,
+    ADD CONSTRAINT task_column FOREIGN KEY (column_id) REFERENCES project_column (id) ON DELETE RESTRICT;
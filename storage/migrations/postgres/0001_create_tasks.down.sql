@@ -0,0 +1,5 @@
+DROP TABLE task_checklist_item;
+DROP TABLE task_dependency;
+DROP TABLE task_tag;
+DROP TABLE tag;
+DROP TABLE task;
@@ -0,0 +1,57 @@
+-- Times are stored in UTC as TIMESTAMPTZ, the timezone of due dates is kept separately in due_timezone.
+CREATE TABLE task (
+    id INTEGER GENERATED BY DEFAULT AS IDENTITY PRIMARY KEY,
+    title TEXT NOT NULL,
+    description TEXT NOT NULL,
+    status VARCHAR(20) NOT NULL,
+    due_at TIMESTAMPTZ NULL,
+    due_timezone VARCHAR(64) NULL,
+    parent_id INTEGER NULL,
+    recurrence_rule VARCHAR(255) NULL,
+    recurrence_start TIMESTAMPTZ NULL,
+    -- Ranks are compared byte by byte, like Go compares strings.
+    sort_rank VARCHAR(64) COLLATE "C" NOT NULL DEFAULT '',
+    version INTEGER NOT NULL DEFAULT 1,
+    created_at TIMESTAMPTZ NOT NULL,
+    updated_at TIMESTAMPTZ NULL,
+    deleted_at TIMESTAMPTZ NULL,
+    CONSTRAINT task_parent FOREIGN KEY (parent_id) REFERENCES task (id) ON DELETE SET NULL
+);
+CREATE INDEX task_sort_rank ON task (sort_rank, id);
+CREATE INDEX task_deleted_at_due_at ON task (deleted_at, due_at);
+CREATE INDEX task_parent_id ON task (parent_id);
+
+-- Tags are normalized by the application, so they are compared exactly.
+CREATE TABLE tag (
+    id INTEGER GENERATED BY DEFAULT AS IDENTITY PRIMARY KEY,
+    name VARCHAR(50) COLLATE "C" NOT NULL,
+    CONSTRAINT tag_name UNIQUE (name)
+);
+
+CREATE TABLE task_tag (
+    task_id INTEGER NOT NULL,
+    tag_id INTEGER NOT NULL,
+    PRIMARY KEY (task_id, tag_id),
+    CONSTRAINT task_tag_task FOREIGN KEY (task_id) REFERENCES task (id),
+    CONSTRAINT task_tag_tag FOREIGN KEY (tag_id) REFERENCES tag (id)
+);
+CREATE INDEX task_tag_tag_id ON task_tag (tag_id);
+
+CREATE TABLE task_dependency (
+    blocker_id INTEGER NOT NULL,
+    blocked_id INTEGER NOT NULL,
+    PRIMARY KEY (blocker_id, blocked_id),
+    CONSTRAINT task_dependency_blocker FOREIGN KEY (blocker_id) REFERENCES task (id),
+    CONSTRAINT task_dependency_blocked FOREIGN KEY (blocked_id) REFERENCES task (id)
+);
+CREATE INDEX task_dependency_blocked_id ON task_dependency (blocked_id);
+
+CREATE TABLE task_checklist_item (
+    task_id INTEGER NOT NULL,
+    id INTEGER NOT NULL,
+    text VARCHAR(500) NOT NULL,
+    done BOOLEAN NOT NULL DEFAULT FALSE,
+    position INTEGER NOT NULL,
+    PRIMARY KEY (task_id, id),
+    CONSTRAINT task_checklist_item_task FOREIGN KEY (task_id) REFERENCES task (id)
+);
@@ -0,0 +1,27 @@
+-- Revisions hold JSON documents, see revisionStateRecord. They are kept as text, exactly as encoded.
+CREATE TABLE task_revision (
+    task_id INTEGER NOT NULL,
+    number INTEGER NOT NULL,
+    actor VARCHAR(255) NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL,
+    state TEXT NOT NULL,
+    changes TEXT NOT NULL,
+    PRIMARY KEY (task_id, number),
+    CONSTRAINT task_revision_task FOREIGN KEY (task_id) REFERENCES task (id)
+);
+
+-- Events outlive the tasks they are about, so there is no foreign key.
+-- Events which failed too many times are given up on, they stay with failed_at set until looked into.
+CREATE TABLE outbox (
+    id BIGINT GENERATED BY DEFAULT AS IDENTITY PRIMARY KEY,
+    event_type VARCHAR(50) NOT NULL,
+    event_key VARCHAR(100) NOT NULL,
+    payload TEXT NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL,
+    attempts INTEGER NOT NULL DEFAULT 0,
+    next_attempt_at TIMESTAMPTZ NULL,
+    last_error TEXT NULL,
+    delivered_at TIMESTAMPTZ NULL,
+    failed_at TIMESTAMPTZ NULL
+);
+CREATE INDEX outbox_pending ON outbox (delivered_at, failed_at, event_key, id);
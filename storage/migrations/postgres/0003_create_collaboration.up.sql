@@ -0,0 +1,41 @@
+CREATE TABLE task_comment (
+    id INTEGER GENERATED BY DEFAULT AS IDENTITY PRIMARY KEY,
+    task_id INTEGER NOT NULL,
+    author VARCHAR(255) NOT NULL,
+    body TEXT NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL,
+    edited_at TIMESTAMPTZ NULL,
+    CONSTRAINT task_comment_task FOREIGN KEY (task_id) REFERENCES task (id)
+);
+CREATE INDEX task_comment_task_id ON task_comment (task_id, created_at);
+
+-- Attachments sharing content have the same hash, which is the key of the content in the blob store.
+CREATE TABLE task_attachment (
+    id INTEGER GENERATED BY DEFAULT AS IDENTITY PRIMARY KEY,
+    task_id INTEGER NOT NULL,
+    file_name VARCHAR(255) NOT NULL,
+    content_type VARCHAR(255) NOT NULL,
+    size BIGINT NOT NULL,
+    hash CHAR(64) NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL,
+    CONSTRAINT task_attachment_task FOREIGN KEY (task_id) REFERENCES task (id)
+);
+CREATE INDEX task_attachment_task_id ON task_attachment (task_id, created_at);
+CREATE UNIQUE INDEX task_attachment_task_hash ON task_attachment (task_id, hash);
+CREATE INDEX task_attachment_hash ON task_attachment (hash);
+
+-- User is a reserved word, so the column is always quoted.
+CREATE TABLE task_time_entry (
+    id INTEGER GENERATED BY DEFAULT AS IDENTITY PRIMARY KEY,
+    task_id INTEGER NOT NULL,
+    "user" VARCHAR(255) NOT NULL,
+    start_at TIMESTAMPTZ NOT NULL,
+    end_at TIMESTAMPTZ NULL,
+    note TEXT NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL,
+    CONSTRAINT task_time_entry_task FOREIGN KEY (task_id) REFERENCES task (id)
+);
+-- Running entries have no end. The partial unique index allows a single running timer per user.
+CREATE UNIQUE INDEX task_time_entry_running_user ON task_time_entry ("user") WHERE end_at IS NULL;
+CREATE INDEX task_time_entry_task_id ON task_time_entry (task_id, start_at);
+CREATE INDEX task_time_entry_start_at ON task_time_entry (start_at);
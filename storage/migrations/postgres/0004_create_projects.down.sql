@@ -0,0 +1,8 @@
+ALTER TABLE task
+    DROP CONSTRAINT task_column,
+    DROP CONSTRAINT task_project;
+ALTER TABLE task
+    DROP COLUMN column_id,
+    DROP COLUMN project_id;
+DROP TABLE project_column;
+DROP TABLE project;
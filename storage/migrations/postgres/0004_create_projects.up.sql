@@ -0,0 +1,25 @@
+CREATE TABLE project (
+    id INTEGER GENERATED BY DEFAULT AS IDENTITY PRIMARY KEY,
+    name VARCHAR(100) NOT NULL,
+    description TEXT NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL,
+    updated_at TIMESTAMPTZ NULL
+);
+
+-- Positions are not unique, as saving a project rewrites them one column at a time.
+CREATE TABLE project_column (
+    id INTEGER GENERATED BY DEFAULT AS IDENTITY PRIMARY KEY,
+    project_id INTEGER NOT NULL,
+    name VARCHAR(50) NOT NULL,
+    wip_limit INTEGER NOT NULL DEFAULT 0,
+    position INTEGER NOT NULL,
+    CONSTRAINT project_column_project FOREIGN KEY (project_id) REFERENCES project (id)
+);
+CREATE INDEX project_column_project_id ON project_column (project_id, position);
+
+-- Columns still holding tasks cannot be removed.
+ALTER TABLE task
+    ADD COLUMN project_id INTEGER NULL,
+    ADD COLUMN column_id INTEGER NULL,
+    ADD CONSTRAINT task_project FOREIGN KEY (project_id) REFERENCES project (id) ON DELETE RESTRICT,
+    ADD CONSTRAINT task_column FOREIGN KEY (column_id) REFERENCES project_column (id) ON DELETE RESTRICT;
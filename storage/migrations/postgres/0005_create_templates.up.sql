@@ -0,0 +1,8 @@
+-- Definition is the JSON encoded task tree of the template, see templateTaskRecord.
+CREATE TABLE template (
+    id INTEGER GENERATED BY DEFAULT AS IDENTITY PRIMARY KEY,
+    name VARCHAR(100) NOT NULL,
+    definition TEXT NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL,
+    updated_at TIMESTAMPTZ NULL
+);
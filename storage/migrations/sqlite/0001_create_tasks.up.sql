@@ -0,0 +1,50 @@
+-- Times are stored as text in UTC, which the driver parses back for columns declared as DATETIME.
+CREATE TABLE task (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    title TEXT NOT NULL,
+    description TEXT NOT NULL,
+    status TEXT NOT NULL,
+    due_at DATETIME NULL,
+    due_timezone TEXT NULL,
+    parent_id INTEGER NULL REFERENCES task (id) ON DELETE SET NULL,
+    recurrence_rule TEXT NULL,
+    recurrence_start DATETIME NULL,
+    -- Text is compared byte by byte by default, like Go compares strings.
+    sort_rank TEXT NOT NULL DEFAULT '',
+    version INTEGER NOT NULL DEFAULT 1,
+    created_at DATETIME NOT NULL,
+    updated_at DATETIME NULL,
+    deleted_at DATETIME NULL
+);
+CREATE INDEX task_sort_rank ON task (sort_rank, id);
+CREATE INDEX task_deleted_at_due_at ON task (deleted_at, due_at);
+CREATE INDEX task_parent_id ON task (parent_id);
+
+CREATE TABLE tag (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    name TEXT NOT NULL
+);
+CREATE UNIQUE INDEX tag_name ON tag (name);
+
+CREATE TABLE task_tag (
+    task_id INTEGER NOT NULL REFERENCES task (id),
+    tag_id INTEGER NOT NULL REFERENCES tag (id),
+    PRIMARY KEY (task_id, tag_id)
+);
+CREATE INDEX task_tag_tag_id ON task_tag (tag_id);
+
+CREATE TABLE task_dependency (
+    blocker_id INTEGER NOT NULL REFERENCES task (id),
+    blocked_id INTEGER NOT NULL REFERENCES task (id),
+    PRIMARY KEY (blocker_id, blocked_id)
+);
+CREATE INDEX task_dependency_blocked_id ON task_dependency (blocked_id);
+
+CREATE TABLE task_checklist_item (
+    task_id INTEGER NOT NULL REFERENCES task (id),
+    id INTEGER NOT NULL,
+    text TEXT NOT NULL,
+    done BOOLEAN NOT NULL DEFAULT FALSE,
+    position INTEGER NOT NULL,
+    PRIMARY KEY (task_id, id)
+);
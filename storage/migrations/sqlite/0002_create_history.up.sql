@@ -0,0 +1,26 @@
+-- Revisions hold JSON documents, see revisionStateRecord.
+CREATE TABLE task_revision (
+    task_id INTEGER NOT NULL REFERENCES task (id),
+    number INTEGER NOT NULL,
+    actor TEXT NOT NULL,
+    created_at DATETIME NOT NULL,
+    state TEXT NOT NULL,
+    changes TEXT NOT NULL,
+    PRIMARY KEY (task_id, number)
+);
+
+-- Events outlive the tasks they are about, so there is no foreign key.
+-- Events which failed too many times are given up on, they stay with failed_at set until looked into.
+CREATE TABLE outbox (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    event_type TEXT NOT NULL,
+    event_key TEXT NOT NULL,
+    payload TEXT NOT NULL,
+    created_at DATETIME NOT NULL,
+    attempts INTEGER NOT NULL DEFAULT 0,
+    next_attempt_at DATETIME NULL,
+    last_error TEXT NULL,
+    delivered_at DATETIME NULL,
+    failed_at DATETIME NULL
+);
+CREATE INDEX outbox_pending ON outbox (delivered_at, failed_at, event_key, id);
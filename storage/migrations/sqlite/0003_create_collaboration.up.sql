@@ -0,0 +1,37 @@
+CREATE TABLE task_comment (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    task_id INTEGER NOT NULL REFERENCES task (id),
+    author TEXT NOT NULL,
+    body TEXT NOT NULL,
+    created_at DATETIME NOT NULL,
+    edited_at DATETIME NULL
+);
+CREATE INDEX task_comment_task_id ON task_comment (task_id, created_at);
+
+-- Attachments sharing content have the same hash, which is the key of the content in the blob store.
+CREATE TABLE task_attachment (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    task_id INTEGER NOT NULL REFERENCES task (id),
+    file_name TEXT NOT NULL,
+    content_type TEXT NOT NULL,
+    size INTEGER NOT NULL,
+    hash TEXT NOT NULL,
+    created_at DATETIME NOT NULL
+);
+CREATE INDEX task_attachment_task_id ON task_attachment (task_id, created_at);
+CREATE UNIQUE INDEX task_attachment_task_hash ON task_attachment (task_id, hash);
+CREATE INDEX task_attachment_hash ON task_attachment (hash);
+
+CREATE TABLE task_time_entry (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    task_id INTEGER NOT NULL REFERENCES task (id),
+    user TEXT NOT NULL,
+    start_at DATETIME NOT NULL,
+    end_at DATETIME NULL,
+    note TEXT NOT NULL,
+    created_at DATETIME NOT NULL
+);
+-- Running entries have no end. The partial unique index allows a single running timer per user.
+CREATE UNIQUE INDEX task_time_entry_running_user ON task_time_entry (user) WHERE end_at IS NULL;
+CREATE INDEX task_time_entry_task_id ON task_time_entry (task_id, start_at);
+CREATE INDEX task_time_entry_start_at ON task_time_entry (start_at);
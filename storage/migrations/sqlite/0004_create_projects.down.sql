@@ -0,0 +1,30 @@
+-- Columns taking part in foreign keys cannot be dropped, so the task table is recreated without them.
+-- Foreign keys of other tables referring to tasks are checked once the tasks are back in place.
+PRAGMA defer_foreign_keys = ON;
+CREATE TEMP TABLE task_backup AS
+    SELECT id, title, description, status, due_at, due_timezone, parent_id, recurrence_rule, recurrence_start,
+    sort_rank, version, created_at, updated_at, deleted_at FROM task;
+DROP TABLE task;
+CREATE TABLE task (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    title TEXT NOT NULL,
+    description TEXT NOT NULL,
+    status TEXT NOT NULL,
+    due_at DATETIME NULL,
+    due_timezone TEXT NULL,
+    parent_id INTEGER NULL REFERENCES task (id) ON DELETE SET NULL,
+    recurrence_rule TEXT NULL,
+    recurrence_start DATETIME NULL,
+    sort_rank TEXT NOT NULL DEFAULT '',
+    version INTEGER NOT NULL DEFAULT 1,
+    created_at DATETIME NOT NULL,
+    updated_at DATETIME NULL,
+    deleted_at DATETIME NULL
+);
+INSERT INTO task SELECT * FROM task_backup;
+DROP TABLE task_backup;
+CREATE INDEX task_sort_rank ON task (sort_rank, id);
+CREATE INDEX task_deleted_at_due_at ON task (deleted_at, due_at);
+CREATE INDEX task_parent_id ON task (parent_id);
+DROP TABLE project_column;
+DROP TABLE project;
@@ -0,0 +1,21 @@
+CREATE TABLE project (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    name TEXT NOT NULL,
+    description TEXT NOT NULL,
+    created_at DATETIME NOT NULL,
+    updated_at DATETIME NULL
+);
+
+-- Positions are not unique, as saving a project rewrites them one column at a time.
+CREATE TABLE project_column (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    project_id INTEGER NOT NULL REFERENCES project (id),
+    name TEXT NOT NULL,
+    wip_limit INTEGER NOT NULL DEFAULT 0,
+    position INTEGER NOT NULL
+);
+CREATE INDEX project_column_project_id ON project_column (project_id, position);
+
+-- Columns still holding tasks cannot be removed.
+ALTER TABLE task ADD COLUMN project_id INTEGER NULL REFERENCES project (id) ON DELETE RESTRICT;
+ALTER TABLE task ADD COLUMN column_id INTEGER NULL REFERENCES project_column (id) ON DELETE RESTRICT;
@@ -0,0 +1,8 @@
+-- Definition is the JSON encoded task tree of the template, see templateTaskRecord.
+CREATE TABLE template (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    name TEXT NOT NULL,
+    definition TEXT NOT NULL,
+    created_at DATETIME NOT NULL,
+    updated_at DATETIME NULL
+);
@@ -0,0 +1,267 @@
+package storage
+
+import (
+	"demo-app-go/eventbus"
+	"demo-app-go/task"
+	"encoding/json"
+	"fmt"
+	"github.com/jmoiron/sqlx"
+	"time"
+)
+
+const (
+	// outboxBatchSize is how many events OutboxRelay.Relay reads at once.
+	outboxBatchSize = 100
+	// outboxMinBackoff doubles with every failed attempt, up to outboxMaxBackoff.
+	outboxMinBackoff = time.Second
+	outboxMaxBackoff = 10 * time.Minute
+	// outboxMaxAttempts is how many times an event is tried before it is given up on, which takes a few hours.
+	outboxMaxAttempts = 30
+)
+
+type outboxRecord struct {
+	Id            uint64     `db:"id"`
+	EventType     string     `db:"event_type"`
+	EventKey      string     `db:"event_key"`
+	Payload       []byte     `db:"payload"`
+	CreatedAt     time.Time  `db:"created_at"`
+	Attempts      int        `db:"attempts"`
+	NextAttemptAt *time.Time `db:"next_attempt_at"`
+	LastError     *string    `db:"last_error"`
+	DeliveredAt   *time.Time `db:"delivered_at"`
+	FailedAt      *time.Time `db:"failed_at"`
+}
+
+// outboxPayload is shared by all task events, each using only some of the fields.
+type outboxPayload struct {
+	Task      *taskRecord           `json:"task,omitempty"`
+	Tags      []string              `json:"tags,omitempty"`
+	Checklist []checklistItemRecord `json:"checklist,omitempty"`
+	TaskID    task.ID               `json:"taskId,omitempty"`
+	Changes   json.RawMessage       `json:"changes,omitempty"`
+	Permanent bool                  `json:"permanent,omitempty"`
+	Actor     string                `json:"actor"`
+	At        time.Time             `json:"at"`
+}
+
+// insertOutboxEvent is called by TaskRepository, so the event is queued in the same transaction as the change of
+// the task it describes.
+func insertOutboxEvent(tx *sqlx.Tx, event eventbus.Event) error {
+	eventType, payload, err := encodeEvent(event)
+	if err != nil {
+		return err
+	}
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.NamedExec(
+		`INSERT INTO outbox (event_type, event_key, payload, created_at, attempts)
+		VALUES (:eventType, :eventKey, :payload, :createdAt, 0);`,
+		map[string]any{
+			"eventType": eventType,
+			"eventKey":  event.Key(),
+			"payload":   string(data),
+			"createdAt": time.Now(),
+		},
+	)
+	return err
+}
+
+func encodeEvent(event eventbus.Event) (string, outboxPayload, error) {
+	switch e := event.(type) {
+	case task.TaskCreated:
+		return "TaskCreated", createTaskPayload(e.Task, e.Actor, e.At), nil
+	case task.TaskUpdated:
+		payload := createTaskPayload(e.Task, e.Actor, e.At)
+		changes, err := encodeChanges(e.Changes)
+		if err != nil {
+			return "", outboxPayload{}, err
+		}
+		payload.Changes = changes
+		return "TaskUpdated", payload, nil
+	case task.TaskDeleted:
+		return "TaskDeleted", outboxPayload{TaskID: e.TaskID, Permanent: e.Permanent, Actor: e.Actor, At: e.At}, nil
+	default:
+		return "", outboxPayload{}, fmt.Errorf("unsupported event %T", event)
+	}
+}
+
+func createTaskPayload(entity task.Task, actor string, at time.Time) outboxPayload {
+	record := createTaskRecord(entity)
+	return outboxPayload{
+		Task:      &record,
+		Tags:      entity.Tags(),
+		Checklist: createChecklistItemRecords(entity.Id(), entity.Checklist()),
+		Actor:     actor,
+		At:        at,
+	}
+}
+
+func decodeEvent(record outboxRecord) (eventbus.Event, error) {
+	var payload outboxPayload
+	err := json.Unmarshal(record.Payload, &payload)
+	if err != nil {
+		return nil, err
+	}
+
+	switch record.EventType {
+	case "TaskCreated":
+		entity, err := decodePayloadTask(payload)
+		if err != nil {
+			return nil, err
+		}
+		return task.TaskCreated{Task: entity, Actor: payload.Actor, At: payload.At}, nil
+	case "TaskUpdated":
+		entity, err := decodePayloadTask(payload)
+		if err != nil {
+			return nil, err
+		}
+		changes, err := decodeChanges(payload.Changes)
+		if err != nil {
+			return nil, err
+		}
+		return task.TaskUpdated{Task: entity, Changes: changes, Actor: payload.Actor, At: payload.At}, nil
+	case "TaskDeleted":
+		return task.TaskDeleted{
+			TaskID:    payload.TaskID,
+			Permanent: payload.Permanent,
+			Actor:     payload.Actor,
+			At:        payload.At,
+		}, nil
+	default:
+		return nil, fmt.Errorf("unsupported event type %q", record.EventType)
+	}
+}
+
+func decodePayloadTask(payload outboxPayload) (task.Task, error) {
+	if payload.Task == nil {
+		return task.Task{}, fmt.Errorf("missing task")
+	}
+	return createTask(*payload.Task, payload.Tags, createChecklist(payload.Checklist))
+}
+
+type OutboxHandler func(event eventbus.Event) error
+
+// OutboxRelay delivers events queued in the outbox to its subscribers, in the order they were queued for their key.
+// Delivery is at least once: an event is marked as delivered only after all subscribers handle it, so a subscriber
+// may get it again when another one fails, or when the process stops in between. A failed event is retried with
+// backoff, holding back the events queued after it for the same key, while events of other keys go on.
+// After outboxMaxAttempts, the event is marked as failed and left in the outbox, letting the rest of its key go.
+// Only a single relay is expected to run per database.
+type OutboxRelay struct {
+	db       *sqlx.DB
+	handlers []OutboxHandler
+}
+
+func NewOutboxRelay(db *sqlx.DB) *OutboxRelay {
+	return &OutboxRelay{db: db}
+}
+
+// Subscribe is expected to be called before the relay starts.
+func (r *OutboxRelay) Subscribe(handler OutboxHandler) {
+	r.handlers = append(r.handlers, handler)
+}
+
+// Relay delivers the oldest pending event of each key, when it is due, and returns how many were delivered.
+// Events failing to be delivered do not stop the others. The first failure is returned after all of them are tried.
+func (r *OutboxRelay) Relay() (int, error) {
+	var records []outboxRecord
+	err := r.db.Select(
+		&records,
+		r.db.Rebind(`SELECT * FROM outbox WHERE id IN (
+			SELECT MIN(id) FROM outbox WHERE delivered_at IS NULL AND failed_at IS NULL GROUP BY event_key
+		) AND (next_attempt_at IS NULL OR next_attempt_at <= ?) ORDER BY id LIMIT ?;`),
+		time.Now(),
+		outboxBatchSize,
+	)
+	if err != nil {
+		return 0, err
+	}
+
+	delivered := 0
+	var firstFailure error
+	for _, record := range records {
+		now := time.Now()
+		err = r.deliver(record)
+		if err != nil {
+			failure := fmt.Errorf("event %d: %w", record.Id, err)
+			err = r.scheduleRetry(record, now, failure)
+			if err != nil {
+				return delivered, fmt.Errorf("%w, and scheduling retry failed: %s", failure, err)
+			}
+			if firstFailure == nil {
+				firstFailure = failure
+			}
+			continue
+		}
+
+		_, err = r.db.Exec(r.db.Rebind("UPDATE outbox SET delivered_at=? WHERE id=?;"), now, record.Id)
+		if err != nil {
+			return delivered, err
+		}
+		delivered++
+	}
+
+	return delivered, firstFailure
+}
+
+// Prune removes events delivered before the given time and returns how many were removed.
+// Failed events are kept, so that they can be looked into.
+func (r *OutboxRelay) Prune(deliveredBefore time.Time) (int, error) {
+	result, err := r.db.Exec(r.db.Rebind("DELETE FROM outbox WHERE delivered_at < ?;"), deliveredBefore)
+	if err != nil {
+		return 0, err
+	}
+	affected, err := result.RowsAffected()
+	return int(affected), err
+}
+
+func (r *OutboxRelay) deliver(record outboxRecord) (err error) {
+	event, err := decodeEvent(record)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if recovered := recover(); recovered != nil {
+			err = fmt.Errorf("subscriber panicked: %v", recovered)
+		}
+	}()
+	for _, handler := range r.handlers {
+		err = handler(event)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// scheduleRetry counts the failed attempt, marking the event as failed once it reaches outboxMaxAttempts.
+func (r *OutboxRelay) scheduleRetry(record outboxRecord, now time.Time, failure error) error {
+	attempts := record.Attempts + 1
+	var failedAt *time.Time
+	if attempts >= outboxMaxAttempts {
+		failedAt = &now
+	}
+	_, err := r.db.Exec(
+		r.db.Rebind("UPDATE outbox SET attempts=?, next_attempt_at=?, last_error=?, failed_at=? WHERE id=?;"),
+		attempts,
+		now.Add(outboxBackoff(attempts)),
+		failure.Error(),
+		failedAt,
+		record.Id,
+	)
+	return err
+}
+
+func outboxBackoff(attempts int) time.Duration {
+	backoff := outboxMinBackoff
+	for i := 1; i < attempts && backoff < outboxMaxBackoff; i++ {
+		backoff *= 2
+	}
+	if backoff > outboxMaxBackoff {
+		return outboxMaxBackoff
+	}
+	return backoff
+}
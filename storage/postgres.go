@@ -0,0 +1,25 @@
+package storage
+
+import (
+	"fmt"
+	"net/url"
+)
+
+// postgresDriverName is registered by lib/pq, which sqlx knows to use $n placeholders with.
+const postgresDriverName = "postgres"
+
+// postgresDSN sets the session timezone to UTC unless the DSN sets it, so that times are read in UTC,
+// like the MySQL driver does by default, rather than in the timezone configured on the server.
+func postgresDSN(dsn string) (string, error) {
+	parsed, err := url.Parse(dsn)
+	if err != nil {
+		return "", fmt.Errorf("postgres DSN: %w", err)
+	}
+	params := parsed.Query()
+	if !params.Has("timezone") {
+		params.Set("timezone", "UTC")
+	}
+	parsed.RawQuery = params.Encode()
+
+	return parsed.String(), nil
+}
@@ -0,0 +1,268 @@
+package storage
+
+import (
+	"database/sql"
+	"demo-app-go/task"
+	"errors"
+	"github.com/jmoiron/sqlx"
+	"time"
+)
+
+type ProjectRepository struct {
+	db *sqlx.DB
+}
+
+func NewProjectRepository(db *sqlx.DB) *ProjectRepository {
+	return &ProjectRepository{db: db}
+}
+
+type projectRecord struct {
+	Id          task.ProjectID `db:"id"`
+	Name        string         `db:"name"`
+	Description string         `db:"description"`
+	CreatedAt   time.Time      `db:"created_at"`
+	UpdatedAt   *time.Time     `db:"updated_at"`
+}
+
+type columnRecord struct {
+	Id        task.ColumnID  `db:"id"`
+	ProjectId task.ProjectID `db:"project_id"`
+	Name      string         `db:"name"`
+	WipLimit  int            `db:"wip_limit"`
+	Position  int            `db:"position"`
+}
+
+func (r *ProjectRepository) List() ([]task.Project, error) {
+	var records []projectRecord
+	err := r.db.Select(&records, "SELECT * FROM project ORDER BY id;")
+	if err != nil {
+		return nil, err
+	}
+	var columns []columnRecord
+	err = r.db.Select(&columns, "SELECT * FROM project_column ORDER BY project_id, position;")
+	if err != nil {
+		return nil, err
+	}
+
+	byProject := map[task.ProjectID][]columnRecord{}
+	for _, column := range columns {
+		byProject[column.ProjectId] = append(byProject[column.ProjectId], column)
+	}
+	result := make([]task.Project, len(records))
+	for i, record := range records {
+		result[i] = createProject(record, byProject[record.Id])
+	}
+
+	return result, nil
+}
+
+func (r *ProjectRepository) GetByID(id task.ProjectID) (task.Project, error) {
+	return getProject(r.db, id)
+}
+
+func getProject(q sqlx.Ext, id task.ProjectID) (task.Project, error) {
+	var record projectRecord
+	err := sqlx.Get(q, &record, q.Rebind("SELECT * FROM project WHERE id=?;"), id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return task.Project{}, ErrResourceNotFound
+	}
+	if err != nil {
+		return task.Project{}, err
+	}
+	var columns []columnRecord
+	err = sqlx.Select(
+		q,
+		&columns,
+		q.Rebind("SELECT * FROM project_column WHERE project_id=? ORDER BY position;"),
+		id,
+	)
+	if err != nil {
+		return task.Project{}, err
+	}
+
+	return createProject(record, columns), nil
+}
+
+func (r *ProjectRepository) Add(addProject task.AddProjectCommand) (task.Project, error) {
+	tx, err := r.db.Beginx()
+	if err != nil {
+		return task.Project{}, err
+	}
+	defer rollback(tx)
+
+	rows, err := tx.NamedQuery(
+		`INSERT INTO project (name, description, created_at) VALUES (:name, :description, :createdAt) RETURNING id;`,
+		map[string]any{
+			"name":        addProject.Name(),
+			"description": addProject.Description(),
+			"createdAt":   addProject.CreatedAt(),
+		},
+	)
+	if err != nil {
+		return task.Project{}, err
+	}
+	defer rows.Close()
+	var id task.ProjectID
+	if rows.Next() == false {
+		return task.Project{}, noRowReturned(rows)
+	}
+	err = rows.Scan(&id)
+	if err != nil {
+		return task.Project{}, err
+	}
+	err = rows.Close()
+	if err != nil {
+		return task.Project{}, err
+	}
+
+	err = saveColumns(tx, id, addProject.Columns())
+	if err != nil {
+		return task.Project{}, err
+	}
+	project, err := getProject(tx, id)
+	if err != nil {
+		return task.Project{}, err
+	}
+
+	return project, tx.Commit()
+}
+
+func (r *ProjectRepository) Save(project task.Project) (task.Project, error) {
+	tx, err := r.db.Beginx()
+	if err != nil {
+		return task.Project{}, err
+	}
+	defer rollback(tx)
+
+	result, err := tx.Exec(
+		tx.Rebind("UPDATE project SET name=?, description=?, updated_at=? WHERE id=?;"),
+		project.Name(),
+		project.Description(),
+		project.UpdatedAt(),
+		project.Id(),
+	)
+	if err != nil {
+		return task.Project{}, err
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return task.Project{}, err
+	}
+	if affected == 0 {
+		return task.Project{}, ErrResourceNotFound
+	}
+
+	err = saveColumns(tx, project.Id(), project.Columns())
+	if err != nil {
+		return task.Project{}, err
+	}
+	saved, err := getProject(tx, project.Id())
+	if err != nil {
+		return task.Project{}, err
+	}
+
+	return saved, tx.Commit()
+}
+
+// saveColumns makes the project have exactly the given columns, in their order. Columns with zero id are inserted.
+// Removing a column which tasks still refer to fails on the foreign key, with task.ErrHasTasks.
+func saveColumns(tx *sqlx.Tx, projectID task.ProjectID, columns []task.Column) error {
+	kept := []task.ColumnID{}
+	for _, column := range columns {
+		if column.Id() != 0 {
+			kept = append(kept, column.Id())
+		}
+	}
+	query := "DELETE FROM project_column WHERE project_id=?;"
+	args := []any{projectID}
+	if len(kept) > 0 {
+		var err error
+		query, args, err = sqlx.In("DELETE FROM project_column WHERE project_id=? AND id NOT IN (?);", projectID, kept)
+		if err != nil {
+			return err
+		}
+	}
+	_, err := tx.Exec(tx.Rebind(query), args...)
+	if isForeignKeyViolation(err) {
+		return task.ErrHasTasks
+	}
+	if err != nil {
+		return err
+	}
+
+	for position, column := range columns {
+		if column.Id() == 0 {
+			_, err = tx.Exec(
+				tx.Rebind("INSERT INTO project_column (project_id, name, wip_limit, position) VALUES (?, ?, ?, ?);"),
+				projectID,
+				column.Name(),
+				column.Limit(),
+				position,
+			)
+		} else {
+			_, err = tx.Exec(
+				tx.Rebind("UPDATE project_column SET name=?, wip_limit=?, position=? WHERE id=? AND project_id=?;"),
+				column.Name(),
+				column.Limit(),
+				position,
+				column.Id(),
+				projectID,
+			)
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Delete removes the project along with its columns. Tasks are expected to be removed from it first,
+// otherwise it fails with task.ErrHasTasks.
+func (r *ProjectRepository) Delete(id task.ProjectID) error {
+	tx, err := r.db.Beginx()
+	if err != nil {
+		return err
+	}
+	defer rollback(tx)
+
+	_, err = tx.Exec(tx.Rebind("DELETE FROM project_column WHERE project_id=?;"), id)
+	if isForeignKeyViolation(err) {
+		return task.ErrHasTasks
+	}
+	if err != nil {
+		return err
+	}
+	result, err := tx.Exec(tx.Rebind("DELETE FROM project WHERE id=?;"), id)
+	if isForeignKeyViolation(err) {
+		return task.ErrHasTasks
+	}
+	if err != nil {
+		return err
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return ErrResourceNotFound
+	}
+
+	return tx.Commit()
+}
+
+func createProject(record projectRecord, columns []columnRecord) task.Project {
+	result := make([]task.Column, len(columns))
+	for i, column := range columns {
+		result[i] = task.NewColumn(column.Id, column.Name, column.WipLimit)
+	}
+
+	return task.NewProject(task.ProjectSnapshot{
+		Id:          record.Id,
+		Name:        record.Name,
+		Description: record.Description,
+		Columns:     result,
+		CreatedAt:   record.CreatedAt,
+		UpdatedAt:   record.UpdatedAt,
+	})
+}
@@ -0,0 +1,219 @@
+package storage
+
+import (
+	"database/sql"
+	"demo-app-go/task"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/jmoiron/sqlx"
+	"time"
+)
+
+type RevisionRepository struct {
+	db *sqlx.DB
+}
+
+func NewRevisionRepository(db *sqlx.DB) *RevisionRepository {
+	return &RevisionRepository{db: db}
+}
+
+// revisionRecord keeps state and changes as JSON, as revisions are never queried by their content.
+type revisionRecord struct {
+	TaskId    task.ID      `db:"task_id"`
+	Number    task.Version `db:"number"`
+	Actor     string       `db:"actor"`
+	CreatedAt time.Time    `db:"created_at"`
+	State     []byte       `db:"state"`
+	Changes   []byte       `db:"changes"`
+}
+
+type revisionStateRecord struct {
+	Title       string          `json:"title"`
+	Description string          `json:"description"`
+	Status      string          `json:"status"`
+	DueAt       *string         `json:"dueAt"`
+	DueTimezone *string         `json:"dueTimezone"`
+	ParentId    *task.ID        `json:"parentId"`
+	ProjectId   *task.ProjectID `json:"projectId"`
+	ColumnId    *task.ColumnID  `json:"columnId"`
+	Tags        []string        `json:"tags"`
+	Recurrence  *string         `json:"recurrence"`
+	Checklist   []string        `json:"checklist"`
+	Deleted     bool            `json:"deleted"`
+}
+
+// fieldChangeRecord keeps values as they were encoded, so they are returned as raw JSON once loaded.
+type fieldChangeRecord struct {
+	Field  string          `json:"field"`
+	Before json.RawMessage `json:"before"`
+	After  json.RawMessage `json:"after"`
+}
+
+func (r *RevisionRepository) List(taskID task.ID) ([]task.Revision, error) {
+	var records []revisionRecord
+	err := r.db.Select(&records, r.db.Rebind("SELECT * FROM task_revision WHERE task_id=? ORDER BY number;"), taskID)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]task.Revision, len(records))
+	for i, record := range records {
+		result[i], err = createRevision(record)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return result, nil
+}
+
+func (r *RevisionRepository) Get(taskID task.ID, number task.Version) (task.Revision, error) {
+	var record revisionRecord
+	err := r.db.Get(&record, r.db.Rebind("SELECT * FROM task_revision WHERE task_id=? AND number=?;"), taskID, number)
+	if errors.Is(err, sql.ErrNoRows) {
+		return task.Revision{}, ErrResourceNotFound
+	}
+	if err != nil {
+		return task.Revision{}, err
+	}
+
+	return createRevision(record)
+}
+
+// insertRevision is called by TaskRepository, so the revision is stored in the same transaction as the task.
+func insertRevision(tx *sqlx.Tx, revision task.Revision) error {
+	state, err := json.Marshal(createRevisionStateRecord(revision.State()))
+	if err != nil {
+		return err
+	}
+	changes, err := encodeChanges(revision.Changes())
+	if err != nil {
+		return err
+	}
+
+	// Documents are passed as text, since byte slices are sent to Postgres as bytea, which would not read back as JSON.
+	_, err = tx.NamedExec(
+		`INSERT INTO task_revision (task_id, number, actor, created_at, state, changes)
+		VALUES (:taskId, :number, :actor, :createdAt, :state, :changes);`,
+		map[string]any{
+			"taskId":    revision.TaskID(),
+			"number":    revision.Number(),
+			"actor":     revision.Actor(),
+			"createdAt": revision.CreatedAt(),
+			"state":     string(state),
+			"changes":   string(changes),
+		},
+	)
+	return err
+}
+
+func createRevisionStateRecord(state task.RevisionState) revisionStateRecord {
+	var dueAt *string
+	if state.DueAt != nil {
+		value := state.DueAt.Format(time.RFC3339Nano)
+		dueAt = &value
+	}
+
+	return revisionStateRecord{
+		Title:       state.Title,
+		Description: state.Description,
+		Status:      string(state.Status),
+		DueAt:       dueAt,
+		DueTimezone: timezoneName(state.DueAt),
+		ParentId:    state.ParentID,
+		ProjectId:   state.ProjectID,
+		ColumnId:    state.ColumnID,
+		Tags:        state.Tags,
+		Recurrence:  state.Recurrence,
+		Checklist:   state.Checklist,
+		Deleted:     state.Deleted,
+	}
+}
+
+func createRevision(record revisionRecord) (task.Revision, error) {
+	var stateRecord revisionStateRecord
+	err := json.Unmarshal(record.State, &stateRecord)
+	if err != nil {
+		return task.Revision{}, fmt.Errorf("revision %d of task %d: %w", record.Number, record.TaskId, err)
+	}
+	state, err := createRevisionState(stateRecord)
+	if err != nil {
+		return task.Revision{}, fmt.Errorf("revision %d of task %d: %w", record.Number, record.TaskId, err)
+	}
+
+	changes, err := decodeChanges(record.Changes)
+	if err != nil {
+		return task.Revision{}, fmt.Errorf("revision %d of task %d: %w", record.Number, record.TaskId, err)
+	}
+
+	return task.NewRevision(task.RevisionSnapshot{
+		TaskID:    record.TaskId,
+		Number:    record.Number,
+		Actor:     record.Actor,
+		CreatedAt: record.CreatedAt,
+		State:     state,
+		Changes:   changes,
+	}), nil
+}
+
+func encodeChanges(changes []task.FieldChange) ([]byte, error) {
+	records := make([]fieldChangeRecord, len(changes))
+	for i, change := range changes {
+		before, err := json.Marshal(change.Before())
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", change.Field(), err)
+		}
+		after, err := json.Marshal(change.After())
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", change.Field(), err)
+		}
+		records[i] = fieldChangeRecord{Field: change.Field(), Before: before, After: after}
+	}
+	return json.Marshal(records)
+}
+
+func decodeChanges(data []byte) ([]task.FieldChange, error) {
+	var records []fieldChangeRecord
+	err := json.Unmarshal(data, &records)
+	if err != nil {
+		return nil, err
+	}
+	changes := make([]task.FieldChange, len(records))
+	for i, record := range records {
+		changes[i] = task.NewFieldChange(record.Field, record.Before, record.After)
+	}
+	return changes, nil
+}
+
+func createRevisionState(record revisionStateRecord) (task.RevisionState, error) {
+	status, err := task.ParseStatus(record.Status)
+	if err != nil {
+		return task.RevisionState{}, err
+	}
+	var dueAt *time.Time
+	if record.DueAt != nil {
+		parsed, err := time.Parse(time.RFC3339Nano, *record.DueAt)
+		if err != nil {
+			return task.RevisionState{}, err
+		}
+		dueAt, err = inTimezone(&parsed, record.DueTimezone)
+		if err != nil {
+			return task.RevisionState{}, err
+		}
+	}
+
+	return task.RevisionState{
+		Title:       record.Title,
+		Description: record.Description,
+		Status:      status,
+		DueAt:       dueAt,
+		ParentID:    record.ParentId,
+		ProjectID:   record.ProjectId,
+		ColumnID:    record.ColumnId,
+		Tags:        record.Tags,
+		Recurrence:  record.Recurrence,
+		Checklist:   record.Checklist,
+		Deleted:     record.Deleted,
+	}, nil
+}
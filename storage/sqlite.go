@@ -0,0 +1,85 @@
+package storage
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+	"github.com/jmoiron/sqlx"
+	"github.com/mattn/go-sqlite3"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// sqliteDriverName is the SQLite driver which stores times in UTC, see sqliteConn.
+const sqliteDriverName = "sqlite"
+
+// sqliteDefaults are connection parameters of the driver, set unless the DSN sets them.
+// Foreign keys are off in SQLite by default. Transactions take the write lock right away, so that concurrent ones wait
+// for each other instead of failing to upgrade their read lock.
+var sqliteDefaults = map[string]string{
+	"_foreign_keys": "on",
+	"_busy_timeout": "5000",
+	"_journal_mode": "WAL",
+	"_txlock":       "immediate",
+}
+
+func init() {
+	sql.Register(sqliteDriverName, sqliteDriver{})
+	sqlx.BindDriver(sqliteDriverName, sqlx.QUESTION)
+}
+
+// sqliteDSN turns the path of the database file, optionally followed by query parameters, into DSN of the driver.
+// Times are always read in UTC, like the MySQL driver does by default.
+// The directory of the file is created when missing, the file itself is created by the driver.
+func sqliteDSN(path string) (string, error) {
+	path, query, _ := strings.Cut(path, "?")
+	params, err := url.ParseQuery(query)
+	if err != nil {
+		return "", fmt.Errorf("sqlite parameters: %w", err)
+	}
+	err = os.MkdirAll(filepath.Dir(path), 0o750)
+	if err != nil {
+		return "", err
+	}
+	for name, value := range sqliteDefaults {
+		if !params.Has(name) {
+			params.Set(name, value)
+		}
+	}
+	params.Set("_loc", "UTC")
+
+	return "file:" + path + "?" + params.Encode(), nil
+}
+
+type sqliteDriver struct {
+	sqlite3.SQLiteDriver
+}
+
+func (d sqliteDriver) Open(dsn string) (driver.Conn, error) {
+	conn, err := d.SQLiteDriver.Open(dsn)
+	if err != nil {
+		return nil, err
+	}
+	return sqliteConn{conn.(*sqlite3.SQLiteConn)}, nil
+}
+
+// sqliteConn converts times to UTC before they are stored. SQLite keeps times as text, which compares and sorts
+// correctly only when all of them are in the same timezone, while the driver would store them in their own.
+type sqliteConn struct {
+	*sqlite3.SQLiteConn
+}
+
+func (c sqliteConn) CheckNamedValue(value *driver.NamedValue) error {
+	converted, err := driver.DefaultParameterConverter.ConvertValue(value.Value)
+	if err != nil {
+		return err
+	}
+	if t, ok := converted.(time.Time); ok {
+		converted = t.UTC()
+	}
+	value.Value = converted
+	return nil
+}
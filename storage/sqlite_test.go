@@ -0,0 +1,190 @@
+package storage_test
+
+import (
+	"demo-app-go/eventbus"
+	"demo-app-go/storage"
+	"demo-app-go/task"
+	"errors"
+	"github.com/jmoiron/sqlx"
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+func TestSQLite(t *testing.T) {
+	setup := func(t *testing.T) (*sqlx.DB, *storage.Migrator) {
+		db, err := storage.Open("sqlite://" + t.TempDir() + "/test.db")
+		require.NoError(t, err, "open database")
+		t.Cleanup(func() { db.Close() })
+		migrator, err := storage.NewMigrator(db)
+		require.NoError(t, err, "create migrator")
+		_, err = migrator.Up()
+		require.NoError(t, err, "migrate")
+		return db, migrator
+	}
+	newService := func(db *sqlx.DB) *task.Service {
+		return task.NewService(
+			storage.NewTaskRepository(db),
+			storage.NewRevisionRepository(db),
+			storage.NewProjectRepository(db),
+			task.DeleteOrphan,
+		)
+	}
+	addTask := func(t *testing.T, service *task.Service, dueAt *time.Time, parentID *task.ID, tags ...string) task.Task {
+		command, err := task.NewAddTaskCommand("Write docs", "", dueAt, parentID, tags, nil, nil, nil)
+		require.NoError(t, err, "create command")
+		entity, err := service.AddTask(command, "alice")
+		require.NoError(t, err, "add task")
+		return entity
+	}
+
+	t.Run("reverts and applies migrations again, keeping tasks", func(t *testing.T) {
+		t.Parallel()
+		db, migrator := setup(t)
+		service := newService(db)
+		parent := addTask(t, service, nil, nil, "docs")
+		parentID := parent.Id()
+		subtask := addTask(t, service, nil, &parentID)
+
+		reverted, err := migrator.To(3)
+		require.NoError(t, err, "revert projects")
+		require.Len(t, reverted, 2)
+		_, err = migrator.Up()
+		require.NoError(t, err, "migrate again")
+
+		loaded, err := service.GetTask(subtask.Id())
+		require.NoError(t, err, "unexpected error")
+		require.Equal(t, &parentID, loaded.ParentID())
+
+		_, err = migrator.To(0)
+		require.NoError(t, err, "revert all")
+		statuses, err := migrator.Status()
+		require.NoError(t, err, "unexpected error")
+		for _, status := range statuses {
+			require.Nil(t, status.AppliedAt)
+		}
+	})
+	t.Run("keeps timezone of due date and compares times in UTC", func(t *testing.T) {
+		t.Parallel()
+		db, _ := setup(t)
+		service := newService(db)
+		prague, err := time.LoadLocation("Europe/Prague")
+		require.NoError(t, err, "load location")
+		dueAt := time.Now().Add(time.Hour).In(prague)
+
+		added := addTask(t, service, &dueAt, nil)
+		loaded, err := service.GetTask(added.Id())
+		require.NoError(t, err, "unexpected error")
+		require.True(t, dueAt.Equal(*loaded.DueAt()))
+		require.Equal(t, "Europe/Prague", loaded.DueAt().Location().String())
+
+		// Stored in the local time, due date would be compared as text with an offset later than UTC.
+		dueBefore := dueAt.Add(time.Minute).UTC()
+		tasks, err := service.ListTasks(task.ListFilter{DueBefore: &dueBefore})
+		require.NoError(t, err, "unexpected error")
+		require.Len(t, tasks, 1)
+	})
+	t.Run("saves tasks only at their version", func(t *testing.T) {
+		t.Parallel()
+		db, _ := setup(t)
+		service := newService(db)
+		added := addTask(t, service, nil, nil)
+
+		_, err := service.TransitionTask(added.Id(), task.StatusInProgress, "alice")
+		require.NoError(t, err, "unexpected error")
+		err = storage.NewTaskRepository(db).Save(added, task.ChangeRecord{})
+		require.ErrorIs(t, err, task.ErrVersionMismatch)
+		err = storage.NewTaskRepository(db).Delete(added.Id()+1, task.ChangeRecord{})
+		require.ErrorIs(t, err, task.ErrNotFound)
+	})
+	t.Run("merges tags", func(t *testing.T) {
+		t.Parallel()
+		db, _ := setup(t)
+		service := newService(db)
+		addTask(t, service, nil, nil, "docs", "writing")
+		addTask(t, service, nil, nil, "docs")
+
+		require.NoError(t, service.MergeTags("docs", "writing"), "merge")
+		tags, err := service.ListTags()
+		require.NoError(t, err, "unexpected error")
+		require.Equal(t, []task.TagUsage{task.NewTagUsage("writing", 2)}, tags)
+	})
+	t.Run("reports violated foreign keys as domain errors", func(t *testing.T) {
+		t.Parallel()
+		db, _ := setup(t)
+		service := newService(db)
+		addProject, err := task.NewAddProjectCommand("Docs", "", []task.Column{task.NewColumn(0, "Todo", 0)})
+		require.NoError(t, err, "create command")
+		project, err := storage.NewProjectRepository(db).Add(addProject)
+		require.NoError(t, err, "add project")
+		projectID := project.Id()
+		columnID := project.Columns()[0].Id()
+		command, err := task.NewAddTaskCommand("Write docs", "", nil, nil, nil, nil, &projectID, &columnID)
+		require.NoError(t, err, "create command")
+		entity, err := service.AddTask(command, "alice")
+		require.NoError(t, err, "add task")
+
+		err = storage.NewProjectRepository(db).Delete(projectID)
+		require.ErrorIs(t, err, task.ErrHasTasks)
+		addComment, err := task.NewAddCommentCommand(entity.Id()+1, "alice", "Started")
+		require.NoError(t, err, "create command")
+		_, err = storage.NewCommentRepository(db).Add(addComment)
+		require.ErrorIs(t, err, task.ErrNotFound)
+	})
+	t.Run("relays events in order of their key, giving up on failing ones", func(t *testing.T) {
+		t.Parallel()
+		db, _ := setup(t)
+		service := newService(db)
+		failing := addTask(t, service, nil, nil)
+		other := addTask(t, service, nil, nil)
+		_, err := service.TransitionTask(failing.Id(), task.StatusInProgress, "alice")
+		require.NoError(t, err, "unexpected error")
+		relay := storage.NewOutboxRelay(db)
+		var relayed []eventbus.Event
+		relay.Subscribe(func(event eventbus.Event) error {
+			if created, ok := event.(task.TaskCreated); ok && created.Task.Id() == failing.Id() {
+				return errors.New("unavailable")
+			}
+			relayed = append(relayed, event)
+			return nil
+		})
+
+		count, err := relay.Relay()
+		require.ErrorContains(t, err, "unavailable")
+		require.Equal(t, 1, count, "events of other tasks are expected to go on")
+		count, err = relay.Relay()
+		require.NoError(t, err, "failed event is expected to wait for its retry")
+		require.Equal(t, 0, count)
+
+		_, err = db.Exec("UPDATE outbox SET attempts=1000, next_attempt_at=NULL WHERE attempts > 0;")
+		require.NoError(t, err, "use up attempts")
+		count, err = relay.Relay()
+		require.ErrorContains(t, err, "unavailable")
+		require.Equal(t, 0, count)
+		count, err = relay.Relay()
+		require.NoError(t, err, "events after the failed one are expected to go on")
+		require.Equal(t, 1, count)
+		require.Len(t, relayed, 2)
+		require.Equal(t, other.Id(), relayed[0].(task.TaskCreated).Task.Id())
+		require.Equal(t, failing.Id(), relayed[1].(task.TaskUpdated).Task.Id())
+
+		pruned, err := relay.Prune(time.Now().Add(time.Second))
+		require.NoError(t, err, "unexpected error")
+		require.Equal(t, 2, pruned)
+		var remaining int
+		require.NoError(t, db.Get(&remaining, "SELECT COUNT(*) FROM outbox WHERE failed_at IS NOT NULL;"))
+		require.Equal(t, 1, remaining, "failed event is expected to be kept")
+	})
+	t.Run("keeps single running timer per user", func(t *testing.T) {
+		t.Parallel()
+		db, _ := setup(t)
+		service := newService(db)
+		entity := addTask(t, service, nil, nil)
+		timeService := task.NewTimeService(storage.NewTimeEntryRepository(db), storage.NewTaskRepository(db))
+
+		_, err := timeService.StartTimer(entity.Id(), "alice", "")
+		require.NoError(t, err, "unexpected error")
+		_, err = timeService.StartTimer(entity.Id(), "alice", "")
+		require.ErrorIs(t, err, task.ErrTimerRunning)
+	})
+}
@@ -0,0 +1,348 @@
+// Package storagetest checks that repositories behave the way the task package relies on, whatever keeps the data.
+package storagetest
+
+import (
+	"demo-app-go/task"
+	"fmt"
+	"github.com/stretchr/testify/require"
+	"sync"
+	"testing"
+	"time"
+)
+
+// concurrentWriters is how many writers race each other in the concurrency tests.
+const concurrentWriters = 10
+
+// TaskRepository is the repository task.Service works with, so that any of its implementations can be checked.
+type TaskRepository interface {
+	List(filter task.ListFilter) ([]task.Task, error)
+	GetByID(id task.ID) (task.Task, error)
+	GetFromTrash(id task.ID) (task.Task, error)
+	Add(addTask task.AddTaskCommand, record func(task.Task) task.ChangeRecord) (task.Task, error)
+	AddTree(addTree task.AddTaskTreeCommand, record func(task.Task) task.ChangeRecord) (task.Tree, error)
+	Save(task task.Task, record task.ChangeRecord) error
+	SaveAll(changes []task.TaskChange) error
+	SaveAndAdd(
+		task task.Task,
+		record task.ChangeRecord,
+		addTask task.AddTaskCommand,
+		recordAdded func(task.Task) task.ChangeRecord,
+	) (task.Task, error)
+	Delete(id task.ID, record task.ChangeRecord) error
+	LastRank() (task.Rank, error)
+	SaveRanks(ranks map[task.ID]task.Rank) error
+	SaveWithRanks(task task.Task, record task.ChangeRecord, ranks map[task.ID]task.Rank) error
+	ListDependencies() ([]task.Dependency, error)
+	AddDependency(dependency task.Dependency) error
+	RemoveDependency(dependency task.Dependency) error
+	ListTags() ([]task.TagUsage, error)
+	RenameTag(from string, to string) error
+	MergeTags(from string, into string) error
+}
+
+// TestTaskRepository runs the conformance suite of task repositories. Subtests run one after another, each with
+// a repository returned by newRepository, which is expected to be empty.
+func TestTaskRepository(t *testing.T, newRepository func(t *testing.T) TaskRepository) {
+	t.Run("lists tasks by rank, then by id", func(t *testing.T) {
+		repository := newRepository(t)
+		first := addTask(t, repository, "First", nil)
+		second := addTask(t, repository, "Second", nil)
+		third := addTask(t, repository, "Third", nil)
+		trashed := addTask(t, repository, "Trashed", nil)
+		snapshot := trashed.Snapshot()
+		deletedAt := time.Now()
+		snapshot.DeletedAt = &deletedAt
+		require.NoError(t, repository.Save(task.NewTask(snapshot), task.ChangeRecord{}), "move to trash")
+
+		// Ranks are compared byte by byte, so digits go before letters regardless of case rules of the database.
+		err := repository.SaveRanks(map[task.ID]task.Rank{
+			first.Id():   "i",
+			second.Id():  "i",
+			third.Id():   "0z",
+			trashed.Id(): "z",
+		})
+		require.NoError(t, err, "save ranks")
+
+		tasks, err := repository.List(task.ListFilter{})
+		require.NoError(t, err, "unexpected error")
+		require.Equal(t, []task.ID{third.Id(), first.Id(), second.Id()}, taskIds(tasks))
+		require.Equal(t, task.Version(1), tasks[0].Version(), "ranks are expected to be saved without a new version")
+		tasks, err = repository.List(task.ListFilter{Deleted: true})
+		require.NoError(t, err, "unexpected error")
+		require.Equal(t, []task.ID{trashed.Id()}, taskIds(tasks))
+		rank, err := repository.LastRank()
+		require.NoError(t, err, "unexpected error")
+		require.Equal(t, task.Rank("z"), rank, "tasks in the trash are expected to count")
+	})
+	t.Run("reports missing tasks as not found", func(t *testing.T) {
+		repository := newRepository(t)
+		live := addTask(t, repository, "Live", nil)
+		missing := live.Snapshot()
+		missing.Id = live.Id() + 100
+
+		_, err := repository.GetByID(missing.Id)
+		require.ErrorIs(t, err, task.ErrNotFound)
+		_, err = repository.GetFromTrash(missing.Id)
+		require.ErrorIs(t, err, task.ErrNotFound)
+		_, err = repository.GetFromTrash(live.Id())
+		require.ErrorIs(t, err, task.ErrNotFound, "tasks out of the trash are expected to be missing there")
+		err = repository.Save(task.NewTask(missing), task.ChangeRecord{})
+		require.ErrorIs(t, err, task.ErrNotFound)
+		err = repository.Delete(missing.Id, task.ChangeRecord{})
+		require.ErrorIs(t, err, task.ErrNotFound)
+
+		require.NoError(t, repository.Delete(live.Id(), task.ChangeRecord{}), "delete")
+		_, err = repository.GetByID(live.Id())
+		require.ErrorIs(t, err, task.ErrNotFound)
+		err = repository.Delete(live.Id(), task.ChangeRecord{})
+		require.ErrorIs(t, err, task.ErrNotFound, "deleting twice is expected to fail")
+	})
+	t.Run("keeps timestamps and timezone of due date", func(t *testing.T) {
+		repository := newRepository(t)
+		prague, err := time.LoadLocation("Europe/Prague")
+		require.NoError(t, err, "load location")
+		// Databases keep microseconds at least, so the time is expected to come back as the same instant.
+		dueAt := time.Date(2030, time.March, 31, 1, 30, 15, 123456000, prague)
+		added := addTask(t, repository, "Due", &dueAt)
+
+		loaded, err := repository.GetByID(added.Id())
+		require.NoError(t, err, "unexpected error")
+		require.True(t, dueAt.Equal(*loaded.DueAt()), "due at %s", loaded.DueAt())
+		require.Equal(t, "Europe/Prague", loaded.DueAt().Location().String())
+		require.WithinDuration(t, added.CreatedAt(), loaded.CreatedAt(), time.Microsecond)
+
+		snapshot := loaded.Snapshot()
+		updatedAt := time.Date(2030, time.October, 27, 2, 30, 0, 654321000, time.UTC)
+		deletedAt := updatedAt.Add(time.Second)
+		snapshot.UpdatedAt = &updatedAt
+		snapshot.DeletedAt = &deletedAt
+		require.NoError(t, repository.Save(task.NewTask(snapshot), task.ChangeRecord{}), "save")
+
+		trashed, err := repository.GetFromTrash(added.Id())
+		require.NoError(t, err, "unexpected error")
+		require.True(t, updatedAt.Equal(*trashed.UpdatedAt()), "updated at %s", trashed.UpdatedAt())
+		require.True(t, deletedAt.Equal(*trashed.DeletedAt()), "deleted at %s", trashed.DeletedAt())
+		require.WithinDuration(t, added.CreatedAt(), trashed.CreatedAt(), time.Microsecond)
+
+		dueBefore := dueAt.Add(time.Second).UTC()
+		tasks, err := repository.List(task.ListFilter{Deleted: true, DueBefore: &dueBefore})
+		require.NoError(t, err, "unexpected error")
+		require.Equal(t, []task.ID{added.Id()}, taskIds(tasks))
+		dueBefore = dueAt.Add(-time.Second).UTC()
+		tasks, err = repository.List(task.ListFilter{Deleted: true, DueBefore: &dueBefore})
+		require.NoError(t, err, "unexpected error")
+		require.Empty(t, tasks)
+	})
+	t.Run("adds tasks concurrently", func(t *testing.T) {
+		repository := newRepository(t)
+
+		added := make(chan task.Task, concurrentWriters)
+		errs := make(chan error, concurrentWriters)
+		var wg sync.WaitGroup
+		for i := 0; i < concurrentWriters; i++ {
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+				command, err := task.NewAddTaskCommand(fmt.Sprintf("Task %d", i), "", nil, nil, []string{"b", "a"}, nil, nil, nil)
+				if err != nil {
+					errs <- err
+					return
+				}
+				entity, err := repository.Add(command, noRecord)
+				if err != nil {
+					errs <- err
+					return
+				}
+				added <- entity
+			}(i)
+		}
+		wg.Wait()
+		close(added)
+		close(errs)
+
+		for err := range errs {
+			require.NoError(t, err, "add")
+		}
+		ids := map[task.ID]bool{}
+		for entity := range added {
+			ids[entity.Id()] = true
+		}
+		require.Len(t, ids, concurrentWriters, "tasks are expected to have distinct ids")
+		tasks, err := repository.List(task.ListFilter{Tags: []string{"a", "b"}})
+		require.NoError(t, err, "unexpected error")
+		require.Len(t, tasks, concurrentWriters)
+		tags, err := repository.ListTags()
+		require.NoError(t, err, "unexpected error")
+		require.Equal(t, []task.TagUsage{
+			task.NewTagUsage("a", concurrentWriters),
+			task.NewTagUsage("b", concurrentWriters),
+		}, tags)
+	})
+	t.Run("saves a version only once when saved concurrently", func(t *testing.T) {
+		repository := newRepository(t)
+		added := addTask(t, repository, "Contended", nil)
+
+		errs := make(chan error, concurrentWriters)
+		var wg sync.WaitGroup
+		for i := 0; i < concurrentWriters; i++ {
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+				snapshot := added.Snapshot()
+				snapshot.Title = fmt.Sprintf("Saved by %d", i)
+				errs <- repository.Save(task.NewTask(snapshot), task.ChangeRecord{})
+			}(i)
+		}
+		wg.Wait()
+		close(errs)
+
+		saved := 0
+		for err := range errs {
+			if err == nil {
+				saved++
+				continue
+			}
+			require.ErrorIs(t, err, task.ErrVersionMismatch)
+		}
+		require.Equal(t, 1, saved)
+		loaded, err := repository.GetByID(added.Id())
+		require.NoError(t, err, "unexpected error")
+		require.Equal(t, added.Version()+1, loaded.Version())
+	})
+	t.Run("saves all tasks or none", func(t *testing.T) {
+		repository := newRepository(t)
+		first := addTask(t, repository, "First", nil)
+		second := addTask(t, repository, "Second", nil)
+		renamed := func(entity task.Task, title string) task.TaskChange {
+			snapshot := entity.Snapshot()
+			snapshot.Title = title
+			return task.TaskChange{Task: task.NewTask(snapshot)}
+		}
+
+		err := repository.SaveAll([]task.TaskChange{renamed(first, "First again"), renamed(second, "Second again")})
+		require.NoError(t, err, "unexpected error")
+		err = repository.SaveAll([]task.TaskChange{renamed(first, "Not saved"), renamed(second, "Not saved")})
+		require.ErrorIs(t, err, task.ErrVersionMismatch)
+		loaded, err := repository.GetByID(first.Id())
+		require.NoError(t, err, "unexpected error")
+		err = repository.SaveAll([]task.TaskChange{renamed(loaded, "Not saved"), renamed(second, "Not saved")})
+		require.ErrorIs(t, err, task.ErrVersionMismatch)
+
+		tasks, err := repository.List(task.ListFilter{})
+		require.NoError(t, err, "unexpected error")
+		require.Equal(t, "First again", tasks[0].Title(), "nothing is expected to be saved when any save fails")
+		require.Equal(t, "Second again", tasks[1].Title())
+	})
+	t.Run("saves a task and adds another at once", func(t *testing.T) {
+		repository := newRepository(t)
+		saved := addTask(t, repository, "Saved", nil)
+		snapshot := saved.Snapshot()
+		snapshot.Title = "Saved again"
+		command, err := task.NewAddTaskCommand("Added", "", nil, nil, nil, nil, nil, nil)
+		require.NoError(t, err, "create command")
+
+		added, err := repository.SaveAndAdd(task.NewTask(snapshot), task.ChangeRecord{}, command, noRecord)
+		require.NoError(t, err, "unexpected error")
+		require.Equal(t, "Added", added.Title())
+		tasks, err := repository.List(task.ListFilter{})
+		require.NoError(t, err, "unexpected error")
+		require.Equal(t, []task.ID{saved.Id(), added.Id()}, taskIds(tasks))
+		require.Equal(t, "Saved again", tasks[0].Title())
+
+		_, err = repository.SaveAndAdd(task.NewTask(snapshot), task.ChangeRecord{}, command, noRecord)
+		require.ErrorIs(t, err, task.ErrVersionMismatch)
+		tasks, err = repository.List(task.ListFilter{})
+		require.NoError(t, err, "unexpected error")
+		require.Len(t, tasks, 2, "nothing is expected to be added when saving fails")
+	})
+	t.Run("saves a task and ranks at once", func(t *testing.T) {
+		repository := newRepository(t)
+		saved := addTask(t, repository, "Saved", nil)
+		other := addTask(t, repository, "Other", nil)
+		snapshot := saved.Snapshot()
+		snapshot.Title = "Saved again"
+
+		err := repository.SaveWithRanks(task.NewTask(snapshot), task.ChangeRecord{}, map[task.ID]task.Rank{
+			saved.Id(): "z",
+			other.Id(): "a",
+		})
+		require.NoError(t, err, "unexpected error")
+		tasks, err := repository.List(task.ListFilter{})
+		require.NoError(t, err, "unexpected error")
+		require.Equal(t, []task.ID{other.Id(), saved.Id()}, taskIds(tasks))
+		require.Equal(t, "Saved again", tasks[1].Title())
+		require.Equal(t, task.Version(1), tasks[0].Version(), "ranks are expected to be saved without a new version")
+
+		err = repository.SaveWithRanks(task.NewTask(snapshot), task.ChangeRecord{}, map[task.ID]task.Rank{
+			other.Id(): "zz",
+		})
+		require.ErrorIs(t, err, task.ErrVersionMismatch)
+		snapshot.Version++
+		err = repository.SaveWithRanks(task.NewTask(snapshot), task.ChangeRecord{}, map[task.ID]task.Rank{
+			other.Id():       "zz",
+			other.Id() + 100: "zz",
+		})
+		require.ErrorIs(t, err, task.ErrNotFound)
+		tasks, err = repository.List(task.ListFilter{})
+		require.NoError(t, err, "unexpected error")
+		require.Equal(t, []task.ID{other.Id(), saved.Id()}, taskIds(tasks), "nothing is expected to change on failure")
+		require.Equal(t, saved.Version()+1, tasks[1].Version(), "nothing is expected to change on failure")
+	})
+	t.Run("keeps unicode content", func(t *testing.T) {
+		repository := newRepository(t)
+		title := "Přeložit dokumentaci 日本語 🚀"
+		description := "Zkontrolovat diakritiku: ěščřžýáíé, ligatury ﬁ, kombinované znaky é a emoji 👩‍💻"
+		command, err := task.NewAddTaskCommand(title, description, nil, nil, []string{"čeština", "日本語", "🚀"}, nil, nil, nil)
+		require.NoError(t, err, "create command")
+		added, err := repository.Add(command, noRecord)
+		require.NoError(t, err, "add")
+		snapshot := added.Snapshot()
+		snapshot.Checklist = []task.ChecklistItem{
+			task.NewChecklistItem(1, "Přeložit 📄", true),
+			task.NewChecklistItem(2, "Zkontrolovat 漢字", false),
+		}
+		require.NoError(t, repository.Save(task.NewTask(snapshot), task.ChangeRecord{}), "save")
+
+		loaded, err := repository.GetByID(added.Id())
+		require.NoError(t, err, "unexpected error")
+		require.Equal(t, title, loaded.Title())
+		require.Equal(t, description, loaded.Description())
+		require.Equal(t, []string{"čeština", "日本語", "🚀"}, loaded.Tags())
+		require.Len(t, loaded.Checklist(), 2)
+		require.Equal(t, "Přeložit 📄", loaded.Checklist()[0].Text())
+		require.Equal(t, "Zkontrolovat 漢字", loaded.Checklist()[1].Text())
+
+		tasks, err := repository.List(task.ListFilter{Tags: []string{"日本語"}})
+		require.NoError(t, err, "unexpected error")
+		require.Equal(t, []task.ID{added.Id()}, taskIds(tasks))
+		require.NoError(t, repository.RenameTag("🚀", "🛰"), "rename")
+		tags, err := repository.ListTags()
+		require.NoError(t, err, "unexpected error")
+		require.Equal(t, []task.TagUsage{
+			task.NewTagUsage("čeština", 1),
+			task.NewTagUsage("日本語", 1),
+			task.NewTagUsage("🛰", 1),
+		}, tags)
+	})
+}
+
+func addTask(t *testing.T, repository TaskRepository, title string, dueAt *time.Time) task.Task {
+	t.Helper()
+	command, err := task.NewAddTaskCommand(title, "", dueAt, nil, nil, nil, nil, nil)
+	require.NoError(t, err, "create command")
+	entity, err := repository.Add(command, noRecord)
+	require.NoError(t, err, "add task")
+	return entity
+}
+
+func noRecord(task.Task) task.ChangeRecord {
+	return task.ChangeRecord{}
+}
+
+func taskIds(tasks []task.Task) []task.ID {
+	result := make([]task.ID, len(tasks))
+	for i, entity := range tasks {
+		result[i] = entity.Id()
+	}
+	return result
+}
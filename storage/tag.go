@@ -0,0 +1,200 @@
+package storage
+
+import (
+	"database/sql"
+	"demo-app-go/task"
+	"errors"
+	"github.com/jmoiron/sqlx"
+)
+
+type tagUsageRecord struct {
+	Name  string `db:"name"`
+	Count int    `db:"count"`
+}
+
+// ListTags returns all tags ordered by name, including the ones no longer used by any task.
+// Tasks in the trash are not counted.
+func (r *TaskRepository) ListTags() ([]task.TagUsage, error) {
+	var records []tagUsageRecord
+	err := r.db.Select(
+		&records,
+		`SELECT tag.name, COUNT(task.id) AS count FROM tag
+		LEFT JOIN task_tag ON task_tag.tag_id = tag.id
+		LEFT JOIN task ON task.id = task_tag.task_id AND task.deleted_at IS NULL
+		GROUP BY tag.id, tag.name ORDER BY tag.name;`,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]task.TagUsage, len(records))
+	for i, record := range records {
+		result[i] = task.NewTagUsage(record.Name, record.Count)
+	}
+
+	return result, nil
+}
+
+func (r *TaskRepository) RenameTag(from string, to string) error {
+	tx, err := r.db.Beginx()
+	if err != nil {
+		return err
+	}
+	defer rollback(tx)
+
+	_, err = tagId(tx, to)
+	if err == nil {
+		return task.ErrTagExists
+	}
+	if !errors.Is(err, ErrResourceNotFound) {
+		return err
+	}
+	// The tag may still be created concurrently.
+	result, err := tx.Exec(tx.Rebind("UPDATE tag SET name=? WHERE name=?;"), to, from)
+	if isDuplicateEntry(err) {
+		return task.ErrTagExists
+	}
+	if err != nil {
+		return err
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return ErrResourceNotFound
+	}
+
+	return tx.Commit()
+}
+
+// MergeTags moves all tasks from one tag to another, which is created when missing. Then the former is removed.
+func (r *TaskRepository) MergeTags(from string, into string) error {
+	tx, err := r.db.Beginx()
+	if err != nil {
+		return err
+	}
+	defer rollback(tx)
+
+	fromId, err := tagId(tx, from)
+	if err != nil {
+		return err
+	}
+	intoId, err := ensureTag(tx, into)
+	if err != nil {
+		return err
+	}
+	_, err = tx.Exec(
+		tx.Rebind(dialectOf(tx).insertIgnore(
+			"INSERT INTO task_tag (task_id, tag_id) SELECT task_id, ? FROM task_tag WHERE tag_id=?;",
+		)),
+		intoId,
+		fromId,
+	)
+	if err != nil {
+		return err
+	}
+	_, err = tx.Exec(tx.Rebind("DELETE FROM task_tag WHERE tag_id=?;"), fromId)
+	if err != nil {
+		return err
+	}
+	_, err = tx.Exec(tx.Rebind("DELETE FROM tag WHERE id=?;"), fromId)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+type taskTagRecord struct {
+	TaskId task.ID `db:"task_id"`
+	Name   string  `db:"name"`
+}
+
+// loadTags returns tags of the given tasks, by task id.
+func loadTags(q sqlx.Ext, ids []task.ID) (map[task.ID][]string, error) {
+	result := make(map[task.ID][]string, len(ids))
+	if len(ids) == 0 {
+		return result, nil
+	}
+
+	query, args, err := sqlx.In(
+		`SELECT task_tag.task_id, tag.name FROM task_tag
+		JOIN tag ON tag.id = task_tag.tag_id
+		WHERE task_tag.task_id IN (?) ORDER BY tag.name;`,
+		ids,
+	)
+	if err != nil {
+		return nil, err
+	}
+	var records []taskTagRecord
+	err = sqlx.Select(q, &records, q.Rebind(query), args...)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, record := range records {
+		result[record.TaskId] = append(result[record.TaskId], record.Name)
+	}
+
+	return result, nil
+}
+
+// saveTags replaces tags of the task, creating the ones which don't exist yet.
+func saveTags(tx *sqlx.Tx, id task.ID, tags []string) error {
+	_, err := tx.Exec(tx.Rebind("DELETE FROM task_tag WHERE task_id=?;"), id)
+	if err != nil {
+		return err
+	}
+
+	for _, tag := range tags {
+		tagId, err := ensureTag(tx, tag)
+		if err != nil {
+			return err
+		}
+		_, err = tx.Exec(tx.Rebind("INSERT INTO task_tag (task_id, tag_id) VALUES (?, ?);"), id, tagId)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func ensureTag(tx *sqlx.Tx, name string) (uint, error) {
+	_, err := tx.Exec(tx.Rebind(dialectOf(tx).insertIgnore("INSERT INTO tag (name) VALUES (?);")), name)
+	if err != nil {
+		return 0, err
+	}
+
+	return tagId(tx, name)
+}
+
+func tagId(q sqlx.Ext, name string) (uint, error) {
+	var id uint
+	err := sqlx.Get(q, &id, q.Rebind("SELECT id FROM tag WHERE name=?;"), name)
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, ErrResourceNotFound
+	}
+
+	return id, err
+}
+
+// tagsCondition matches tasks labeled with all the tags, or any of them.
+func tagsCondition(tags []string, any bool) (string, []any, error) {
+	if any {
+		return sqlx.In(
+			`id IN (SELECT task_tag.task_id FROM task_tag
+			JOIN tag ON tag.id = task_tag.tag_id WHERE tag.name IN (?))`,
+			tags,
+		)
+	}
+
+	return sqlx.In(
+		`id IN (SELECT task_tag.task_id FROM task_tag
+		JOIN tag ON tag.id = task_tag.tag_id WHERE tag.name IN (?)
+		GROUP BY task_tag.task_id HAVING COUNT(*) = ?)`,
+		tags,
+		len(tags),
+	)
+}
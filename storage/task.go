@@ -4,12 +4,17 @@ import (
 	"database/sql"
 	"demo-app-go/task"
 	"errors"
+	"fmt"
 	"github.com/jmoiron/sqlx"
+	"log"
+	"strings"
 	"time"
 )
 
-var ErrResourceNotFound = errors.New("resource not found")
+// ErrResourceNotFound matches task.ErrNotFound, so the domain does not need to know about storage errors.
+var ErrResourceNotFound = fmt.Errorf("resource %w", task.ErrNotFound)
 
+// TaskRepository stores tasks in any of the databases supported by Open.
 type TaskRepository struct {
 	db *sqlx.DB
 }
@@ -18,17 +23,42 @@ func NewTaskRepository(db *sqlx.DB) *TaskRepository {
 	return &TaskRepository{db: db}
 }
 
+// taskRecord is also stored as JSON, in events queued in the outbox.
 type taskRecord struct {
-	Id          task.ID    `db:"id"`
-	Title       string     `db:"title"`
-	Description string     `db:"description"`
-	CreatedAt   time.Time  `db:"created_at"`
-	UpdatedAt   *time.Time `db:"updated_at"`
+	Id          task.ID         `db:"id" json:"id"`
+	Title       string          `db:"title" json:"title"`
+	Description string          `db:"description" json:"description"`
+	Status      string          `db:"status" json:"status"`
+	DueAt       *time.Time      `db:"due_at" json:"dueAt"`
+	DueTimezone *string         `db:"due_timezone" json:"dueTimezone"`
+	ParentId    *task.ID        `db:"parent_id" json:"parentId"`
+	ProjectId   *task.ProjectID `db:"project_id" json:"projectId"`
+	ColumnId    *task.ColumnID  `db:"column_id" json:"columnId"`
+	// RecurrenceStart shares the timezone of the due date.
+	RecurrenceRule  *string      `db:"recurrence_rule" json:"recurrenceRule"`
+	RecurrenceStart *time.Time   `db:"recurrence_start" json:"recurrenceStart"`
+	Rank            task.Rank    `db:"sort_rank" json:"rank"`
+	Version         task.Version `db:"version" json:"version"`
+	CreatedAt       time.Time    `db:"created_at" json:"createdAt"`
+	UpdatedAt       *time.Time   `db:"updated_at" json:"updatedAt"`
+	DeletedAt       *time.Time   `db:"deleted_at" json:"deletedAt"`
+	// CommentCount is not a column of the task table, see selectTask.
+	CommentCount int `db:"comment_count" json:"commentCount"`
 }
 
-func (r *TaskRepository) List() ([]task.Task, error) {
+// selectTask reads tasks along with count of their comments.
+const selectTask = `SELECT task.*,
+	(SELECT COUNT(*) FROM task_comment WHERE task_comment.task_id = task.id) AS comment_count
+	FROM task`
+
+func (r *TaskRepository) List(filter task.ListFilter) ([]task.Task, error) {
+	query, args, err := listQuery(filter)
+	if err != nil {
+		return nil, err
+	}
+
 	var records []taskRecord
-	err := r.db.Select(&records, "SELECT * FROM task;")
+	err = r.db.Select(&records, r.db.Rebind(query), args...)
 	if errors.Is(err, sql.ErrNoRows) {
 		return []task.Task{}, nil
 	}
@@ -36,84 +66,775 @@ func (r *TaskRepository) List() ([]task.Task, error) {
 		return nil, err
 	}
 
+	ids := make([]task.ID, len(records))
+	for i, record := range records {
+		ids[i] = record.Id
+	}
+	tags, err := loadTags(r.db, ids)
+	if err != nil {
+		return nil, err
+	}
+	checklists, err := loadChecklists(r.db, ids)
+	if err != nil {
+		return nil, err
+	}
+
 	result := make([]task.Task, len(records))
 	for i, record := range records {
-		result[i] = createTask(record)
+		result[i], err = createTask(record, tags[record.Id], checklists[record.Id])
+		if err != nil {
+			return nil, err
+		}
 	}
 
 	return result, nil
 }
 
+// listQuery translates task.ListFilter into SQL query with its arguments.
+func listQuery(filter task.ListFilter) (string, []any, error) {
+	conditions := []string{"deleted_at IS NULL"}
+	if filter.Deleted {
+		conditions[0] = "deleted_at IS NOT NULL"
+	}
+	var args []any
+	if len(filter.Statuses) > 0 {
+		condition, conditionArgs, err := sqlx.In("status IN (?)", filter.Statuses)
+		if err != nil {
+			return "", nil, err
+		}
+		conditions = append(conditions, condition)
+		args = append(args, conditionArgs...)
+	}
+	// Due date conditions are served by the task_deleted_at_due_at index, along with the condition on deleted_at.
+	if filter.DueBefore != nil {
+		conditions = append(conditions, "due_at < ?")
+		args = append(args, filter.DueBefore.UTC())
+	}
+	if filter.DueAfter != nil {
+		conditions = append(conditions, "due_at > ?")
+		args = append(args, filter.DueAfter.UTC())
+	}
+	if filter.OverdueAt != nil {
+		condition, conditionArgs, err := sqlx.In(
+			"due_at < ? AND status IN (?)",
+			filter.OverdueAt.UTC(),
+			task.OpenStatuses(),
+		)
+		if err != nil {
+			return "", nil, err
+		}
+		conditions = append(conditions, condition)
+		args = append(args, conditionArgs...)
+	}
+	if filter.ParentID != nil {
+		conditions = append(conditions, "parent_id = ?")
+		args = append(args, *filter.ParentID)
+	}
+	if filter.ProjectID != nil {
+		conditions = append(conditions, "project_id = ?")
+		args = append(args, *filter.ProjectID)
+	}
+	if filter.ColumnID != nil {
+		conditions = append(conditions, "column_id = ?")
+		args = append(args, *filter.ColumnID)
+	}
+	if len(filter.Tags) > 0 {
+		condition, conditionArgs, err := tagsCondition(filter.Tags, filter.AnyTag)
+		if err != nil {
+			return "", nil, err
+		}
+		conditions = append(conditions, condition)
+		args = append(args, conditionArgs...)
+	}
+
+	// Ordering is expected to be served by the index on (sort_rank, id).
+	query := selectTask + " WHERE " + strings.Join(conditions, " AND ") + " ORDER BY sort_rank, id;"
+	return query, args, nil
+}
+
+// GetByID does not find tasks in the trash.
 func (r *TaskRepository) GetByID(id task.ID) (task.Task, error) {
+	return r.get(selectTask+" WHERE id=? AND deleted_at IS NULL;", id)
+}
+
+func (r *TaskRepository) GetFromTrash(id task.ID) (task.Task, error) {
+	return r.get(selectTask+" WHERE id=? AND deleted_at IS NOT NULL;", id)
+}
+
+func (r *TaskRepository) get(query string, id task.ID) (task.Task, error) {
 	var record taskRecord
-	err := r.db.Get(&record, "SELECT * FROM task WHERE id=?;", id)
+	err := r.db.Get(&record, r.db.Rebind(query), id)
 	if errors.Is(err, sql.ErrNoRows) {
 		return task.Task{}, ErrResourceNotFound
 	}
 	if err != nil {
 		return task.Task{}, err
 	}
+	tags, err := loadTags(r.db, []task.ID{id})
+	if err != nil {
+		return task.Task{}, err
+	}
+	checklists, err := loadChecklists(r.db, []task.ID{id})
+	if err != nil {
+		return task.Task{}, err
+	}
 
-	return createTask(record), nil
+	return createTask(record, tags[id], checklists[id])
 }
 
-func (r *TaskRepository) Add(addTask task.AddTaskCommand) (task.Task, error) {
-	rows, err := r.db.NamedQuery(
-		"INSERT INTO task (title, description, created_at) VALUES (:title, :description, :createdAt) RETURNING *;",
+func (r *TaskRepository) Add(
+	addTask task.AddTaskCommand,
+	record func(task.Task) task.ChangeRecord,
+) (task.Task, error) {
+	tx, err := r.db.Beginx()
+	if err != nil {
+		return task.Task{}, err
+	}
+	defer rollback(tx)
+
+	entity, err := addTaskTx(tx, addTask, addTask.ParentID(), nil, record)
+	if err != nil {
+		return task.Task{}, err
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return task.Task{}, err
+	}
+
+	return entity, nil
+}
+
+func (r *TaskRepository) AddTree(
+	addTree task.AddTaskTreeCommand,
+	record func(task.Task) task.ChangeRecord,
+) (task.Tree, error) {
+	tx, err := r.db.Beginx()
+	if err != nil {
+		return task.Tree{}, err
+	}
+	defer rollback(tx)
+
+	tree, err := addTreeTx(tx, addTree, addTree.Task().ParentID(), record)
+	if err != nil {
+		return task.Tree{}, err
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return task.Tree{}, err
+	}
+
+	return tree, nil
+}
+
+// addTreeTx inserts the task under the parent, followed by its subtasks.
+func addTreeTx(
+	tx *sqlx.Tx,
+	addTree task.AddTaskTreeCommand,
+	parentID *task.ID,
+	record func(task.Task) task.ChangeRecord,
+) (task.Tree, error) {
+	var source *copySource
+	if addTree.Source() != 0 {
+		source = &copySource{
+			id:          addTree.Source(),
+			comments:    addTree.CopyComments(),
+			attachments: addTree.CopyAttachments(),
+		}
+	}
+	entity, err := addTaskTx(tx, addTree.Task(), parentID, source, record)
+	if err != nil {
+		return task.Tree{}, err
+	}
+	id := entity.Id()
+	subtasks := make([]task.Tree, len(addTree.Subtasks()))
+	for i, subtask := range addTree.Subtasks() {
+		subtasks[i], err = addTreeTx(tx, subtask, &id, record)
+		if err != nil {
+			return task.Tree{}, err
+		}
+	}
+
+	return task.NewTree(entity, subtasks), nil
+}
+
+// copySource is the task whose comments and attachments are copied to the new one.
+type copySource struct {
+	id          task.ID
+	comments    bool
+	attachments bool
+}
+
+// addTaskTx inserts the task along with its tags and checklist. The parent is passed separately, as parents
+// of subtasks added at once are not known until they are inserted.
+func addTaskTx(
+	tx *sqlx.Tx,
+	addTask task.AddTaskCommand,
+	parentID *task.ID,
+	source *copySource,
+	record func(task.Task) task.ChangeRecord,
+) (task.Task, error) {
+	inserted, err := insertTask(tx, addTask, parentID)
+	if err != nil {
+		return task.Task{}, err
+	}
+	err = saveTags(tx, inserted.Id, addTask.Tags())
+	if err != nil {
+		return task.Task{}, err
+	}
+	err = saveChecklist(tx, inserted.Id, addTask.Checklist())
+	if err != nil {
+		return task.Task{}, err
+	}
+	if source != nil {
+		inserted.CommentCount, err = copyContent(tx, *source, inserted.Id)
+		if err != nil {
+			return task.Task{}, err
+		}
+	}
+	entity, err := createTask(inserted, addTask.Tags(), addTask.Checklist())
+	if err != nil {
+		return task.Task{}, err
+	}
+	err = storeChangeRecord(tx, record(entity))
+	if err != nil {
+		return task.Task{}, err
+	}
+
+	return entity, nil
+}
+
+// copyContent copies comments and attachments of the source task, as chosen. It returns the number of comments copied.
+// Attachments share content with the originals, which stays in the blob store while any of them refers to it.
+func copyContent(tx *sqlx.Tx, source copySource, id task.ID) (int, error) {
+	comments := 0
+	if source.comments {
+		result, err := tx.Exec(
+			tx.Rebind(`INSERT INTO task_comment (task_id, author, body, created_at, edited_at)
+			SELECT ?, author, body, created_at, edited_at FROM task_comment WHERE task_id=? ORDER BY id;`),
+			id,
+			source.id,
+		)
+		if err != nil {
+			return 0, err
+		}
+		affected, err := result.RowsAffected()
+		if err != nil {
+			return 0, err
+		}
+		comments = int(affected)
+	}
+	if source.attachments {
+		_, err := tx.Exec(
+			tx.Rebind(`INSERT INTO task_attachment (task_id, file_name, content_type, size, hash, created_at)
+			SELECT ?, file_name, content_type, size, hash, created_at FROM task_attachment WHERE task_id=? ORDER BY id;`),
+			id,
+			source.id,
+		)
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	return comments, nil
+}
+
+func insertTask(tx *sqlx.Tx, addTask task.AddTaskCommand, parentID *task.ID) (taskRecord, error) {
+	rows, err := tx.NamedQuery(
+		`INSERT INTO task (title, description, status, due_at, due_timezone, parent_id, project_id, column_id,
+		recurrence_rule, recurrence_start, sort_rank, version, created_at)
+		VALUES (:title, :description, :status, :dueAt, :dueTimezone, :parentId, :projectId, :columnId,
+		:recurrenceRule, :recurrenceStart, :rank, 1, :createdAt) RETURNING *;`,
 		map[string]any{
-			"title":       addTask.Title(),
-			"description": addTask.Description(),
-			"createdAt":   addTask.CreatedAt(),
+			"title":           addTask.Title(),
+			"description":     addTask.Description(),
+			"status":          addTask.Status(),
+			"dueAt":           utc(addTask.DueAt()),
+			"dueTimezone":     timezoneName(addTask.DueAt()),
+			"parentId":        parentID,
+			"projectId":       addTask.ProjectID(),
+			"columnId":        addTask.ColumnID(),
+			"recurrenceRule":  recurrenceRule(addTask.Recurrence()),
+			"recurrenceStart": recurrenceStart(addTask.Recurrence()),
+			"rank":            addTask.Rank(),
+			"createdAt":       addTask.CreatedAt(),
 		},
 	)
 	if err != nil {
-		return task.Task{}, err
+		return taskRecord{}, referenceError(err)
 	}
+	defer rows.Close()
 
 	var record taskRecord
 	if rows.Next() == false {
-		return task.Task{}, errors.New("sql: Next() failed")
+		return taskRecord{}, referenceError(noRowReturned(rows))
 	}
 	err = rows.StructScan(&record)
+	if err != nil {
+		return taskRecord{}, err
+	}
+
+	return record, rows.Close()
+}
+
+func (r *TaskRepository) Save(entity task.Task, record task.ChangeRecord) error {
+	tx, err := r.db.Beginx()
+	if err != nil {
+		return err
+	}
+	defer rollback(tx)
+
+	err = saveTaskTx(tx, entity, record)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (r *TaskRepository) SaveAll(changes []task.TaskChange) error {
+	tx, err := r.db.Beginx()
+	if err != nil {
+		return err
+	}
+	defer rollback(tx)
+
+	for _, change := range changes {
+		err = saveTaskTx(tx, change.Task, change.Record)
+		if err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+func (r *TaskRepository) SaveAndAdd(
+	entity task.Task,
+	record task.ChangeRecord,
+	addTask task.AddTaskCommand,
+	recordAdded func(task.Task) task.ChangeRecord,
+) (task.Task, error) {
+	tx, err := r.db.Beginx()
 	if err != nil {
 		return task.Task{}, err
 	}
+	defer rollback(tx)
 
-	return createTask(record), nil
+	err = saveTaskTx(tx, entity, record)
+	if err != nil {
+		return task.Task{}, err
+	}
+	added, err := addTaskTx(tx, addTask, addTask.ParentID(), nil, recordAdded)
+	if err != nil {
+		return task.Task{}, err
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return task.Task{}, err
+	}
+
+	return added, nil
 }
 
-func (r *TaskRepository) Save(task task.Task) error {
-	_, err := r.db.NamedExec(
-		"UPDATE task SET title=:title, description=:description, updated_at=:updatedAt WHERE id=:id;",
+// saveTaskTx updates the task at its version, along with its tags and checklist, and stores the change record.
+func saveTaskTx(tx *sqlx.Tx, entity task.Task, record task.ChangeRecord) error {
+	result, err := tx.NamedExec(
+		`UPDATE task SET title=:title, description=:description, status=:status,
+		due_at=:dueAt, due_timezone=:dueTimezone, parent_id=:parentId, project_id=:projectId, column_id=:columnId,
+		recurrence_rule=:recurrenceRule, recurrence_start=:recurrenceStart, updated_at=:updatedAt,
+		deleted_at=:deletedAt, version=version+1 WHERE id=:id AND version=:version;`,
 		map[string]any{
-			"id":          task.Id(),
-			"title":       task.Title(),
-			"description": task.Description(),
-			"updatedAt":   task.UpdatedAt(),
+			"id":              entity.Id(),
+			"title":           entity.Title(),
+			"description":     entity.Description(),
+			"status":          entity.Status(),
+			"dueAt":           utc(entity.DueAt()),
+			"dueTimezone":     timezoneName(entity.DueAt()),
+			"parentId":        entity.ParentID(),
+			"projectId":       entity.ProjectID(),
+			"columnId":        entity.ColumnID(),
+			"recurrenceRule":  recurrenceRule(entity.Recurrence()),
+			"recurrenceStart": recurrenceStart(entity.Recurrence()),
+			"updatedAt":       entity.UpdatedAt(),
+			"deletedAt":       utc(entity.DeletedAt()),
+			"version":         entity.Version(),
 		},
 	)
-	if errors.Is(err, sql.ErrNoRows) {
+	if err != nil {
+		return referenceError(err)
+	}
+
+	// Version always changes, so no affected row means the task is either missing or at another version.
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		err = exists(tx, entity.Id())
+		if err != nil {
+			return err
+		}
+		return task.ErrVersionMismatch
+	}
+	err = saveTags(tx, entity.Id(), entity.Tags())
+	if err != nil {
+		return err
+	}
+	err = saveChecklist(tx, entity.Id(), entity.Checklist())
+	if err != nil {
+		return err
+	}
+
+	return storeChangeRecord(tx, record)
+}
+
+// Delete removes the task permanently, along with dependencies it takes part in, its tags, checklist, time entries,
+// comments, history and attachments.
+// Its remaining subtasks become top level ones. Tasks are expected to be moved to the trash with Save instead.
+func (r *TaskRepository) Delete(id task.ID, record task.ChangeRecord) error {
+	tx, err := r.db.Beginx()
+	if err != nil {
+		return err
+	}
+	defer rollback(tx)
+
+	_, err = tx.Exec(tx.Rebind("UPDATE task SET parent_id=NULL WHERE parent_id=?;"), id)
+	if err != nil {
+		return err
+	}
+	_, err = tx.Exec(tx.Rebind("DELETE FROM task_dependency WHERE blocker_id=? OR blocked_id=?;"), id, id)
+	if err != nil {
+		return err
+	}
+	_, err = tx.Exec(tx.Rebind("DELETE FROM task_tag WHERE task_id=?;"), id)
+	if err != nil {
+		return err
+	}
+	_, err = tx.Exec(tx.Rebind("DELETE FROM task_checklist_item WHERE task_id=?;"), id)
+	if err != nil {
+		return err
+	}
+	_, err = tx.Exec(tx.Rebind("DELETE FROM task_time_entry WHERE task_id=?;"), id)
+	if err != nil {
+		return err
+	}
+	_, err = tx.Exec(tx.Rebind("DELETE FROM task_comment WHERE task_id=?;"), id)
+	if err != nil {
+		return err
+	}
+	_, err = tx.Exec(tx.Rebind("DELETE FROM task_revision WHERE task_id=?;"), id)
+	if err != nil {
+		return err
+	}
+	// Contents of the attachments stay in the blob store, as they may be shared with other tasks, until swept.
+	_, err = tx.Exec(tx.Rebind("DELETE FROM task_attachment WHERE task_id=?;"), id)
+	if err != nil {
+		return err
+	}
+	result, err := tx.Exec(tx.Rebind("DELETE FROM task WHERE id=?;"), id)
+	if err != nil {
+		return err
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
 		return ErrResourceNotFound
 	}
+	err = storeChangeRecord(tx, record)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (r *TaskRepository) LastRank() (task.Rank, error) {
+	var rank task.Rank
+	err := r.db.Get(&rank, "SELECT COALESCE(MAX(sort_rank), '') FROM task;")
+	return rank, err
+}
+
+// SaveRanks changes only the rank column, so it neither conflicts with concurrent saves nor changes versions.
+func (r *TaskRepository) SaveRanks(ranks map[task.ID]task.Rank) error {
+	tx, err := r.db.Beginx()
+	if err != nil {
+		return err
+	}
+	defer rollback(tx)
+
+	err = saveRanksTx(tx, ranks)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (r *TaskRepository) SaveWithRanks(entity task.Task, record task.ChangeRecord, ranks map[task.ID]task.Rank) error {
+	tx, err := r.db.Beginx()
+	if err != nil {
+		return err
+	}
+	defer rollback(tx)
+
+	err = saveTaskTx(tx, entity, record)
+	if err != nil {
+		return err
+	}
+	err = saveRanksTx(tx, ranks)
 	if err != nil {
 		return err
 	}
 
+	return tx.Commit()
+}
+
+func saveRanksTx(tx *sqlx.Tx, ranks map[task.ID]task.Rank) error {
+	for id, rank := range ranks {
+		result, err := tx.Exec(tx.Rebind("UPDATE task SET sort_rank=? WHERE id=?;"), rank, id)
+		if err != nil {
+			return err
+		}
+		// Unchanged rank does not count as affected row.
+		affected, err := result.RowsAffected()
+		if err != nil {
+			return err
+		}
+		if affected == 0 {
+			err = exists(tx, id)
+			if err != nil {
+				return err
+			}
+		}
+	}
 	return nil
 }
 
-func (r *TaskRepository) Delete(id task.ID) error {
-	_, err := r.db.Exec("DELETE FROM task WHERE id=?;", id)
-	if errors.Is(err, sql.ErrNoRows) {
+// storeChangeRecord stores the revision and queues events in the outbox, within the transaction changing the task.
+func storeChangeRecord(tx *sqlx.Tx, record task.ChangeRecord) error {
+	if record.Revision != nil {
+		err := insertRevision(tx, *record.Revision)
+		if err != nil {
+			return fmt.Errorf("recording revision: %w", err)
+		}
+	}
+	for _, event := range record.Events {
+		err := insertOutboxEvent(tx, event)
+		if err != nil {
+			return fmt.Errorf("queueing %T: %w", event, err)
+		}
+	}
+	return nil
+}
+
+type dependencyRecord struct {
+	BlockerId task.ID `db:"blocker_id"`
+	BlockedId task.ID `db:"blocked_id"`
+}
+
+func (r *TaskRepository) ListDependencies() ([]task.Dependency, error) {
+	var records []dependencyRecord
+	err := r.db.Select(&records, "SELECT blocker_id, blocked_id FROM task_dependency;")
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]task.Dependency, len(records))
+	for i, record := range records {
+		result[i], err = task.NewDependency(record.BlockerId, record.BlockedId)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return result, nil
+}
+
+// AddDependency does nothing when the dependency exists already.
+func (r *TaskRepository) AddDependency(dependency task.Dependency) error {
+	_, err := r.db.Exec(
+		r.db.Rebind("INSERT INTO task_dependency (blocker_id, blocked_id) VALUES (?, ?);"),
+		dependency.BlockerID(),
+		dependency.BlockedID(),
+	)
+	if isDuplicateEntry(err) {
+		return nil
+	}
+	return referenceError(err)
+}
+
+func (r *TaskRepository) RemoveDependency(dependency task.Dependency) error {
+	result, err := r.db.Exec(
+		r.db.Rebind("DELETE FROM task_dependency WHERE blocker_id=? AND blocked_id=?;"),
+		dependency.BlockerID(),
+		dependency.BlockedID(),
+	)
+	if err != nil {
+		return err
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
 		return ErrResourceNotFound
 	}
+
+	return nil
+}
+
+func exists(q sqlx.Ext, id task.ID) error {
+	var count int
+	err := sqlx.Get(q, &count, q.Rebind("SELECT COUNT(*) FROM task WHERE id=?;"), id)
 	if err != nil {
 		return err
 	}
+	if count == 0 {
+		return ErrResourceNotFound
+	}
 
 	return nil
 }
 
-func createTask(record taskRecord) task.Task {
-	return task.NewTask(record.Id, record.Title, record.Description, record.CreatedAt, record.UpdatedAt)
+// createTaskRecord is the opposite of createTask, except tags and checklist.
+func createTaskRecord(entity task.Task) taskRecord {
+	return taskRecord{
+		Id:              entity.Id(),
+		Title:           entity.Title(),
+		Description:     entity.Description(),
+		Status:          string(entity.Status()),
+		DueAt:           utc(entity.DueAt()),
+		DueTimezone:     timezoneName(entity.DueAt()),
+		ParentId:        entity.ParentID(),
+		ProjectId:       entity.ProjectID(),
+		ColumnId:        entity.ColumnID(),
+		RecurrenceRule:  recurrenceRule(entity.Recurrence()),
+		RecurrenceStart: recurrenceStart(entity.Recurrence()),
+		Rank:            entity.Rank(),
+		Version:         entity.Version(),
+		CreatedAt:       entity.CreatedAt(),
+		UpdatedAt:       entity.UpdatedAt(),
+		DeletedAt:       entity.DeletedAt(),
+		CommentCount:    entity.CommentCount(),
+	}
+}
+
+func createTask(record taskRecord, tags []string, checklist []task.ChecklistItem) (task.Task, error) {
+	status, err := task.ParseStatus(record.Status)
+	if err != nil {
+		return task.Task{}, fmt.Errorf("task %d: %w", record.Id, err)
+	}
+	dueAt, err := inTimezone(record.DueAt, record.DueTimezone)
+	if err != nil {
+		return task.Task{}, fmt.Errorf("task %d: %w", record.Id, err)
+	}
+	recurrence, err := createRecurrence(record)
+	if err != nil {
+		return task.Task{}, fmt.Errorf("task %d: %w", record.Id, err)
+	}
+
+	return task.NewTask(task.Snapshot{
+		Id:           record.Id,
+		Title:        record.Title,
+		Description:  record.Description,
+		Status:       status,
+		DueAt:        dueAt,
+		ParentID:     record.ParentId,
+		ProjectID:    record.ProjectId,
+		ColumnID:     record.ColumnId,
+		Tags:         tags,
+		Recurrence:   recurrence,
+		Checklist:    checklist,
+		Rank:         record.Rank,
+		CommentCount: record.CommentCount,
+		Version:      record.Version,
+		CreatedAt:    record.CreatedAt,
+		UpdatedAt:    record.UpdatedAt,
+		DeletedAt:    record.DeletedAt,
+	}), nil
+}
+
+func createRecurrence(record taskRecord) (*task.Recurrence, error) {
+	if record.RecurrenceRule == nil || record.RecurrenceStart == nil {
+		return nil, nil
+	}
+	rule, err := task.ParseRecurrenceRule(*record.RecurrenceRule)
+	if err != nil {
+		return nil, err
+	}
+	start, err := inTimezone(record.RecurrenceStart, record.DueTimezone)
+	if err != nil {
+		return nil, err
+	}
+
+	recurrence := task.NewRecurrence(rule, *start)
+	return &recurrence, nil
+}
+
+func recurrenceRule(recurrence *task.Recurrence) *string {
+	if recurrence == nil {
+		return nil
+	}
+	rule := recurrence.Rule().String()
+	return &rule
+}
+
+func recurrenceStart(recurrence *task.Recurrence) *time.Time {
+	if recurrence == nil {
+		return nil
+	}
+	start := recurrence.Start().UTC()
+	return &start
+}
+
+func utc(t *time.Time) *time.Time {
+	if t == nil {
+		return nil
+	}
+	result := t.UTC()
+	return &result
+}
+
+// timezoneName returns name of the time's location, so it can be restored with inTimezone.
+// Times parsed from RFC 3339 may have unnamed location, in which case the offset is used instead.
+func timezoneName(t *time.Time) *string {
+	if t == nil {
+		return nil
+	}
+	name := t.Location().String()
+	if name == "" {
+		name = t.Format("-07:00")
+	}
+	return &name
+}
+
+func inTimezone(t *time.Time, timezone *string) (*time.Time, error) {
+	if t == nil || timezone == nil {
+		return t, nil
+	}
+	location, err := time.LoadLocation(*timezone)
+	if err != nil {
+		offset, offsetErr := time.Parse("-07:00", *timezone)
+		if offsetErr != nil {
+			return nil, fmt.Errorf("timezone %q: %w", *timezone, err)
+		}
+		location = offset.Location()
+	}
+	result := t.In(location)
+	return &result, nil
+}
+
+// rollback is meant to be deferred right after starting a transaction. It does nothing once tx is committed.
+func rollback(tx *sqlx.Tx) {
+	err := tx.Rollback()
+	if err != nil && !errors.Is(err, sql.ErrTxDone) {
+		log.Printf("Rolling back transaction failed: %s", err)
+	}
 }
@@ -0,0 +1,59 @@
+package storage_test
+
+import (
+	"demo-app-go/eventbus"
+	"demo-app-go/storage"
+	"demo-app-go/storage/storagetest"
+	"github.com/jmoiron/sqlx"
+	"github.com/stretchr/testify/require"
+	"os"
+	"testing"
+)
+
+// TestTaskRepository runs the conformance suite against the in-process backends, and against MariaDB or Postgres
+// when TEST_MARIADB_DSN or TEST_POSTGRES_DSN gives a database which the suite may wipe, e.g.
+// TEST_MARIADB_DSN="root:openSesame@(127.0.0.1:3306)/demo-app-test?parseTime=true".
+func TestTaskRepository(t *testing.T) {
+	t.Run("memory", func(t *testing.T) {
+		t.Parallel()
+		storagetest.TestTaskRepository(t, func(t *testing.T) storagetest.TaskRepository {
+			return storage.NewMemoryTaskRepository(storage.NewMemory(eventbus.NewBus()))
+		})
+	})
+	t.Run("sqlite", func(t *testing.T) {
+		t.Parallel()
+		storagetest.TestTaskRepository(t, func(t *testing.T) storagetest.TaskRepository {
+			db, err := storage.Open("sqlite://" + t.TempDir() + "/test.db")
+			require.NoError(t, err, "open database")
+			t.Cleanup(func() { db.Close() })
+			return storage.NewTaskRepository(emptyDatabase(t, db))
+		})
+	})
+	for name, variable := range map[string]string{"mariadb": "TEST_MARIADB_DSN", "postgres": "TEST_POSTGRES_DSN"} {
+		variable := variable
+		t.Run(name, func(t *testing.T) {
+			dsn := os.Getenv(variable)
+			if dsn == "" {
+				t.Skipf("%s is not set", variable)
+			}
+			db, err := storage.Open(dsn)
+			require.NoError(t, err, "open database")
+			t.Cleanup(func() { db.Close() })
+			storagetest.TestTaskRepository(t, func(t *testing.T) storagetest.TaskRepository {
+				return storage.NewTaskRepository(emptyDatabase(t, db))
+			})
+		})
+	}
+}
+
+// emptyDatabase reverts all migrations and applies them again, so that the database has no data.
+func emptyDatabase(t *testing.T, db *sqlx.DB) *sqlx.DB {
+	t.Helper()
+	migrator, err := storage.NewMigrator(db)
+	require.NoError(t, err, "create migrator")
+	_, err = migrator.To(0)
+	require.NoError(t, err, "revert migrations")
+	_, err = migrator.Up()
+	require.NoError(t, err, "apply migrations")
+	return db
+}
@@ -0,0 +1,192 @@
+package storage
+
+import (
+	"database/sql"
+	"demo-app-go/task"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/jmoiron/sqlx"
+	"time"
+)
+
+type TemplateRepository struct {
+	db *sqlx.DB
+}
+
+func NewTemplateRepository(db *sqlx.DB) *TemplateRepository {
+	return &TemplateRepository{db: db}
+}
+
+// templateRecord keeps the whole task tree of the template as JSON, it is always read and written at once.
+type templateRecord struct {
+	Id         task.TemplateID `db:"id"`
+	Name       string          `db:"name"`
+	Definition []byte          `db:"definition"`
+	CreatedAt  time.Time       `db:"created_at"`
+	UpdatedAt  *time.Time      `db:"updated_at"`
+}
+
+type templateTaskRecord struct {
+	Title       string               `json:"title"`
+	Description string               `json:"description"`
+	Tags        []string             `json:"tags"`
+	Checklist   []string             `json:"checklist"`
+	DueIn       *time.Duration       `json:"dueIn"`
+	Subtasks    []templateTaskRecord `json:"subtasks"`
+}
+
+func (r *TemplateRepository) List() ([]task.Template, error) {
+	var records []templateRecord
+	err := r.db.Select(&records, "SELECT * FROM template ORDER BY name, id;")
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]task.Template, len(records))
+	for i, record := range records {
+		result[i], err = createTemplate(record)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return result, nil
+}
+
+func (r *TemplateRepository) GetByID(id task.TemplateID) (task.Template, error) {
+	var record templateRecord
+	err := r.db.Get(&record, r.db.Rebind("SELECT * FROM template WHERE id=?;"), id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return task.Template{}, ErrResourceNotFound
+	}
+	if err != nil {
+		return task.Template{}, err
+	}
+
+	return createTemplate(record)
+}
+
+func (r *TemplateRepository) Add(addTemplate task.AddTemplateCommand) (task.Template, error) {
+	definition, err := json.Marshal(createTemplateTaskRecord(addTemplate.Task()))
+	if err != nil {
+		return task.Template{}, err
+	}
+	rows, err := r.db.NamedQuery(
+		`INSERT INTO template (name, definition, created_at) VALUES (:name, :definition, :createdAt) RETURNING *;`,
+		map[string]any{
+			"name":       addTemplate.Name(),
+			"definition": string(definition),
+			"createdAt":  addTemplate.CreatedAt(),
+		},
+	)
+	if err != nil {
+		return task.Template{}, err
+	}
+	defer rows.Close()
+
+	var record templateRecord
+	if rows.Next() == false {
+		return task.Template{}, noRowReturned(rows)
+	}
+	err = rows.StructScan(&record)
+	if err != nil {
+		return task.Template{}, err
+	}
+	template, err := createTemplate(record)
+	if err != nil {
+		return task.Template{}, err
+	}
+
+	return template, rows.Close()
+}
+
+func (r *TemplateRepository) Save(template task.Template) error {
+	definition, err := json.Marshal(createTemplateTaskRecord(template.Task()))
+	if err != nil {
+		return err
+	}
+	result, err := r.db.Exec(
+		r.db.Rebind("UPDATE template SET name=?, definition=?, updated_at=? WHERE id=?;"),
+		template.Name(),
+		string(definition),
+		template.UpdatedAt(),
+		template.Id(),
+	)
+	if err != nil {
+		return err
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return ErrResourceNotFound
+	}
+
+	return nil
+}
+
+func (r *TemplateRepository) Delete(id task.TemplateID) error {
+	result, err := r.db.Exec(r.db.Rebind("DELETE FROM template WHERE id=?;"), id)
+	if err != nil {
+		return err
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return ErrResourceNotFound
+	}
+
+	return nil
+}
+
+func createTemplate(record templateRecord) (task.Template, error) {
+	var definition templateTaskRecord
+	err := json.Unmarshal(record.Definition, &definition)
+	if err != nil {
+		return task.Template{}, fmt.Errorf("template %d: %w", record.Id, err)
+	}
+
+	return task.NewTemplate(task.TemplateSnapshot{
+		Id:        record.Id,
+		Name:      record.Name,
+		Task:      createTemplateTask(definition),
+		CreatedAt: record.CreatedAt,
+		UpdatedAt: record.UpdatedAt,
+	}), nil
+}
+
+func createTemplateTask(record templateTaskRecord) task.TemplateTask {
+	subtasks := make([]task.TemplateTask, len(record.Subtasks))
+	for i, subtask := range record.Subtasks {
+		subtasks[i] = createTemplateTask(subtask)
+	}
+	return task.NewTemplateTask(
+		record.Title,
+		record.Description,
+		record.Tags,
+		record.Checklist,
+		record.DueIn,
+		subtasks,
+	)
+}
+
+func createTemplateTaskRecord(templateTask task.TemplateTask) templateTaskRecord {
+	subtasks := make([]templateTaskRecord, len(templateTask.Subtasks()))
+	for i, subtask := range templateTask.Subtasks() {
+		subtasks[i] = createTemplateTaskRecord(subtask)
+	}
+	return templateTaskRecord{
+		Title:       templateTask.Title(),
+		Description: templateTask.Description(),
+		Tags:        templateTask.Tags(),
+		Checklist:   templateTask.Checklist(),
+		DueIn:       templateTask.DueIn(),
+		Subtasks:    subtasks,
+	}
+}
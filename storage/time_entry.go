@@ -0,0 +1,160 @@
+package storage
+
+import (
+	"database/sql"
+	"demo-app-go/task"
+	"errors"
+	"fmt"
+	"github.com/jmoiron/sqlx"
+	"time"
+)
+
+type TimeEntryRepository struct {
+	db *sqlx.DB
+}
+
+func NewTimeEntryRepository(db *sqlx.DB) *TimeEntryRepository {
+	return &TimeEntryRepository{db: db}
+}
+
+type timeEntryRecord struct {
+	Id        task.TimeEntryID `db:"id"`
+	TaskId    task.ID          `db:"task_id"`
+	User      string           `db:"user"`
+	StartAt   time.Time        `db:"start_at"`
+	EndAt     *time.Time       `db:"end_at"`
+	Note      string           `db:"note"`
+	CreatedAt time.Time        `db:"created_at"`
+}
+
+// timeEntryColumns leave out running_user, which only serves the unique key allowing a single running timer per user
+// in MariaDB. User is a reserved word in Postgres, so the column is quoted.
+func timeEntryColumns(d dialect) string {
+	return "id, task_id, " + d.quote("user") + ", start_at, end_at, note, created_at"
+}
+
+func (r *TimeEntryRepository) selectTimeEntry() string {
+	return "SELECT " + timeEntryColumns(dialectOf(r.db)) + " FROM task_time_entry"
+}
+
+func (r *TimeEntryRepository) List(taskID task.ID) ([]task.TimeEntry, error) {
+	return r.list(r.selectTimeEntry()+" WHERE task_id=? ORDER BY start_at, id;", taskID)
+}
+
+func (r *TimeEntryRepository) GetRunning(user string) (task.TimeEntry, error) {
+	var record timeEntryRecord
+	query := r.selectTimeEntry() + " WHERE " + dialectOf(r.db).quote("user") + "=? AND end_at IS NULL;"
+	err := r.db.Get(&record, r.db.Rebind(query), user)
+	if errors.Is(err, sql.ErrNoRows) {
+		return task.TimeEntry{}, ErrResourceNotFound
+	}
+	if err != nil {
+		return task.TimeEntry{}, err
+	}
+
+	return createTimeEntry(record), nil
+}
+
+func (r *TimeEntryRepository) ListFinished(from time.Time, to time.Time, user *string) ([]task.TimeEntry, error) {
+	query := r.selectTimeEntry() + " WHERE end_at IS NOT NULL AND start_at >= ? AND start_at < ?"
+	args := []any{from.UTC(), to.UTC()}
+	if user != nil {
+		query += " AND " + dialectOf(r.db).quote("user") + " = ?"
+		args = append(args, *user)
+	}
+
+	return r.list(query+" ORDER BY start_at, id;", args...)
+}
+
+func (r *TimeEntryRepository) list(query string, args ...any) ([]task.TimeEntry, error) {
+	var records []timeEntryRecord
+	err := r.db.Select(&records, r.db.Rebind(query), args...)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]task.TimeEntry, len(records))
+	for i, record := range records {
+		result[i] = createTimeEntry(record)
+	}
+
+	return result, nil
+}
+
+func (r *TimeEntryRepository) Add(addTimeEntry task.AddTimeEntryCommand) (task.TimeEntry, error) {
+	d := dialectOf(r.db)
+	rows, err := r.db.NamedQuery(
+		`INSERT INTO task_time_entry (task_id, `+d.quote("user")+`, start_at, end_at, note, created_at)
+		VALUES (:taskId, :user, :startAt, :endAt, :note, :createdAt)
+		RETURNING `+timeEntryColumns(d)+`;`,
+		map[string]any{
+			"taskId":    addTimeEntry.TaskID(),
+			"user":      addTimeEntry.User(),
+			"startAt":   addTimeEntry.Start().UTC(),
+			"endAt":     utc(addTimeEntry.End()),
+			"note":      addTimeEntry.Note(),
+			"createdAt": addTimeEntry.CreatedAt(),
+		},
+	)
+	if err != nil {
+		return task.TimeEntry{}, timeEntryError(err, addTimeEntry.User())
+	}
+	defer rows.Close()
+
+	var record timeEntryRecord
+	if rows.Next() == false {
+		return task.TimeEntry{}, timeEntryError(noRowReturned(rows), addTimeEntry.User())
+	}
+	err = rows.StructScan(&record)
+	if err != nil {
+		return task.TimeEntry{}, err
+	}
+
+	return createTimeEntry(record), rows.Close()
+}
+
+// Save only stores the end, as the rest of the entry does not change.
+func (r *TimeEntryRepository) Save(entry task.TimeEntry) error {
+	result, err := r.db.Exec(r.db.Rebind("UPDATE task_time_entry SET end_at=? WHERE id=?;"), utc(entry.End()), entry.Id())
+	if err != nil {
+		return err
+	}
+
+	// Stopping a stopped entry within the same second leaves the row unchanged, which MySQL does not count as affected.
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		var count int
+		err = r.db.Get(&count, r.db.Rebind("SELECT COUNT(*) FROM task_time_entry WHERE id=?;"), entry.Id())
+		if err != nil {
+			return err
+		}
+		if count == 0 {
+			return ErrResourceNotFound
+		}
+	}
+
+	return nil
+}
+
+// timeEntryError tells apart a running timer of the user, and a missing task, from other failures of adding an entry.
+func timeEntryError(err error, user string) error {
+	if isDuplicateEntry(err) {
+		return fmt.Errorf("%w: user %s", task.ErrTimerRunning, user)
+	}
+	return referenceError(err)
+}
+
+func createTimeEntry(record timeEntryRecord) task.TimeEntry {
+	return task.NewTimeEntry(task.TimeEntrySnapshot{
+		Id:        record.Id,
+		TaskID:    record.TaskId,
+		User:      record.User,
+		Start:     record.StartAt,
+		End:       record.EndAt,
+		Note:      record.Note,
+		CreatedAt: record.CreatedAt,
+	})
+}
@@ -0,0 +1,216 @@
+package task
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"mime"
+	"net/http"
+	"path"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+const fileNameMaxLength = 255
+
+var (
+	ErrAttachmentTooLarge    = errors.New("attachment is too large")
+	ErrContentTypeNotAllowed = errors.New("content type is not allowed")
+	ErrAttachmentExists      = errors.New("attachment already exists")
+)
+
+type AttachmentID uint
+
+// Attachment is metadata of a file uploaded to a task. The content itself is kept in BlobStore under its hash,
+// so the same file attached many times is stored once.
+type Attachment struct {
+	id          AttachmentID
+	taskID      ID
+	fileName    string
+	contentType string
+	size        int64
+	hash        string
+	createdAt   time.Time
+}
+
+// AttachmentSnapshot holds the entire state of an Attachment, just like Snapshot does for Task.
+type AttachmentSnapshot struct {
+	Id          AttachmentID
+	TaskID      ID
+	FileName    string
+	ContentType string
+	Size        int64
+	Hash        string
+	CreatedAt   time.Time
+}
+
+func NewAttachment(snapshot AttachmentSnapshot) Attachment {
+	return Attachment{
+		id:          snapshot.Id,
+		taskID:      snapshot.TaskID,
+		fileName:    snapshot.FileName,
+		contentType: snapshot.ContentType,
+		size:        snapshot.Size,
+		hash:        snapshot.Hash,
+		createdAt:   snapshot.CreatedAt,
+	}
+}
+
+func (a Attachment) Id() AttachmentID {
+	return a.id
+}
+
+func (a Attachment) TaskID() ID {
+	return a.taskID
+}
+
+func (a Attachment) FileName() string {
+	return a.fileName
+}
+
+func (a Attachment) ContentType() string {
+	return a.contentType
+}
+
+// Size is in bytes.
+func (a Attachment) Size() int64 {
+	return a.size
+}
+
+// Hash is hex encoded SHA-256 of the content, which is also its key in BlobStore.
+func (a Attachment) Hash() string {
+	return a.hash
+}
+
+func (a Attachment) CreatedAt() time.Time {
+	return a.createdAt
+}
+
+// AttachmentPolicy limits what can be uploaded.
+type AttachmentPolicy struct {
+	maxSize      int64
+	contentTypes []string
+}
+
+// NewAttachmentPolicy expects maximum size in bytes and allowed media types, e.g. image/png.
+// Wildcard subtypes like image/* are supported.
+func NewAttachmentPolicy(maxSize int64, contentTypes []string) (AttachmentPolicy, error) {
+	if maxSize < 1 {
+		return AttachmentPolicy{}, fmt.Errorf("%w: maximum attachment size must be positive", ErrValidation)
+	}
+	if len(contentTypes) == 0 {
+		return AttachmentPolicy{}, fmt.Errorf("%w: at least one content type must be allowed", ErrValidation)
+	}
+	normalized := make([]string, len(contentTypes))
+	for i, contentType := range contentTypes {
+		normalized[i] = strings.ToLower(strings.TrimSpace(contentType))
+		if !strings.Contains(normalized[i], "/") {
+			return AttachmentPolicy{}, fmt.Errorf("%w: invalid content type %q", ErrValidation, contentType)
+		}
+	}
+	return AttachmentPolicy{maxSize: maxSize, contentTypes: normalized}, nil
+}
+
+func (p AttachmentPolicy) MaxSize() int64 {
+	return p.maxSize
+}
+
+func (p AttachmentPolicy) allows(contentType string) bool {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return false
+	}
+	for _, allowed := range p.contentTypes {
+		if allowed == mediaType {
+			return true
+		}
+		if strings.HasSuffix(allowed, "/*") && strings.HasPrefix(mediaType, strings.TrimSuffix(allowed, "*")) {
+			return true
+		}
+	}
+	return false
+}
+
+// AddAttachmentCommand is used for creating new Attachment. It is created by AttachmentService,
+// as it requires the whole content for computing the hash and detecting the type.
+type AddAttachmentCommand struct {
+	taskID      ID
+	fileName    string
+	contentType string
+	size        int64
+	hash        string
+	createdAt   time.Time
+}
+
+// newAddAttachmentCommand validates the file against the policy.
+// Content type is detected from the content itself, so it does not depend on what the client claims.
+func newAddAttachmentCommand(
+	taskID ID,
+	fileName string,
+	content []byte,
+	policy AttachmentPolicy,
+) (AddAttachmentCommand, error) {
+	fileName, err := validateFileName(fileName)
+	if err != nil {
+		return AddAttachmentCommand{}, err
+	}
+	if int64(len(content)) > policy.maxSize {
+		return AddAttachmentCommand{}, fmt.Errorf(
+			"%w: maximum size is %d bytes",
+			ErrAttachmentTooLarge,
+			policy.maxSize,
+		)
+	}
+	contentType := http.DetectContentType(content)
+	if !policy.allows(contentType) {
+		return AddAttachmentCommand{}, fmt.Errorf("%w: %s", ErrContentTypeNotAllowed, contentType)
+	}
+	hash := sha256.Sum256(content)
+
+	return AddAttachmentCommand{
+		taskID:      taskID,
+		fileName:    fileName,
+		contentType: contentType,
+		size:        int64(len(content)),
+		hash:        hex.EncodeToString(hash[:]),
+		createdAt:   time.Now(),
+	}, nil
+}
+
+func (a AddAttachmentCommand) TaskID() ID {
+	return a.taskID
+}
+
+func (a AddAttachmentCommand) FileName() string {
+	return a.fileName
+}
+
+func (a AddAttachmentCommand) ContentType() string {
+	return a.contentType
+}
+
+func (a AddAttachmentCommand) Size() int64 {
+	return a.size
+}
+
+func (a AddAttachmentCommand) Hash() string {
+	return a.hash
+}
+
+func (a AddAttachmentCommand) CreatedAt() time.Time {
+	return a.createdAt
+}
+
+// validateFileName keeps only the base name, as clients may send whole paths.
+func validateFileName(fileName string) (string, error) {
+	fileName = strings.TrimSpace(path.Base(strings.ReplaceAll(fileName, "\\", "/")))
+	if fileName == "" || fileName == "." || fileName == "/" {
+		return "", fmt.Errorf("%w: file name must not be empty", ErrValidation)
+	}
+	if utf8.RuneCountInString(fileName) > fileNameMaxLength {
+		return "", fmt.Errorf("%w: file name must not be longer than %d characters", ErrValidation, fileNameMaxLength)
+	}
+	return fileName, nil
+}
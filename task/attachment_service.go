@@ -0,0 +1,171 @@
+package task
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"time"
+)
+
+// BlobStore keeps contents of attachments. Keys are hashes of the contents, so storing the same key again
+// is expected to succeed without changing anything but the time the blob was put.
+type BlobStore interface {
+	Put(key string, content io.Reader) error
+	// Open is expected to return an error matching ErrNotFound when there is no content under the key.
+	Open(key string) (io.ReadSeekCloser, error)
+	// List returns keys of blobs which were last put before the given time.
+	List(putBefore time.Time) ([]string, error)
+	// Delete removes the blob, unless it was put again since the given time. Missing blob is not an error.
+	Delete(key string, putBefore time.Time) error
+}
+
+// attachmentRepository is expected to return an error matching ErrNotFound when the attachment does not exist.
+// Add is expected to return an error matching ErrAttachmentExists when the task has an attachment with the same hash.
+type attachmentRepository interface {
+	List(taskID ID) ([]Attachment, error)
+	GetByID(id AttachmentID) (Attachment, error)
+	// CountByHash counts attachments of all tasks, which share the content.
+	CountByHash(hash string) (int, error)
+	Add(addAttachment AddAttachmentCommand) (Attachment, error)
+	Delete(id AttachmentID) error
+}
+
+// AttachmentService manages files attached to tasks. Like comments, attachments are addressed through their task.
+type AttachmentService struct {
+	repository attachmentRepository
+	tasks      taskRepository
+	blobs      BlobStore
+	policy     AttachmentPolicy
+}
+
+func NewAttachmentService(
+	repository attachmentRepository,
+	tasks taskRepository,
+	blobs BlobStore,
+	policy AttachmentPolicy,
+) *AttachmentService {
+	return &AttachmentService{repository: repository, tasks: tasks, blobs: blobs, policy: policy}
+}
+
+func (s *AttachmentService) ListAttachments(taskID ID) ([]Attachment, error) {
+	_, err := s.tasks.GetByID(taskID)
+	if err != nil {
+		return nil, notFound(err)
+	}
+
+	return s.repository.List(taskID)
+}
+
+// GetAttachment reports ErrNotFound also when the attachment belongs to another task.
+func (s *AttachmentService) GetAttachment(taskID ID, id AttachmentID) (Attachment, error) {
+	attachment, err := s.repository.GetByID(id)
+	if err != nil {
+		return Attachment{}, notFound(err)
+	}
+	if attachment.TaskID() != taskID {
+		return Attachment{}, ErrNotFound
+	}
+
+	return attachment, nil
+}
+
+// AddAttachment reads the whole content, up to the maximum size allowed by the policy.
+// When the task already has an attachment with the same content, that one is returned and false is reported.
+// The content is put into BlobStore before the attachment refers to it. When adding the attachment fails,
+// the content is left to SweepBlobs.
+func (s *AttachmentService) AddAttachment(taskID ID, fileName string, content io.Reader) (Attachment, bool, error) {
+	_, err := s.tasks.GetByID(taskID)
+	if err != nil {
+		return Attachment{}, false, notFound(err)
+	}
+	data, err := io.ReadAll(io.LimitReader(content, s.policy.maxSize+1))
+	if err != nil {
+		return Attachment{}, false, err
+	}
+	command, err := newAddAttachmentCommand(taskID, fileName, data, s.policy)
+	if err != nil {
+		return Attachment{}, false, err
+	}
+
+	err = s.blobs.Put(command.Hash(), bytes.NewReader(data))
+	if err != nil {
+		return Attachment{}, false, err
+	}
+	attachment, err := s.repository.Add(command)
+	if errors.Is(err, ErrAttachmentExists) {
+		return s.findByHash(taskID, command.Hash())
+	}
+	if err != nil {
+		return Attachment{}, false, notFound(err)
+	}
+
+	return attachment, true, nil
+}
+
+// OpenAttachment returns the content along with metadata. The caller is responsible for closing it.
+func (s *AttachmentService) OpenAttachment(taskID ID, id AttachmentID) (Attachment, io.ReadSeekCloser, error) {
+	attachment, err := s.GetAttachment(taskID, id)
+	if err != nil {
+		return Attachment{}, nil, err
+	}
+	content, err := s.blobs.Open(attachment.Hash())
+	if err != nil {
+		return Attachment{}, nil, err
+	}
+
+	return attachment, content, nil
+}
+
+// DeleteAttachment leaves the content in BlobStore, as other attachments may share it. SweepBlobs removes it
+// once no attachment refers to it.
+func (s *AttachmentService) DeleteAttachment(taskID ID, id AttachmentID) error {
+	_, err := s.GetAttachment(taskID, id)
+	if err != nil {
+		return err
+	}
+
+	return notFound(s.repository.Delete(id))
+}
+
+// SweepBlobs removes contents which no attachment refers to, unless they were put since the given time.
+// It returns how many were removed. Attachments are added only after their content is put, and putting it refreshes
+// the time, so the time is expected to leave enough room for any upload to finish.
+func (s *AttachmentService) SweepBlobs(putBefore time.Time) (int, error) {
+	keys, err := s.blobs.List(putBefore)
+	if err != nil {
+		return 0, err
+	}
+
+	count := 0
+	for _, key := range keys {
+		references, err := s.repository.CountByHash(key)
+		if err != nil {
+			return count, err
+		}
+		if references > 0 {
+			continue
+		}
+		err = s.blobs.Delete(key, putBefore)
+		if err != nil {
+			return count, err
+		}
+		count++
+	}
+
+	return count, nil
+}
+
+// findByHash returns the attachment of the task which has the content, reporting it was not created.
+func (s *AttachmentService) findByHash(taskID ID, hash string) (Attachment, bool, error) {
+	existing, err := s.repository.List(taskID)
+	if err != nil {
+		return Attachment{}, false, err
+	}
+	for _, attachment := range existing {
+		if attachment.Hash() == hash {
+			return attachment, false, nil
+		}
+	}
+
+	return Attachment{}, false, ErrNotFound
+}
@@ -0,0 +1,261 @@
+package task_test
+
+import (
+	"bytes"
+	"demo-app-go/task"
+	"github.com/stretchr/testify/require"
+	"io"
+	"strings"
+	"testing"
+	"time"
+)
+
+// fakeAttachmentRepository keeps attachments in a map and fails the same way as storage.AttachmentRepository.
+type fakeAttachmentRepository struct {
+	attachments map[task.AttachmentID]task.Attachment
+	nextID      task.AttachmentID
+}
+
+func newFakeAttachmentRepository() *fakeAttachmentRepository {
+	return &fakeAttachmentRepository{attachments: map[task.AttachmentID]task.Attachment{}, nextID: 1}
+}
+
+func (r *fakeAttachmentRepository) List(taskID task.ID) ([]task.Attachment, error) {
+	result := []task.Attachment{}
+	for id := task.AttachmentID(1); id < r.nextID; id++ {
+		if attachment, ok := r.attachments[id]; ok && attachment.TaskID() == taskID {
+			result = append(result, attachment)
+		}
+	}
+	return result, nil
+}
+
+func (r *fakeAttachmentRepository) GetByID(id task.AttachmentID) (task.Attachment, error) {
+	attachment, ok := r.attachments[id]
+	if !ok {
+		return task.Attachment{}, wrapNotFound()
+	}
+	return attachment, nil
+}
+
+func (r *fakeAttachmentRepository) CountByHash(hash string) (int, error) {
+	count := 0
+	for _, attachment := range r.attachments {
+		if attachment.Hash() == hash {
+			count++
+		}
+	}
+	return count, nil
+}
+
+func (r *fakeAttachmentRepository) Add(addAttachment task.AddAttachmentCommand) (task.Attachment, error) {
+	for _, existing := range r.attachments {
+		if existing.TaskID() == addAttachment.TaskID() && existing.Hash() == addAttachment.Hash() {
+			return task.Attachment{}, task.ErrAttachmentExists
+		}
+	}
+	attachment := task.NewAttachment(task.AttachmentSnapshot{
+		Id:          r.nextID,
+		TaskID:      addAttachment.TaskID(),
+		FileName:    addAttachment.FileName(),
+		ContentType: addAttachment.ContentType(),
+		Size:        addAttachment.Size(),
+		Hash:        addAttachment.Hash(),
+		CreatedAt:   addAttachment.CreatedAt(),
+	})
+	r.attachments[attachment.Id()] = attachment
+	r.nextID++
+	return attachment, nil
+}
+
+func (r *fakeAttachmentRepository) Delete(id task.AttachmentID) error {
+	if _, ok := r.attachments[id]; !ok {
+		return wrapNotFound()
+	}
+	delete(r.attachments, id)
+	return nil
+}
+
+// fakeBlobStore keeps blobs in memory, along with the time they were last put.
+type fakeBlobStore struct {
+	contents map[string][]byte
+	putAt    map[string]time.Time
+}
+
+func newFakeBlobStore() *fakeBlobStore {
+	return &fakeBlobStore{contents: map[string][]byte{}, putAt: map[string]time.Time{}}
+}
+
+func (s *fakeBlobStore) Put(key string, content io.Reader) error {
+	data, err := io.ReadAll(content)
+	if err != nil {
+		return err
+	}
+	s.contents[key] = data
+	s.putAt[key] = time.Now()
+	return nil
+}
+
+type nopSeekCloser struct {
+	io.ReadSeeker
+}
+
+func (nopSeekCloser) Close() error {
+	return nil
+}
+
+func (s *fakeBlobStore) Open(key string) (io.ReadSeekCloser, error) {
+	data, ok := s.contents[key]
+	if !ok {
+		return nil, wrapNotFound()
+	}
+	return nopSeekCloser{bytes.NewReader(data)}, nil
+}
+
+func (s *fakeBlobStore) List(putBefore time.Time) ([]string, error) {
+	var keys []string
+	for key, putAt := range s.putAt {
+		if putAt.Before(putBefore) {
+			keys = append(keys, key)
+		}
+	}
+	return keys, nil
+}
+
+func (s *fakeBlobStore) Delete(key string, putBefore time.Time) error {
+	if s.putAt[key].Before(putBefore) {
+		delete(s.contents, key)
+		delete(s.putAt, key)
+	}
+	return nil
+}
+
+func TestNewAttachmentPolicy(t *testing.T) {
+	samples := map[string]struct {
+		maxSize      int64
+		contentTypes []string
+	}{
+		"zero size":              {maxSize: 0, contentTypes: []string{"text/plain"}},
+		"no content types":       {maxSize: 10, contentTypes: nil},
+		"malformed content type": {maxSize: 10, contentTypes: []string{"text"}},
+	}
+	for name, sample := range samples {
+		sample := sample
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			_, err := task.NewAttachmentPolicy(sample.maxSize, sample.contentTypes)
+			require.ErrorIs(t, err, task.ErrValidation)
+		})
+	}
+}
+
+func TestAttachmentService(t *testing.T) {
+	png := []byte("\x89PNG\x0D\x0A\x1A\x0Afake image")
+	setup := func(t *testing.T) (*task.AttachmentService, *fakeBlobStore) {
+		tasks := newFakeRepository()
+		for _, title := range []string{"first", "second"} {
+			command, err := task.NewAddTaskCommand(title, "", nil, nil, nil, nil, nil, nil)
+			require.NoError(t, err, "command")
+			_, err = tasks.Add(command, noRecord)
+			require.NoError(t, err, "add task")
+		}
+		policy, err := task.NewAttachmentPolicy(32, []string{"image/*", "text/plain"})
+		require.NoError(t, err, "policy")
+		blobs := newFakeBlobStore()
+		return task.NewAttachmentService(newFakeAttachmentRepository(), tasks, blobs, policy), blobs
+	}
+
+	t.Run("AddAttachment", func(t *testing.T) {
+		t.Run("stores content under its hash", func(t *testing.T) {
+			t.Parallel()
+			service, blobs := setup(t)
+
+			result, created, err := service.AddAttachment(1, `C:\Users\alice\image.png`, bytes.NewReader(png))
+			require.NoError(t, err, "unexpected error")
+			require.True(t, created)
+			require.Equal(t, "image.png", result.FileName())
+			require.Equal(t, "image/png", result.ContentType())
+			require.Equal(t, int64(len(png)), result.Size())
+			require.Len(t, result.Hash(), 64)
+			require.Equal(t, png, blobs.contents[result.Hash()])
+		})
+		t.Run("returns existing attachment with the same content", func(t *testing.T) {
+			t.Parallel()
+			service, _ := setup(t)
+			first, _, err := service.AddAttachment(1, "image.png", bytes.NewReader(png))
+			require.NoError(t, err, "first upload")
+
+			result, created, err := service.AddAttachment(1, "copy.png", bytes.NewReader(png))
+			require.NoError(t, err, "unexpected error")
+			require.False(t, created)
+			require.Equal(t, first, result)
+		})
+		t.Run("rejects too large content", func(t *testing.T) {
+			t.Parallel()
+			service, blobs := setup(t)
+
+			_, _, err := service.AddAttachment(1, "notes.txt", strings.NewReader(strings.Repeat("a", 33)))
+			require.ErrorIs(t, err, task.ErrAttachmentTooLarge)
+			require.Empty(t, blobs.contents)
+		})
+		t.Run("rejects content type which is not allowed", func(t *testing.T) {
+			t.Parallel()
+			service, _ := setup(t)
+
+			_, _, err := service.AddAttachment(1, "notes.pdf", strings.NewReader("%PDF-1.7"))
+			require.ErrorIs(t, err, task.ErrContentTypeNotAllowed)
+		})
+		t.Run("returns ErrNotFound for missing task", func(t *testing.T) {
+			t.Parallel()
+			service, _ := setup(t)
+
+			_, _, err := service.AddAttachment(3, "image.png", bytes.NewReader(png))
+			require.Equal(t, task.ErrNotFound, err)
+		})
+	})
+	t.Run("OpenAttachment returns content", func(t *testing.T) {
+		t.Parallel()
+		service, _ := setup(t)
+		attachment, _, err := service.AddAttachment(1, "image.png", bytes.NewReader(png))
+		require.NoError(t, err, "upload")
+
+		_, content, err := service.OpenAttachment(1, attachment.Id())
+		require.NoError(t, err, "unexpected error")
+		defer content.Close()
+		data, err := io.ReadAll(content)
+		require.NoError(t, err, "read content")
+		require.Equal(t, png, data)
+
+		_, _, err = service.OpenAttachment(2, attachment.Id())
+		require.Equal(t, task.ErrNotFound, err, "attachment of another task")
+	})
+	t.Run("SweepBlobs removes content no longer attached", func(t *testing.T) {
+		t.Parallel()
+		service, blobs := setup(t)
+		first, _, err := service.AddAttachment(1, "image.png", bytes.NewReader(png))
+		require.NoError(t, err, "first upload")
+		second, _, err := service.AddAttachment(2, "image.png", bytes.NewReader(png))
+		require.NoError(t, err, "second upload")
+
+		err = service.DeleteAttachment(1, first.Id())
+		require.NoError(t, err, "unexpected error")
+		count, err := service.SweepBlobs(time.Now().Add(time.Second))
+		require.NoError(t, err, "unexpected error")
+		require.Equal(t, 0, count)
+		require.Contains(t, blobs.contents, first.Hash(), "content shared with other tasks is expected to stay")
+
+		err = service.DeleteAttachment(2, second.Id())
+		require.NoError(t, err, "unexpected error")
+		count, err = service.SweepBlobs(time.Now().Add(-time.Hour))
+		require.NoError(t, err, "unexpected error")
+		require.Equal(t, 0, count)
+		require.Contains(t, blobs.contents, first.Hash(), "content put recently is expected to stay")
+		count, err = service.SweepBlobs(time.Now().Add(time.Second))
+		require.NoError(t, err, "unexpected error")
+		require.Equal(t, 1, count)
+		require.NotContains(t, blobs.contents, first.Hash())
+
+		err = service.DeleteAttachment(2, second.Id())
+		require.Equal(t, task.ErrNotFound, err, "deleted twice")
+	})
+}
@@ -0,0 +1,189 @@
+package task
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf8"
+)
+
+const (
+	checklistItemMaxLength = 500
+	// checklistMaxItems keeps checklists short, longer pieces of work deserve subtasks.
+	checklistMaxItems = 100
+)
+
+// ChecklistItemID is unique within the task only.
+type ChecklistItemID uint
+
+// ChecklistItem is a single step of a task, too small to be a subtask.
+// Items are kept in order, with positions starting at 0.
+type ChecklistItem struct {
+	id       ChecklistItemID
+	text     string
+	done     bool
+	position int
+}
+
+// NewChecklistItem is meant for repositories restoring tasks. Position is given by the order of items in Snapshot.
+func NewChecklistItem(id ChecklistItemID, text string, done bool) ChecklistItem {
+	return ChecklistItem{id: id, text: text, done: done}
+}
+
+func (i ChecklistItem) Id() ChecklistItemID {
+	return i.id
+}
+
+func (i ChecklistItem) Text() string {
+	return i.text
+}
+
+func (i ChecklistItem) Done() bool {
+	return i.done
+}
+
+func (i ChecklistItem) Position() int {
+	return i.position
+}
+
+// AddChecklistItem appends the item to the end of the checklist.
+func (t *Task) AddChecklistItem(text string) (ChecklistItem, error) {
+	text, err := validateChecklistItemText(text)
+	if err != nil {
+		return ChecklistItem{}, err
+	}
+	if len(t.checklist) >= checklistMaxItems {
+		return ChecklistItem{}, fmt.Errorf(
+			"%w: checklist must not have more than %d items",
+			ErrValidation,
+			checklistMaxItems,
+		)
+	}
+
+	id := ChecklistItemID(1)
+	for _, item := range t.checklist {
+		if item.id >= id {
+			id = item.id + 1
+		}
+	}
+	item := ChecklistItem{id: id, text: text, position: len(t.checklist)}
+	t.checklist = append(copyChecklist(t.checklist), item)
+	t.touch()
+	return item, nil
+}
+
+// ToggleChecklistItem marks the item as done, or back as not done.
+func (t *Task) ToggleChecklistItem(id ChecklistItemID) (ChecklistItem, error) {
+	i, err := t.checklistItemIndex(id)
+	if err != nil {
+		return ChecklistItem{}, err
+	}
+	t.checklist = copyChecklist(t.checklist)
+	t.checklist[i].done = !t.checklist[i].done
+	t.touch()
+	return t.checklist[i], nil
+}
+
+// MoveChecklistItem puts the item at the position, shifting the items in between.
+func (t *Task) MoveChecklistItem(id ChecklistItemID, position int) error {
+	i, err := t.checklistItemIndex(id)
+	if err != nil {
+		return err
+	}
+	if position < 0 || position >= len(t.checklist) {
+		return fmt.Errorf("%w: position must be from 0 to %d", ErrValidation, len(t.checklist)-1)
+	}
+
+	items := make([]ChecklistItem, 0, len(t.checklist))
+	for j, other := range t.checklist {
+		if j != i {
+			items = append(items, other)
+		}
+	}
+	items = append(items[:position], append([]ChecklistItem{t.checklist[i]}, items[position:]...)...)
+	renumberChecklist(items)
+	t.checklist = items
+	t.touch()
+	return nil
+}
+
+func (t *Task) RemoveChecklistItem(id ChecklistItemID) error {
+	i, err := t.checklistItemIndex(id)
+	if err != nil {
+		return err
+	}
+	t.checklist = append(copyChecklist(t.checklist[:i]), t.checklist[i+1:]...)
+	renumberChecklist(t.checklist)
+	t.touch()
+	return nil
+}
+
+func (t Task) Checklist() []ChecklistItem {
+	return copyChecklist(t.checklist)
+}
+
+// ChecklistCompletion returns percentage of done items, rounded down, so it is 100 only when all are done.
+// It returns false when the checklist is empty.
+func (t Task) ChecklistCompletion() (int, bool) {
+	if len(t.checklist) == 0 {
+		return 0, false
+	}
+	done := 0
+	for _, item := range t.checklist {
+		if item.done {
+			done++
+		}
+	}
+	return done * 100 / len(t.checklist), true
+}
+
+func (t Task) checklistItemIndex(id ChecklistItemID) (int, error) {
+	for i, item := range t.checklist {
+		if item.id == id {
+			return i, nil
+		}
+	}
+	return 0, fmt.Errorf("%w: checklist item %d", ErrNotFound, id)
+}
+
+func validateChecklistItemText(text string) (string, error) {
+	text = strings.TrimSpace(text)
+	if text == "" {
+		return "", fmt.Errorf("%w: checklist item must not be empty", ErrValidation)
+	}
+	if utf8.RuneCountInString(text) > checklistItemMaxLength {
+		return "", fmt.Errorf(
+			"%w: checklist item must not be longer than %d characters",
+			ErrValidation,
+			checklistItemMaxLength,
+		)
+	}
+	return text, nil
+}
+
+// copyChecklist is used before changing items, as copies of the task share them.
+// It also sets positions, so items restored from Snapshot do not need to have them.
+func copyChecklist(items []ChecklistItem) []ChecklistItem {
+	result := make([]ChecklistItem, len(items))
+	copy(result, items)
+	renumberChecklist(result)
+	return result
+}
+
+func renumberChecklist(items []ChecklistItem) {
+	for i := range items {
+		items[i].position = i
+	}
+}
+
+// checklistLines describes the checklist in the history, e.g. "[x] Buy milk".
+func checklistLines(items []ChecklistItem) []string {
+	result := make([]string, len(items))
+	for i, item := range items {
+		mark := "[ ]"
+		if item.done {
+			mark = "[x]"
+		}
+		result[i] = mark + " " + item.text
+	}
+	return result
+}
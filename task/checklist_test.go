@@ -0,0 +1,105 @@
+package task_test
+
+import (
+	"demo-app-go/task"
+	"github.com/stretchr/testify/require"
+	"strings"
+	"testing"
+)
+
+func TestTask_Checklist(t *testing.T) {
+	setup := func(t *testing.T, texts ...string) task.Task {
+		entity := task.NewTask(task.Snapshot{Id: 1, Title: "task", Status: task.StatusTodo})
+		for _, text := range texts {
+			_, err := entity.AddChecklistItem(text)
+			require.NoError(t, err, "add item")
+		}
+		return entity
+	}
+	texts := func(entity task.Task) []string {
+		result := []string{}
+		for i, item := range entity.Checklist() {
+			require.Equal(t, i, item.Position(), "position of %q", item.Text())
+			result = append(result, item.Text())
+		}
+		return result
+	}
+
+	t.Run("AddChecklistItem appends item", func(t *testing.T) {
+		t.Parallel()
+		entity := setup(t, "first")
+
+		item, err := entity.AddChecklistItem("  second ")
+		require.NoError(t, err, "unexpected error")
+		require.Equal(t, task.ChecklistItemID(2), item.Id())
+		require.Equal(t, "second", item.Text())
+		require.False(t, item.Done())
+		require.Equal(t, []string{"first", "second"}, texts(entity))
+	})
+	t.Run("AddChecklistItem rejects invalid text", func(t *testing.T) {
+		t.Parallel()
+		entity := setup(t)
+
+		for _, text := range []string{" ", strings.Repeat("a", 501)} {
+			_, err := entity.AddChecklistItem(text)
+			require.ErrorIs(t, err, task.ErrValidation)
+		}
+	})
+	t.Run("ToggleChecklistItem changes completion", func(t *testing.T) {
+		t.Parallel()
+		entity := setup(t, "first", "second", "third")
+		_, ok := setup(t).ChecklistCompletion()
+		require.False(t, ok, "empty checklist")
+
+		item, err := entity.ToggleChecklistItem(2)
+		require.NoError(t, err, "unexpected error")
+		require.True(t, item.Done())
+		completion, ok := entity.ChecklistCompletion()
+		require.True(t, ok)
+		require.Equal(t, 33, completion)
+
+		item, err = entity.ToggleChecklistItem(2)
+		require.NoError(t, err, "unexpected error")
+		require.False(t, item.Done())
+
+		_, err = entity.ToggleChecklistItem(4)
+		require.ErrorIs(t, err, task.ErrNotFound)
+	})
+	t.Run("MoveChecklistItem shifts items in between", func(t *testing.T) {
+		t.Parallel()
+		entity := setup(t, "a", "b", "c", "d")
+
+		err := entity.MoveChecklistItem(1, 2)
+		require.NoError(t, err, "unexpected error")
+		require.Equal(t, []string{"b", "c", "a", "d"}, texts(entity))
+		err = entity.MoveChecklistItem(4, 0)
+		require.NoError(t, err, "unexpected error")
+		require.Equal(t, []string{"d", "b", "c", "a"}, texts(entity))
+
+		err = entity.MoveChecklistItem(1, 4)
+		require.ErrorIs(t, err, task.ErrValidation)
+	})
+	t.Run("RemoveChecklistItem keeps IDs of other items", func(t *testing.T) {
+		t.Parallel()
+		entity := setup(t, "a", "b", "c")
+
+		err := entity.RemoveChecklistItem(2)
+		require.NoError(t, err, "unexpected error")
+		require.Equal(t, []string{"a", "c"}, texts(entity))
+		item, err := entity.AddChecklistItem("d")
+		require.NoError(t, err, "add item")
+		require.Equal(t, task.ChecklistItemID(4), item.Id())
+	})
+	t.Run("changes do not affect copies of the task", func(t *testing.T) {
+		t.Parallel()
+		entity := setup(t, "a", "b")
+		copied := entity
+
+		_, err := entity.ToggleChecklistItem(1)
+		require.NoError(t, err, "toggle")
+		err = entity.MoveChecklistItem(1, 1)
+		require.NoError(t, err, "move")
+		require.Equal(t, []string{"a", "b"}, texts(copied))
+		require.False(t, copied.Checklist()[0].Done())
+	})
+}
@@ -0,0 +1,101 @@
+package task
+
+import "time"
+
+// CloneOptions chooses what is copied besides the title, due date, recurrence and placement of the task.
+type CloneOptions struct {
+	Description bool
+	Tags        bool
+	// Checklist is copied with all items not done.
+	Checklist bool
+	// Subtasks copies the whole subtree, applying the other options to each subtask. Tasks in the trash are left out.
+	Subtasks    bool
+	Comments    bool
+	Attachments bool
+}
+
+// Clone is the copy of a task, along with ids of all copies keyed by ids of the tasks they were copied from.
+type Clone struct {
+	tree Tree
+	ids  map[ID]ID
+}
+
+func (c Clone) Tree() Tree {
+	return c.tree
+}
+
+func (c Clone) IDs() map[ID]ID {
+	result := make(map[ID]ID, len(c.ids))
+	for from, to := range c.ids {
+		result[from] = to
+	}
+	return result
+}
+
+// CloneTask copies the task under the same parent and into the same column, as a new task ranked after all others.
+// Copies start in StatusTodo, regardless of the status of the originals.
+func (s *Service) CloneTask(id ID, options CloneOptions, actor string) (Clone, error) {
+	tree, err := s.GetTaskTree(id)
+	if err != nil {
+		return Clone{}, err
+	}
+	if !options.Subtasks {
+		tree = NewTree(tree.task, nil)
+	}
+
+	result, err := s.addTree(cloneTree(tree, options, time.Now()), actor)
+	if err != nil {
+		return Clone{}, err
+	}
+	ids := map[ID]ID{}
+	mapClones(tree, result, ids)
+
+	return Clone{tree: result, ids: ids}, nil
+}
+
+func cloneTree(tree Tree, options CloneOptions, now time.Time) AddTaskTreeCommand {
+	original := tree.task
+	command := AddTaskCommand{
+		title:      original.title,
+		status:     StatusTodo,
+		dueAt:      original.dueAt,
+		parentID:   original.parentID,
+		projectID:  original.projectID,
+		columnID:   original.columnID,
+		recurrence: original.recurrence,
+		createdAt:  now,
+	}
+	if options.Description {
+		command.description = original.description
+	}
+	if options.Tags {
+		command.tags = copyTags(original.tags)
+	}
+	if options.Checklist {
+		command.checklist = make([]ChecklistItem, len(original.checklist))
+		for i, item := range original.checklist {
+			command.checklist[i] = NewChecklistItem(item.id, item.text, false)
+		}
+	}
+
+	subtasks := make([]AddTaskTreeCommand, len(tree.subtasks))
+	for i, subtask := range tree.subtasks {
+		subtasks[i] = cloneTree(subtask, options, now)
+	}
+
+	return AddTaskTreeCommand{
+		task:            command,
+		subtasks:        subtasks,
+		source:          original.id,
+		copyComments:    options.Comments,
+		copyAttachments: options.Attachments,
+	}
+}
+
+// mapClones relies on repositories returning subtasks in the order of the command.
+func mapClones(original Tree, clone Tree, ids map[ID]ID) {
+	ids[original.task.id] = clone.task.id
+	for i, subtask := range original.subtasks {
+		mapClones(subtask, clone.subtasks[i], ids)
+	}
+}
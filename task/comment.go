@@ -0,0 +1,162 @@
+package task
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+const (
+	authorMaxLength      = 100
+	commentBodyMaxLength = 10000
+)
+
+// ErrNotAuthor is returned when someone else than the author changes the comment.
+var ErrNotAuthor = errors.New("only author can change the comment")
+
+type CommentID uint
+
+type Comment struct {
+	id        CommentID
+	taskID    ID
+	author    string
+	body      string
+	createdAt time.Time
+	editedAt  *time.Time
+}
+
+// CommentSnapshot holds the entire state of a Comment, just like Snapshot does for Task.
+type CommentSnapshot struct {
+	Id        CommentID
+	TaskID    ID
+	Author    string
+	Body      string
+	CreatedAt time.Time
+	EditedAt  *time.Time
+}
+
+func NewComment(snapshot CommentSnapshot) Comment {
+	return Comment{
+		id:        snapshot.Id,
+		taskID:    snapshot.TaskID,
+		author:    snapshot.Author,
+		body:      snapshot.Body,
+		createdAt: snapshot.CreatedAt,
+		editedAt:  snapshot.EditedAt,
+	}
+}
+
+// Edit replaces body of the comment. Only the author is allowed to do it.
+func (c *Comment) Edit(author string, body string) error {
+	err := c.checkAuthor(author)
+	if err != nil {
+		return err
+	}
+	body, err = validateCommentBody(body)
+	if err != nil {
+		return err
+	}
+
+	c.body = body
+	now := time.Now()
+	c.editedAt = &now
+	return nil
+}
+
+func (c Comment) checkAuthor(author string) error {
+	if strings.TrimSpace(author) != c.author {
+		return ErrNotAuthor
+	}
+	return nil
+}
+
+func (c Comment) Id() CommentID {
+	return c.id
+}
+
+func (c Comment) TaskID() ID {
+	return c.taskID
+}
+
+func (c Comment) Author() string {
+	return c.author
+}
+
+func (c Comment) Body() string {
+	return c.body
+}
+
+func (c Comment) CreatedAt() time.Time {
+	return c.createdAt
+}
+
+// EditedAt is set once the body was changed.
+func (c Comment) EditedAt() *time.Time {
+	return c.editedAt
+}
+
+// AddCommentCommand is used for creating new Comment. Like AddTaskCommand, it cannot be changed once created.
+type AddCommentCommand struct {
+	taskID    ID
+	author    string
+	body      string
+	createdAt time.Time
+}
+
+func NewAddCommentCommand(taskID ID, author string, body string) (AddCommentCommand, error) {
+	author = strings.TrimSpace(author)
+	if author == "" {
+		return AddCommentCommand{}, fmt.Errorf("%w: author must not be empty", ErrValidation)
+	}
+	if utf8.RuneCountInString(author) > authorMaxLength {
+		return AddCommentCommand{}, fmt.Errorf(
+			"%w: author must not be longer than %d characters",
+			ErrValidation,
+			authorMaxLength,
+		)
+	}
+	body, err := validateCommentBody(body)
+	if err != nil {
+		return AddCommentCommand{}, err
+	}
+
+	return AddCommentCommand{
+		taskID:    taskID,
+		author:    author,
+		body:      body,
+		createdAt: time.Now(),
+	}, nil
+}
+
+func (a AddCommentCommand) TaskID() ID {
+	return a.taskID
+}
+
+func (a AddCommentCommand) Author() string {
+	return a.author
+}
+
+func (a AddCommentCommand) Body() string {
+	return a.body
+}
+
+func (a AddCommentCommand) CreatedAt() time.Time {
+	return a.createdAt
+}
+
+func validateCommentBody(body string) (string, error) {
+	body = strings.TrimSpace(body)
+	if body == "" {
+		return "", fmt.Errorf("%w: comment must not be empty", ErrValidation)
+	}
+	if utf8.RuneCountInString(body) > commentBodyMaxLength {
+		return "", fmt.Errorf(
+			"%w: comment must not be longer than %d characters",
+			ErrValidation,
+			commentBodyMaxLength,
+		)
+	}
+	return body, nil
+}
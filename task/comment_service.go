@@ -0,0 +1,82 @@
+package task
+
+// commentRepository is expected to return an error matching ErrNotFound when the comment does not exist.
+type commentRepository interface {
+	// List returns a page of the task's comments, oldest first, along with the count of all of them.
+	List(taskID ID, page Page) ([]Comment, int, error)
+	GetByID(id CommentID) (Comment, error)
+	Add(addComment AddCommentCommand) (Comment, error)
+	Save(comment Comment) error
+	Delete(id CommentID) error
+}
+
+// CommentService manages comments under tasks. Comments are always addressed through their task.
+type CommentService struct {
+	repository commentRepository
+	tasks      taskRepository
+}
+
+func NewCommentService(repository commentRepository, tasks taskRepository) *CommentService {
+	return &CommentService{repository: repository, tasks: tasks}
+}
+
+func (s *CommentService) ListComments(taskID ID, page Page) ([]Comment, int, error) {
+	_, err := s.tasks.GetByID(taskID)
+	if err != nil {
+		return nil, 0, notFound(err)
+	}
+
+	return s.repository.List(taskID, page)
+}
+
+// GetComment reports ErrNotFound also when the comment belongs to another task.
+func (s *CommentService) GetComment(taskID ID, id CommentID) (Comment, error) {
+	comment, err := s.repository.GetByID(id)
+	if err != nil {
+		return Comment{}, notFound(err)
+	}
+	if comment.TaskID() != taskID {
+		return Comment{}, ErrNotFound
+	}
+
+	return comment, nil
+}
+
+func (s *CommentService) AddComment(command AddCommentCommand) (Comment, error) {
+	_, err := s.tasks.GetByID(command.TaskID())
+	if err != nil {
+		return Comment{}, notFound(err)
+	}
+
+	return s.repository.Add(command)
+}
+
+func (s *CommentService) EditComment(taskID ID, id CommentID, author string, body string) (Comment, error) {
+	comment, err := s.GetComment(taskID, id)
+	if err != nil {
+		return Comment{}, err
+	}
+	err = comment.Edit(author, body)
+	if err != nil {
+		return Comment{}, err
+	}
+	err = s.repository.Save(comment)
+	if err != nil {
+		return Comment{}, notFound(err)
+	}
+
+	return comment, nil
+}
+
+func (s *CommentService) DeleteComment(taskID ID, id CommentID, author string) error {
+	comment, err := s.GetComment(taskID, id)
+	if err != nil {
+		return err
+	}
+	err = comment.checkAuthor(author)
+	if err != nil {
+		return err
+	}
+
+	return notFound(s.repository.Delete(id))
+}
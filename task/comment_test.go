@@ -0,0 +1,204 @@
+package task_test
+
+import (
+	"demo-app-go/task"
+	"github.com/stretchr/testify/require"
+	"strings"
+	"testing"
+)
+
+// fakeCommentRepository keeps comments in a map and fails the same way as storage.CommentRepository.
+type fakeCommentRepository struct {
+	comments map[task.CommentID]task.Comment
+	nextID   task.CommentID
+}
+
+func newFakeCommentRepository() *fakeCommentRepository {
+	return &fakeCommentRepository{comments: map[task.CommentID]task.Comment{}, nextID: 1}
+}
+
+func (r *fakeCommentRepository) List(taskID task.ID, page task.Page) ([]task.Comment, int, error) {
+	var all []task.Comment
+	for id := task.CommentID(1); id < r.nextID; id++ {
+		if comment, ok := r.comments[id]; ok && comment.TaskID() == taskID {
+			all = append(all, comment)
+		}
+	}
+	result := []task.Comment{}
+	for i := page.Offset(); i < len(all) && i < page.Offset()+page.Limit(); i++ {
+		result = append(result, all[i])
+	}
+	return result, len(all), nil
+}
+
+func (r *fakeCommentRepository) GetByID(id task.CommentID) (task.Comment, error) {
+	comment, ok := r.comments[id]
+	if !ok {
+		return task.Comment{}, wrapNotFound()
+	}
+	return comment, nil
+}
+
+func (r *fakeCommentRepository) Add(addComment task.AddCommentCommand) (task.Comment, error) {
+	comment := task.NewComment(task.CommentSnapshot{
+		Id:        r.nextID,
+		TaskID:    addComment.TaskID(),
+		Author:    addComment.Author(),
+		Body:      addComment.Body(),
+		CreatedAt: addComment.CreatedAt(),
+	})
+	r.comments[comment.Id()] = comment
+	r.nextID++
+	return comment, nil
+}
+
+func (r *fakeCommentRepository) Save(comment task.Comment) error {
+	if _, ok := r.comments[comment.Id()]; !ok {
+		return wrapNotFound()
+	}
+	r.comments[comment.Id()] = comment
+	return nil
+}
+
+func (r *fakeCommentRepository) Delete(id task.CommentID) error {
+	if _, ok := r.comments[id]; !ok {
+		return wrapNotFound()
+	}
+	delete(r.comments, id)
+	return nil
+}
+
+func TestNewAddCommentCommand(t *testing.T) {
+	t.Run("trims author and body", func(t *testing.T) {
+		command, err := task.NewAddCommentCommand(1, " alice ", "  looks good\n")
+		require.NoError(t, err, "unexpected error")
+		require.Equal(t, "alice", command.Author())
+		require.Equal(t, "looks good", command.Body())
+	})
+	t.Run("fails at validation", func(t *testing.T) {
+		samples := map[string]struct {
+			author string
+			body   string
+		}{
+			"empty author":    {author: " ", body: "body"},
+			"too long author": {author: strings.Repeat("a", 101), body: "body"},
+			"empty body":      {author: "alice", body: "\n\t"},
+			"too long body":   {author: "alice", body: strings.Repeat("ż", 10001)},
+		}
+		for name, sample := range samples {
+			sample := sample
+			t.Run(name, func(t *testing.T) {
+				t.Parallel()
+				_, err := task.NewAddCommentCommand(1, sample.author, sample.body)
+				require.ErrorIs(t, err, task.ErrValidation)
+			})
+		}
+	})
+}
+
+func TestCommentService(t *testing.T) {
+	setup := func(t *testing.T) *task.CommentService {
+		tasks := newFakeRepository()
+		for _, title := range []string{"first", "second"} {
+			command, err := task.NewAddTaskCommand(title, "", nil, nil, nil, nil, nil, nil)
+			require.NoError(t, err, "command")
+			_, err = tasks.Add(command, noRecord)
+			require.NoError(t, err, "add task")
+		}
+		service := task.NewCommentService(newFakeCommentRepository(), tasks)
+		for _, body := range []string{"one", "two", "three"} {
+			command, err := task.NewAddCommentCommand(1, "alice", body)
+			require.NoError(t, err, "command")
+			_, err = service.AddComment(command)
+			require.NoError(t, err, "add comment")
+		}
+		return service
+	}
+
+	t.Run("ListComments", func(t *testing.T) {
+		t.Run("returns page and total count", func(t *testing.T) {
+			t.Parallel()
+			service := setup(t)
+			page, err := task.NewPage(1, 1)
+			require.NoError(t, err, "page")
+
+			result, total, err := service.ListComments(1, page)
+			require.NoError(t, err, "unexpected error")
+			require.Equal(t, 3, total)
+			require.Len(t, result, 1)
+			require.Equal(t, "two", result[0].Body())
+		})
+		t.Run("returns ErrNotFound for missing task", func(t *testing.T) {
+			t.Parallel()
+			service := setup(t)
+			page, err := task.NewPage(0, task.DefaultPageLimit)
+			require.NoError(t, err, "page")
+
+			_, _, err = service.ListComments(3, page)
+			require.Equal(t, task.ErrNotFound, err)
+		})
+	})
+	t.Run("GetComment returns ErrNotFound for comment of another task", func(t *testing.T) {
+		t.Parallel()
+		service := setup(t)
+
+		_, err := service.GetComment(2, 1)
+		require.Equal(t, task.ErrNotFound, err)
+	})
+	t.Run("AddComment returns ErrNotFound for missing task", func(t *testing.T) {
+		t.Parallel()
+		service := setup(t)
+		command, err := task.NewAddCommentCommand(3, "alice", "hello")
+		require.NoError(t, err, "command")
+
+		_, err = service.AddComment(command)
+		require.Equal(t, task.ErrNotFound, err)
+	})
+	t.Run("EditComment", func(t *testing.T) {
+		t.Run("changes body and marks comment as edited", func(t *testing.T) {
+			t.Parallel()
+			service := setup(t)
+
+			result, err := service.EditComment(1, 2, "alice", " changed ")
+			require.NoError(t, err, "unexpected error")
+			require.Equal(t, "changed", result.Body())
+			require.NotNil(t, result.EditedAt())
+
+			saved, err := service.GetComment(1, 2)
+			require.NoError(t, err, "unexpected error")
+			require.Equal(t, result, saved)
+		})
+		t.Run("rejects someone else than the author", func(t *testing.T) {
+			t.Parallel()
+			service := setup(t)
+
+			_, err := service.EditComment(1, 2, "bob", "changed")
+			require.Equal(t, task.ErrNotAuthor, err)
+		})
+	})
+	t.Run("DeleteComment", func(t *testing.T) {
+		t.Run("removes comment", func(t *testing.T) {
+			t.Parallel()
+			service := setup(t)
+
+			err := service.DeleteComment(1, 2, "alice")
+			require.NoError(t, err, "unexpected error")
+			_, err = service.GetComment(1, 2)
+			require.Equal(t, task.ErrNotFound, err)
+		})
+		t.Run("rejects someone else than the author", func(t *testing.T) {
+			t.Parallel()
+			service := setup(t)
+
+			err := service.DeleteComment(1, 2, "bob")
+			require.Equal(t, task.ErrNotAuthor, err)
+		})
+		t.Run("returns ErrNotFound", func(t *testing.T) {
+			t.Parallel()
+			service := setup(t)
+
+			err := service.DeleteComment(1, 4, "alice")
+			require.Equal(t, task.ErrNotFound, err)
+		})
+	})
+}
@@ -0,0 +1,142 @@
+package task
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+)
+
+var ErrBlocked = errors.New("task is blocked")
+
+// Dependency means that the blocker has to be finished, before the blocked task is started or completed.
+type Dependency struct {
+	blockerID ID
+	blockedID ID
+}
+
+func NewDependency(blockerID ID, blockedID ID) (Dependency, error) {
+	if blockerID == blockedID {
+		return Dependency{}, fmt.Errorf("%w: task cannot block itself", ErrValidation)
+	}
+	return Dependency{blockerID: blockerID, blockedID: blockedID}, nil
+}
+
+func (d Dependency) BlockerID() ID {
+	return d.blockerID
+}
+
+func (d Dependency) BlockedID() ID {
+	return d.blockedID
+}
+
+// DependencyGraph answers questions about dependencies between tasks.
+type DependencyGraph struct {
+	blockers   map[ID][]ID
+	dependents map[ID][]ID
+}
+
+func NewDependencyGraph(dependencies []Dependency) DependencyGraph {
+	graph := DependencyGraph{blockers: map[ID][]ID{}, dependents: map[ID][]ID{}}
+	for _, dependency := range dependencies {
+		graph.blockers[dependency.blockedID] = append(graph.blockers[dependency.blockedID], dependency.blockerID)
+		graph.dependents[dependency.blockerID] = append(graph.dependents[dependency.blockerID], dependency.blockedID)
+	}
+	return graph
+}
+
+// Blockers lists tasks blocking the given one.
+func (g DependencyGraph) Blockers(id ID) []ID {
+	return g.blockers[id]
+}
+
+// Dependents lists tasks blocked by the given one.
+func (g DependencyGraph) Dependents(id ID) []ID {
+	return g.dependents[id]
+}
+
+func (g DependencyGraph) Contains(dependency Dependency) bool {
+	for _, blocker := range g.blockers[dependency.blockedID] {
+		if blocker == dependency.blockerID {
+			return true
+		}
+	}
+	return false
+}
+
+// validate rejects dependency which would close a cycle, so that no task could ever be started.
+// It is the case, when the blocked task already blocks the blocker, directly or not.
+func (g DependencyGraph) validate(dependency Dependency) error {
+	visited := map[ID]bool{}
+	queue := []ID{dependency.blockedID}
+	for len(queue) > 0 {
+		current := queue[0]
+		queue = queue[1:]
+		if current == dependency.blockerID {
+			return fmt.Errorf(
+				"%w: task %d already depends on task %d, dependency would create a cycle",
+				ErrValidation,
+				dependency.blockerID,
+				dependency.blockedID,
+			)
+		}
+		if visited[current] {
+			continue
+		}
+		visited[current] = true
+		queue = append(queue, g.dependents[current]...)
+	}
+	return nil
+}
+
+// validateBlockers rejects starting or completing a task, while any of its blockers is open.
+func validateBlockers(status Status, blockers []Task) error {
+	if status != StatusInProgress && status != StatusDone {
+		return nil
+	}
+	for _, blocker := range blockers {
+		if blocker.Status().IsOpen() {
+			return fmt.Errorf("%w: task %d is not finished yet", ErrBlocked, blocker.Id())
+		}
+	}
+	return nil
+}
+
+// Sort orders tasks topologically, so that each task comes after all of its blockers.
+// Dependencies on tasks outside the given list are ignored. Ties are resolved by task id.
+func (g DependencyGraph) Sort(tasks []Task) []Task {
+	byID := make(map[ID]Task, len(tasks))
+	for _, entity := range tasks {
+		byID[entity.Id()] = entity
+	}
+	pending := make(map[ID]int, len(tasks))
+	var ready []ID
+	for _, entity := range tasks {
+		for _, blockerID := range g.blockers[entity.Id()] {
+			if _, ok := byID[blockerID]; ok {
+				pending[entity.Id()]++
+			}
+		}
+		if pending[entity.Id()] == 0 {
+			ready = append(ready, entity.Id())
+		}
+	}
+
+	result := make([]Task, 0, len(tasks))
+	for len(ready) > 0 {
+		sort.Slice(ready, func(i, j int) bool { return ready[i] < ready[j] })
+		current := ready[0]
+		ready = ready[1:]
+		result = append(result, byID[current])
+		for _, dependentID := range g.dependents[current] {
+			if _, ok := byID[dependentID]; !ok {
+				continue
+			}
+			pending[dependentID]--
+			if pending[dependentID] == 0 {
+				ready = append(ready, dependentID)
+			}
+		}
+	}
+
+	return result
+}
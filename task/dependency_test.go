@@ -0,0 +1,50 @@
+package task_test
+
+import (
+	"demo-app-go/task"
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+func TestNewDependency(t *testing.T) {
+	t.Run("create successfully", func(t *testing.T) {
+		t.Parallel()
+		result, err := task.NewDependency(1, 2)
+		require.NoError(t, err, "unexpected error")
+		require.Equal(t, task.ID(1), result.BlockerID())
+		require.Equal(t, task.ID(2), result.BlockedID())
+	})
+	t.Run("task cannot block itself", func(t *testing.T) {
+		t.Parallel()
+		_, err := task.NewDependency(1, 1)
+		require.ErrorIs(t, err, task.ErrValidation)
+	})
+}
+
+func TestDependencyGraph_Sort(t *testing.T) {
+	dependency := func(blockerID task.ID, blockedID task.ID) task.Dependency {
+		result, err := task.NewDependency(blockerID, blockedID)
+		require.NoError(t, err, "dependency")
+		return result
+	}
+	tasks := make([]task.Task, 5)
+	for i := range tasks {
+		tasks[i] = task.NewTask(task.Snapshot{Id: task.ID(i + 1), Title: "title", Status: task.StatusTodo, CreatedAt: time.Now()})
+	}
+	graph := task.NewDependencyGraph([]task.Dependency{
+		dependency(4, 1),
+		dependency(5, 4),
+		dependency(2, 3),
+		// Tasks outside sorted list are ignored.
+		dependency(1337, 2),
+	})
+
+	result := graph.Sort(tasks)
+
+	ids := make([]task.ID, len(result))
+	for i, entity := range result {
+		ids[i] = entity.Id()
+	}
+	require.Equal(t, []task.ID{2, 3, 5, 4, 1}, ids)
+}
@@ -0,0 +1,61 @@
+package task
+
+import (
+	"demo-app-go/eventbus"
+	"strconv"
+	"time"
+)
+
+// TaskCreated is published when a task is added, including the next occurrence of a recurring task.
+type TaskCreated struct {
+	Task  Task
+	Actor string
+	At    time.Time
+}
+
+func (e TaskCreated) Key() string {
+	return eventKey(e.Task.Id())
+}
+
+// TaskUpdated is published when a saved change modifies any field of the task, including restoring it from the trash.
+type TaskUpdated struct {
+	Task    Task
+	Changes []FieldChange
+	Actor   string
+	At      time.Time
+}
+
+func (e TaskUpdated) Key() string {
+	return eventKey(e.Task.Id())
+}
+
+// TaskDeleted is published when a task is moved to the trash, and again with Permanent set when it is purged.
+// Purging is not attributed to anyone, so Actor is empty then.
+type TaskDeleted struct {
+	TaskID    ID
+	Permanent bool
+	Actor     string
+	At        time.Time
+}
+
+func (e TaskDeleted) Key() string {
+	return eventKey(e.TaskID)
+}
+
+// eventKey keeps events of a single task in order.
+func eventKey(id ID) string {
+	return "task/" + strconv.FormatUint(uint64(id), 10)
+}
+
+// newRevisionEvent describes the saved revision, or returns nil when it changed nothing.
+func newRevisionEvent(revision Revision, task Task) eventbus.Event {
+	for _, change := range revision.Changes() {
+		if change.Field() == "deleted" && revision.State().Deleted {
+			return TaskDeleted{TaskID: task.Id(), Actor: revision.Actor(), At: revision.CreatedAt()}
+		}
+	}
+	if len(revision.Changes()) == 0 {
+		return nil
+	}
+	return TaskUpdated{Task: task, Changes: revision.Changes(), Actor: revision.Actor(), At: revision.CreatedAt()}
+}
@@ -0,0 +1,79 @@
+package task
+
+import "time"
+
+// ListFilter narrows down the tasks returned by the repository. Zero value matches all tasks, except deleted ones.
+type ListFilter struct {
+	Statuses []Status
+	// DueBefore and DueAfter are exclusive. Tasks without due date never match them.
+	DueBefore *time.Time
+	DueAfter  *time.Time
+	// OverdueAt matches open tasks which are past their due date at the given time.
+	OverdueAt *time.Time
+	// ParentID matches direct subtasks of the given task.
+	ParentID *ID
+	// ProjectID and ColumnID match tasks on the board of the project, or in the column only.
+	ProjectID *ProjectID
+	ColumnID  *ColumnID
+	// Tags matches tasks labeled with all of them, or any of them when AnyTag is set. Tags must be normalized.
+	Tags   []string
+	AnyTag bool
+	// Deleted matches tasks in the trash instead of the regular ones.
+	Deleted bool
+}
+
+// Matches reports whether the task satisfies the filter.
+// Repositories backed by a database are expected to translate the filter into a query instead.
+func (f ListFilter) Matches(task Task) bool {
+	if (task.DeletedAt() != nil) != f.Deleted {
+		return false
+	}
+	if len(f.Statuses) > 0 && !containsStatus(f.Statuses, task.Status()) {
+		return false
+	}
+	dueAt := task.DueAt()
+	if f.DueBefore != nil && (dueAt == nil || !dueAt.Before(*f.DueBefore)) {
+		return false
+	}
+	if f.DueAfter != nil && (dueAt == nil || !dueAt.After(*f.DueAfter)) {
+		return false
+	}
+	if f.OverdueAt != nil && !task.IsOverdue(*f.OverdueAt) {
+		return false
+	}
+	if f.ParentID != nil && (task.ParentID() == nil || *task.ParentID() != *f.ParentID) {
+		return false
+	}
+	if f.ProjectID != nil && (task.ProjectID() == nil || *task.ProjectID() != *f.ProjectID) {
+		return false
+	}
+	if f.ColumnID != nil && (task.ColumnID() == nil || *task.ColumnID() != *f.ColumnID) {
+		return false
+	}
+	if len(f.Tags) > 0 && !f.matchesTags(task) {
+		return false
+	}
+	return true
+}
+
+func containsStatus(statuses []Status, status Status) bool {
+	for _, s := range statuses {
+		if s == status {
+			return true
+		}
+	}
+	return false
+}
+
+func (f ListFilter) matchesTags(task Task) bool {
+	for _, tag := range f.Tags {
+		hasTag := task.HasTag(tag)
+		if f.AnyTag && hasTag {
+			return true
+		}
+		if !f.AnyTag && !hasTag {
+			return false
+		}
+	}
+	return !f.AnyTag
+}
@@ -0,0 +1,174 @@
+package task
+
+import (
+	"errors"
+	"fmt"
+)
+
+// MaxDepth limits how deeply subtasks can be nested. Task without parent is at depth 1.
+const MaxDepth = 5
+
+var ErrHasSubtasks = errors.New("task has subtasks")
+
+// DeletePolicy decides what happens to subtasks when their parent is deleted.
+type DeletePolicy string
+
+const (
+	// DeleteCascade deletes all subtasks along with the parent.
+	DeleteCascade DeletePolicy = "cascade"
+	// DeleteOrphan keeps subtasks, turning direct children into top level tasks.
+	DeleteOrphan DeletePolicy = "orphan"
+	// DeleteRefuse does not allow deleting task which has subtasks.
+	DeleteRefuse DeletePolicy = "refuse"
+)
+
+func ParseDeletePolicy(value string) (DeletePolicy, error) {
+	policy := DeletePolicy(value)
+	switch policy {
+	case DeleteCascade, DeleteOrphan, DeleteRefuse:
+		return policy, nil
+	default:
+		return "", fmt.Errorf("%w: unknown delete policy %q", ErrValidation, value)
+	}
+}
+
+// validateParent checks whether task with given id can be placed under the parent.
+// Ancestors start with the parent itself, followed by its parent and so on.
+// Height counts levels of the task's own subtree, so it is 1 for a task without subtasks.
+// New tasks have no id yet, so 0 is passed for them.
+func validateParent(id ID, ancestors []ID, height int) error {
+	for _, ancestor := range ancestors {
+		if id != 0 && ancestor == id {
+			return fmt.Errorf("%w: task cannot be its own subtask", ErrValidation)
+		}
+	}
+	if len(ancestors)+height > MaxDepth {
+		return fmt.Errorf("%w: subtasks cannot be nested deeper than %d levels", ErrValidation, MaxDepth)
+	}
+	return nil
+}
+
+// Tree is a task along with all of its subtasks.
+type Tree struct {
+	task     Task
+	subtasks []Tree
+}
+
+func NewTree(task Task, subtasks []Tree) Tree {
+	return Tree{task: task, subtasks: subtasks}
+}
+
+func (t Tree) Task() Task {
+	return t.task
+}
+
+func (t Tree) Subtasks() []Tree {
+	return t.subtasks
+}
+
+// Height counts levels of the tree, including the root.
+func (t Tree) Height() int {
+	height := 0
+	for _, subtask := range t.subtasks {
+		if h := subtask.Height(); h > height {
+			height = h
+		}
+	}
+	return height + 1
+}
+
+// Progress is a fraction of finished work, from 0 to 1.
+// Task with subtasks takes the average progress of its children, ignoring cancelled ones.
+// Otherwise, it is either done or not, based on its own status.
+func (t Tree) Progress() float64 {
+	total := 0.0
+	counted := 0
+	for _, subtask := range t.subtasks {
+		if subtask.task.Status() == StatusCancelled {
+			continue
+		}
+		total += subtask.Progress()
+		counted++
+	}
+	if counted > 0 {
+		return total / float64(counted)
+	}
+	if t.task.Status() == StatusDone {
+		return 1
+	}
+	return 0
+}
+
+// descendants lists all subtasks, the deepest ones first.
+func (t Tree) descendants() []Task {
+	var result []Task
+	for _, subtask := range t.subtasks {
+		result = append(result, subtask.descendants()...)
+		result = append(result, subtask.task)
+	}
+	return result
+}
+
+// AddTaskTreeCommand is used for adding a task along with its subtasks at once.
+// Parent of the root task is taken from its command, subtasks are put under their new parents by the repository.
+type AddTaskTreeCommand struct {
+	task     AddTaskCommand
+	subtasks []AddTaskTreeCommand
+	// source is the task being copied, 0 for tasks which are not copies.
+	source          ID
+	copyComments    bool
+	copyAttachments bool
+}
+
+func (a AddTaskTreeCommand) Task() AddTaskCommand {
+	return a.task
+}
+
+func (a AddTaskTreeCommand) Subtasks() []AddTaskTreeCommand {
+	return a.subtasks
+}
+
+// Source is the task the new one is a copy of, zero when it is not a copy.
+func (a AddTaskTreeCommand) Source() ID {
+	return a.source
+}
+
+// CopyComments tells the repository to copy comments of Source to the new task, as they were written.
+func (a AddTaskTreeCommand) CopyComments() bool {
+	return a.copyComments
+}
+
+// CopyAttachments tells the repository to copy attachments of Source to the new task.
+// The copies share content with the originals.
+func (a AddTaskTreeCommand) CopyAttachments() bool {
+	return a.copyAttachments
+}
+
+func (a AddTaskTreeCommand) height() int {
+	height := 0
+	for _, subtask := range a.subtasks {
+		if h := subtask.height(); h > height {
+			height = h
+		}
+	}
+	return height + 1
+}
+
+// size counts the task along with all of its subtasks.
+func (a AddTaskTreeCommand) size() int {
+	size := 1
+	for _, subtask := range a.subtasks {
+		size += subtask.size()
+	}
+	return size
+}
+
+// rank gives the tasks the ranks one after another in depth-first order, returning the ranks left.
+func (a *AddTaskTreeCommand) rank(ranks []Rank) []Rank {
+	a.task.rank = ranks[0]
+	ranks = ranks[1:]
+	for i := range a.subtasks {
+		ranks = a.subtasks[i].rank(ranks)
+	}
+	return ranks
+}
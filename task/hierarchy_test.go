@@ -0,0 +1,41 @@
+package task_test
+
+import (
+	"demo-app-go/task"
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+func TestTree_Progress(t *testing.T) {
+	leaf := func(status task.Status) task.Tree {
+		return task.NewTree(task.NewTask(task.Snapshot{Id: 1, Title: "leaf", Status: status, CreatedAt: time.Now()}), nil)
+	}
+	parent := func(subtasks ...task.Tree) task.Tree {
+		return task.NewTree(task.NewTask(task.Snapshot{Id: 1, Title: "parent", Status: task.StatusInProgress, CreatedAt: time.Now()}), subtasks)
+	}
+	samples := map[string]struct {
+		tree     task.Tree
+		expected float64
+	}{
+		"open task": {tree: leaf(task.StatusTodo), expected: 0},
+		"done task": {tree: leaf(task.StatusDone), expected: 1},
+		"half done": {tree: parent(leaf(task.StatusDone), leaf(task.StatusBlocked)), expected: 0.5},
+		"cancelled": {tree: parent(leaf(task.StatusDone), leaf(task.StatusCancelled)), expected: 1},
+		"all cancelled, own status counts": {
+			tree:     parent(leaf(task.StatusCancelled)),
+			expected: 0,
+		},
+		"nested": {
+			tree:     parent(leaf(task.StatusDone), parent(leaf(task.StatusDone), leaf(task.StatusTodo))),
+			expected: 0.75,
+		},
+	}
+	for name, sample := range samples {
+		sample := sample
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			require.Equal(t, sample.expected, sample.tree.Progress())
+		})
+	}
+}
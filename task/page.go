@@ -0,0 +1,32 @@
+package task
+
+import "fmt"
+
+const (
+	DefaultPageLimit = 20
+	MaxPageLimit     = 100
+)
+
+// Page selects a slice of a longer list.
+type Page struct {
+	offset int
+	limit  int
+}
+
+func NewPage(offset int, limit int) (Page, error) {
+	if offset < 0 {
+		return Page{}, fmt.Errorf("%w: offset must not be negative", ErrValidation)
+	}
+	if limit < 1 || limit > MaxPageLimit {
+		return Page{}, fmt.Errorf("%w: limit must be a number from 1 to %d", ErrValidation, MaxPageLimit)
+	}
+	return Page{offset: offset, limit: limit}, nil
+}
+
+func (p Page) Offset() int {
+	return p.offset
+}
+
+func (p Page) Limit() int {
+	return p.limit
+}
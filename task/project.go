@@ -0,0 +1,303 @@
+package task
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+const (
+	projectNameMaxLength        = 100
+	projectDescriptionMaxLength = 2000
+	columnNameMaxLength         = 50
+	maxColumns                  = 20
+)
+
+var (
+	// ErrColumnFull is returned when a task would exceed the limit of its column.
+	ErrColumnFull = errors.New("column is full")
+	// ErrHasTasks is returned when removing a project or column which tasks still belong to.
+	ErrHasTasks = errors.New("project has tasks")
+)
+
+type ProjectID uint
+
+type ColumnID uint
+
+// Project groups tasks on a board. Each task of the project is in one of its columns, which are ordered
+// the way work flows through them.
+type Project struct {
+	id          ProjectID
+	name        string
+	description string
+	columns     []Column
+	createdAt   time.Time
+	updatedAt   *time.Time
+}
+
+// ProjectSnapshot holds the entire state of a Project, just like Snapshot does for Task.
+type ProjectSnapshot struct {
+	Id          ProjectID
+	Name        string
+	Description string
+	// Columns are in board order.
+	Columns   []Column
+	CreatedAt time.Time
+	UpdatedAt *time.Time
+}
+
+func NewProject(snapshot ProjectSnapshot) Project {
+	return Project{
+		id:          snapshot.Id,
+		name:        snapshot.Name,
+		description: snapshot.Description,
+		columns:     copyColumns(snapshot.Columns),
+		createdAt:   snapshot.CreatedAt,
+		updatedAt:   snapshot.UpdatedAt,
+	}
+}
+
+// Update replaces name, description and columns of the project. Columns keep their identity by id,
+// the ones with zero id are added. Checking that removed columns are empty is up to ProjectService.
+func (p *Project) Update(command UpdateProjectCommand) error {
+	for _, column := range command.Columns() {
+		if _, ok := p.Column(column.Id()); column.Id() != 0 && !ok {
+			return fmt.Errorf("%w: column %d does not belong to project %d", ErrValidation, column.Id(), p.id)
+		}
+	}
+
+	p.name = command.Name()
+	p.description = command.Description()
+	p.columns = command.Columns()
+	now := time.Now()
+	p.updatedAt = &now
+	return nil
+}
+
+func (p Project) Id() ProjectID {
+	return p.id
+}
+
+func (p Project) Name() string {
+	return p.name
+}
+
+func (p Project) Description() string {
+	return p.description
+}
+
+// Columns are in board order. There is always at least one.
+func (p Project) Columns() []Column {
+	return copyColumns(p.columns)
+}
+
+func (p Project) Column(id ColumnID) (Column, bool) {
+	for _, column := range p.columns {
+		if column.id == id {
+			return column, true
+		}
+	}
+	return Column{}, false
+}
+
+func (p Project) CreatedAt() time.Time {
+	return p.createdAt
+}
+
+func (p Project) UpdatedAt() *time.Time {
+	return p.updatedAt
+}
+
+// Column is a workflow state of a project, e.g. "In review".
+type Column struct {
+	id    ColumnID
+	name  string
+	limit int
+}
+
+// NewColumn is meant both for restoring stored columns and for describing columns in project commands,
+// where zero id stands for a new column. Zero limit means the column can hold any number of tasks.
+func NewColumn(id ColumnID, name string, limit int) Column {
+	return Column{id: id, name: name, limit: limit}
+}
+
+func (c Column) Id() ColumnID {
+	return c.id
+}
+
+func (c Column) Name() string {
+	return c.name
+}
+
+// Limit is the maximum number of tasks in the column, not counting tasks in the trash. Zero means no limit.
+func (c Column) Limit() int {
+	return c.limit
+}
+
+// accepts reports whether the column holding count tasks can take one more.
+func (c Column) accepts(count int) bool {
+	return c.limit == 0 || count < c.limit
+}
+
+// AddProjectCommand is used for creating new Project. Like AddTaskCommand, it cannot be changed once created.
+type AddProjectCommand struct {
+	name        string
+	description string
+	columns     []Column
+	createdAt   time.Time
+}
+
+// NewAddProjectCommand expects columns in board order, all of them new.
+func NewAddProjectCommand(name string, description string, columns []Column) (AddProjectCommand, error) {
+	name, description, err := validateProject(name, description, columns)
+	if err != nil {
+		return AddProjectCommand{}, err
+	}
+	for _, column := range columns {
+		if column.Id() != 0 {
+			return AddProjectCommand{}, fmt.Errorf("%w: new project cannot have existing columns", ErrValidation)
+		}
+	}
+
+	return AddProjectCommand{
+		name:        name,
+		description: description,
+		columns:     normalizeColumns(columns),
+		createdAt:   time.Now(),
+	}, nil
+}
+
+func (a AddProjectCommand) Name() string {
+	return a.name
+}
+
+func (a AddProjectCommand) Description() string {
+	return a.description
+}
+
+func (a AddProjectCommand) Columns() []Column {
+	return copyColumns(a.columns)
+}
+
+func (a AddProjectCommand) CreatedAt() time.Time {
+	return a.createdAt
+}
+
+// UpdateProjectCommand is used for updating existing Project.
+type UpdateProjectCommand struct {
+	id          ProjectID
+	name        string
+	description string
+	columns     []Column
+}
+
+// NewUpdateProjectCommand expects all columns the project should have, in board order.
+// Existing columns are referenced by id, columns left out are removed.
+func NewUpdateProjectCommand(
+	id ProjectID,
+	name string,
+	description string,
+	columns []Column,
+) (UpdateProjectCommand, error) {
+	name, description, err := validateProject(name, description, columns)
+	if err != nil {
+		return UpdateProjectCommand{}, err
+	}
+	ids := map[ColumnID]bool{}
+	for _, column := range columns {
+		if column.Id() != 0 && ids[column.Id()] {
+			return UpdateProjectCommand{}, fmt.Errorf("%w: column %d is listed twice", ErrValidation, column.Id())
+		}
+		ids[column.Id()] = true
+	}
+
+	return UpdateProjectCommand{
+		id:          id,
+		name:        name,
+		description: description,
+		columns:     normalizeColumns(columns),
+	}, nil
+}
+
+func (u UpdateProjectCommand) Id() ProjectID {
+	return u.id
+}
+
+func (u UpdateProjectCommand) Name() string {
+	return u.name
+}
+
+func (u UpdateProjectCommand) Description() string {
+	return u.description
+}
+
+func (u UpdateProjectCommand) Columns() []Column {
+	return copyColumns(u.columns)
+}
+
+func validateProject(name string, description string, columns []Column) (string, string, error) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return "", "", fmt.Errorf("%w: name must not be empty", ErrValidation)
+	}
+	if utf8.RuneCountInString(name) > projectNameMaxLength {
+		return "", "", fmt.Errorf(
+			"%w: name must not be longer than %d characters",
+			ErrValidation,
+			projectNameMaxLength,
+		)
+	}
+	description = strings.TrimSpace(description)
+	if utf8.RuneCountInString(description) > projectDescriptionMaxLength {
+		return "", "", fmt.Errorf(
+			"%w: description must not be longer than %d characters",
+			ErrValidation,
+			projectDescriptionMaxLength,
+		)
+	}
+	if len(columns) == 0 || len(columns) > maxColumns {
+		return "", "", fmt.Errorf("%w: project must have from 1 to %d columns", ErrValidation, maxColumns)
+	}
+
+	names := map[string]bool{}
+	for _, column := range columns {
+		columnName := strings.TrimSpace(column.Name())
+		if columnName == "" {
+			return "", "", fmt.Errorf("%w: column name must not be empty", ErrValidation)
+		}
+		if utf8.RuneCountInString(columnName) > columnNameMaxLength {
+			return "", "", fmt.Errorf(
+				"%w: column name must not be longer than %d characters",
+				ErrValidation,
+				columnNameMaxLength,
+			)
+		}
+		if names[strings.ToLower(columnName)] {
+			return "", "", fmt.Errorf("%w: column %q is listed twice", ErrValidation, columnName)
+		}
+		names[strings.ToLower(columnName)] = true
+		if column.Limit() < 0 {
+			return "", "", fmt.Errorf("%w: column limit must not be negative", ErrValidation)
+		}
+	}
+	return name, description, nil
+}
+
+func normalizeColumns(columns []Column) []Column {
+	result := make([]Column, len(columns))
+	for i, column := range columns {
+		result[i] = NewColumn(column.Id(), strings.TrimSpace(column.Name()), column.Limit())
+	}
+	return result
+}
+
+func copyColumns(columns []Column) []Column {
+	if columns == nil {
+		return nil
+	}
+	result := make([]Column, len(columns))
+	copy(result, columns)
+	return result
+}
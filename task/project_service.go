@@ -0,0 +1,185 @@
+package task
+
+import "fmt"
+
+// projectRepository is expected to return an error matching ErrNotFound when the project does not exist.
+// Add and Save return the project as stored, with ids assigned to new columns. Save removes columns left out.
+type projectRepository interface {
+	List() ([]Project, error)
+	GetByID(id ProjectID) (Project, error)
+	Add(addProject AddProjectCommand) (Project, error)
+	Save(project Project) (Project, error)
+	Delete(id ProjectID) error
+}
+
+// ProjectService manages projects and their boards. Tasks are placed into projects by Service.
+type ProjectService struct {
+	repository projectRepository
+	tasks      taskRepository
+}
+
+func NewProjectService(repository projectRepository, tasks taskRepository) *ProjectService {
+	return &ProjectService{repository: repository, tasks: tasks}
+}
+
+func (s *ProjectService) ListProjects() ([]Project, error) {
+	return s.repository.List()
+}
+
+func (s *ProjectService) GetProject(id ProjectID) (Project, error) {
+	project, err := s.repository.GetByID(id)
+	if err != nil {
+		return Project{}, notFound(err)
+	}
+
+	return project, nil
+}
+
+func (s *ProjectService) AddProject(command AddProjectCommand) (Project, error) {
+	return s.repository.Add(command)
+}
+
+// UpdateProject refuses to remove columns which still have tasks, including the ones in the trash,
+// and to lower the limit of a column below the number of tasks it holds.
+func (s *ProjectService) UpdateProject(command UpdateProjectCommand) (Project, error) {
+	project, err := s.GetProject(command.Id())
+	if err != nil {
+		return Project{}, err
+	}
+	before := project
+	err = project.Update(command)
+	if err != nil {
+		return Project{}, err
+	}
+
+	active, all, err := s.countTasks(project.Id())
+	if err != nil {
+		return Project{}, err
+	}
+	for _, column := range before.Columns() {
+		if _, ok := project.Column(column.Id()); !ok && all[column.Id()] > 0 {
+			return Project{}, fmt.Errorf("%w: column %q cannot be removed", ErrHasTasks, column.Name())
+		}
+	}
+	for _, column := range project.Columns() {
+		if column.Limit() > 0 && active[column.Id()] > column.Limit() {
+			return Project{}, fmt.Errorf(
+				"%w: column %q holds %d tasks",
+				ErrColumnFull,
+				column.Name(),
+				active[column.Id()],
+			)
+		}
+	}
+
+	project, err = s.repository.Save(project)
+	if err != nil {
+		return Project{}, notFound(err)
+	}
+
+	return project, nil
+}
+
+// DeleteProject refuses to delete projects which still have tasks, including the ones in the trash.
+func (s *ProjectService) DeleteProject(id ProjectID) error {
+	_, err := s.GetProject(id)
+	if err != nil {
+		return err
+	}
+	_, all, err := s.countTasks(id)
+	if err != nil {
+		return err
+	}
+	for _, count := range all {
+		if count > 0 {
+			return fmt.Errorf("%w: project %d cannot be deleted", ErrHasTasks, id)
+		}
+	}
+
+	return notFound(s.repository.Delete(id))
+}
+
+// GetBoard returns the project with tasks in each of its columns, ordered by rank.
+func (s *ProjectService) GetBoard(id ProjectID) (Board, error) {
+	project, err := s.GetProject(id)
+	if err != nil {
+		return Board{}, err
+	}
+	tasks, err := s.tasks.List(ListFilter{ProjectID: &id})
+	if err != nil {
+		return Board{}, err
+	}
+
+	return newBoard(project, tasks), nil
+}
+
+// countTasks counts tasks in each column of the project, first without tasks in the trash, then including them.
+func (s *ProjectService) countTasks(id ProjectID) (map[ColumnID]int, map[ColumnID]int, error) {
+	active := map[ColumnID]int{}
+	all := map[ColumnID]int{}
+	for _, deleted := range []bool{false, true} {
+		tasks, err := s.tasks.List(ListFilter{ProjectID: &id, Deleted: deleted})
+		if err != nil {
+			return nil, nil, err
+		}
+		for _, entity := range tasks {
+			if entity.ColumnID() == nil {
+				continue
+			}
+			if !deleted {
+				active[*entity.ColumnID()]++
+			}
+			all[*entity.ColumnID()]++
+		}
+	}
+
+	return active, all, nil
+}
+
+// Board is a project with its tasks laid out in columns.
+type Board struct {
+	project Project
+	columns []BoardColumn
+}
+
+func newBoard(project Project, tasks []Task) Board {
+	columns := project.Columns()
+	result := Board{project: project, columns: make([]BoardColumn, len(columns))}
+	index := make(map[ColumnID]int, len(columns))
+	for i, column := range columns {
+		result.columns[i] = BoardColumn{column: column, tasks: []Task{}}
+		index[column.Id()] = i
+	}
+	for _, entity := range tasks {
+		if entity.ColumnID() == nil {
+			continue
+		}
+		if i, ok := index[*entity.ColumnID()]; ok {
+			result.columns[i].tasks = append(result.columns[i].tasks, entity)
+		}
+	}
+	return result
+}
+
+func (b Board) Project() Project {
+	return b.project
+}
+
+// Columns are in board order.
+func (b Board) Columns() []BoardColumn {
+	return b.columns
+}
+
+type BoardColumn struct {
+	column Column
+	tasks  []Task
+}
+
+func (c BoardColumn) Column() Column {
+	return c.column
+}
+
+// Tasks are ordered by rank.
+func (c BoardColumn) Tasks() []Task {
+	return c.tasks
+}
@@ -0,0 +1,404 @@
+package task_test
+
+import (
+	"demo-app-go/task"
+	"github.com/stretchr/testify/require"
+	"strings"
+	"testing"
+)
+
+// fakeProjectRepository keeps projects in a map and assigns ids to new columns like storage.ProjectRepository.
+type fakeProjectRepository struct {
+	projects     map[task.ProjectID]task.Project
+	nextID       task.ProjectID
+	nextColumnID task.ColumnID
+}
+
+func newFakeProjectRepository() *fakeProjectRepository {
+	return &fakeProjectRepository{projects: map[task.ProjectID]task.Project{}, nextID: 1, nextColumnID: 1}
+}
+
+func (r *fakeProjectRepository) List() ([]task.Project, error) {
+	result := make([]task.Project, 0, len(r.projects))
+	for id := task.ProjectID(1); id < r.nextID; id++ {
+		if project, ok := r.projects[id]; ok {
+			result = append(result, project)
+		}
+	}
+	return result, nil
+}
+
+func (r *fakeProjectRepository) GetByID(id task.ProjectID) (task.Project, error) {
+	project, ok := r.projects[id]
+	if !ok {
+		return task.Project{}, wrapNotFound()
+	}
+	return project, nil
+}
+
+func (r *fakeProjectRepository) Add(addProject task.AddProjectCommand) (task.Project, error) {
+	project := task.NewProject(task.ProjectSnapshot{
+		Id:          r.nextID,
+		Name:        addProject.Name(),
+		Description: addProject.Description(),
+		Columns:     r.assignColumnIDs(addProject.Columns()),
+		CreatedAt:   addProject.CreatedAt(),
+	})
+	r.projects[project.Id()] = project
+	r.nextID++
+	return project, nil
+}
+
+func (r *fakeProjectRepository) Save(project task.Project) (task.Project, error) {
+	if _, ok := r.projects[project.Id()]; !ok {
+		return task.Project{}, wrapNotFound()
+	}
+	snapshot := task.ProjectSnapshot{
+		Id:          project.Id(),
+		Name:        project.Name(),
+		Description: project.Description(),
+		Columns:     r.assignColumnIDs(project.Columns()),
+		CreatedAt:   project.CreatedAt(),
+		UpdatedAt:   project.UpdatedAt(),
+	}
+	r.projects[project.Id()] = task.NewProject(snapshot)
+	return r.projects[project.Id()], nil
+}
+
+func (r *fakeProjectRepository) Delete(id task.ProjectID) error {
+	if _, ok := r.projects[id]; !ok {
+		return wrapNotFound()
+	}
+	delete(r.projects, id)
+	return nil
+}
+
+func (r *fakeProjectRepository) assignColumnIDs(columns []task.Column) []task.Column {
+	result := make([]task.Column, len(columns))
+	for i, column := range columns {
+		result[i] = column
+		if column.Id() == 0 {
+			result[i] = task.NewColumn(r.nextColumnID, column.Name(), column.Limit())
+			r.nextColumnID++
+		}
+	}
+	return result
+}
+
+func TestNewAddProjectCommand(t *testing.T) {
+	t.Run("trims and keeps column order", func(t *testing.T) {
+		t.Parallel()
+		result, err := task.NewAddProjectCommand(" Website ", "", []task.Column{
+			task.NewColumn(0, " To do ", 0),
+			task.NewColumn(0, "Done", 0),
+		})
+		require.NoError(t, err, "unexpected error")
+		require.Equal(t, "Website", result.Name())
+		require.Equal(t, []task.Column{task.NewColumn(0, "To do", 0), task.NewColumn(0, "Done", 0)}, result.Columns())
+	})
+
+	samples := map[string]struct {
+		name    string
+		columns []task.Column
+	}{
+		"empty name":        {name: " ", columns: []task.Column{task.NewColumn(0, "To do", 0)}},
+		"too long name":     {name: strings.Repeat("a", 101), columns: []task.Column{task.NewColumn(0, "To do", 0)}},
+		"no columns":        {name: "Website"},
+		"empty column name": {name: "Website", columns: []task.Column{task.NewColumn(0, " ", 0)}},
+		"negative limit":    {name: "Website", columns: []task.Column{task.NewColumn(0, "To do", -1)}},
+		"existing column":   {name: "Website", columns: []task.Column{task.NewColumn(1, "To do", 0)}},
+		"duplicate column name": {
+			name:    "Website",
+			columns: []task.Column{task.NewColumn(0, "Done", 0), task.NewColumn(0, "done", 0)},
+		},
+	}
+	for name, sample := range samples {
+		sample := sample
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			_, err := task.NewAddProjectCommand(sample.name, "", sample.columns)
+			require.ErrorIs(t, err, task.ErrValidation)
+		})
+	}
+}
+
+func TestProjects(t *testing.T) {
+	// setup adds project 1 with columns 1 "To do", 2 "Doing" limited to 1 task and 3 "Done",
+	// and project 2 with column 4 "Backlog".
+	setup := func(t *testing.T) (*task.Service, *task.ProjectService) {
+		service, repository := newFakeService(task.DeleteRefuse)
+		projects := task.NewProjectService(repository.projects, repository)
+		for _, columns := range [][]task.Column{
+			{task.NewColumn(0, "To do", 0), task.NewColumn(0, "Doing", 1), task.NewColumn(0, "Done", 0)},
+			{task.NewColumn(0, "Backlog", 0)},
+		} {
+			command, err := task.NewAddProjectCommand("project", "", columns)
+			require.NoError(t, err, "command")
+			_, err = projects.AddProject(command)
+			require.NoError(t, err, "add project")
+		}
+		return service, projects
+	}
+	projectID := func(id task.ProjectID) *task.ProjectID {
+		return &id
+	}
+	columnID := func(id task.ColumnID) *task.ColumnID {
+		return &id
+	}
+	taskID := func(id task.ID) *task.ID {
+		return &id
+	}
+	addTask := func(t *testing.T, service *task.Service, title string, column *task.ColumnID) (task.Task, error) {
+		command, err := task.NewAddTaskCommand(title, "", nil, nil, nil, nil, projectID(1), column)
+		require.NoError(t, err, "command")
+		return service.AddTask(command, "alice")
+	}
+	board := func(t *testing.T, projects *task.ProjectService) map[string][]string {
+		result, err := projects.GetBoard(1)
+		require.NoError(t, err, "get board")
+		titles := map[string][]string{}
+		for _, column := range result.Columns() {
+			titles[column.Column().Name()] = []string{}
+			for _, entity := range column.Tasks() {
+				titles[column.Column().Name()] = append(titles[column.Column().Name()], entity.Title())
+			}
+		}
+		return titles
+	}
+
+	t.Run("AddTask places task into first column by default", func(t *testing.T) {
+		t.Parallel()
+		service, projects := setup(t)
+
+		result, err := addTask(t, service, "first", nil)
+		require.NoError(t, err, "unexpected error")
+		require.Equal(t, projectID(1), result.ProjectID())
+		require.Equal(t, columnID(1), result.ColumnID())
+		_, err = addTask(t, service, "second", columnID(3))
+		require.NoError(t, err, "explicit column")
+		_, err = addTask(t, service, "third", nil)
+		require.NoError(t, err, "add task")
+
+		require.Equal(t, map[string][]string{
+			"To do": {"first", "third"},
+			"Doing": {},
+			"Done":  {"second"},
+		}, board(t, projects))
+	})
+	t.Run("AddTask rejects column of another project", func(t *testing.T) {
+		t.Parallel()
+		service, _ := setup(t)
+
+		_, err := addTask(t, service, "first", columnID(4))
+		require.ErrorIs(t, err, task.ErrValidation)
+		command, err := task.NewAddTaskCommand("first", "", nil, nil, nil, nil, projectID(3), nil)
+		require.NoError(t, err, "command")
+		_, err = service.AddTask(command, "alice")
+		require.ErrorIs(t, err, task.ErrValidation, "missing project")
+	})
+	t.Run("column limit is enforced", func(t *testing.T) {
+		t.Parallel()
+		service, _ := setup(t)
+		_, err := addTask(t, service, "first", columnID(2))
+		require.NoError(t, err, "add task")
+		second, err := addTask(t, service, "second", nil)
+		require.NoError(t, err, "add task")
+
+		_, err = addTask(t, service, "third", columnID(2))
+		require.ErrorIs(t, err, task.ErrColumnFull, "add")
+		command, err := task.NewMoveTaskCommand(second.Id(), columnID(2), nil, nil)
+		require.NoError(t, err, "command")
+		_, err = service.MoveTask(command, "alice")
+		require.ErrorIs(t, err, task.ErrColumnFull, "move")
+		update, err := task.NewUpdateTaskCommand(1, "first", "", nil, nil, nil, nil, projectID(1), nil, nil)
+		require.NoError(t, err, "command")
+		_, err = service.UpdateTask(update, "alice")
+		require.NoError(t, err, "task staying in full column")
+
+		err = service.DeleteTask(1, "alice")
+		require.NoError(t, err, "delete task")
+		_, err = service.MoveTask(command, "alice")
+		require.NoError(t, err, "move after column got room")
+		_, err = service.RestoreTask(1, "alice")
+		require.ErrorIs(t, err, task.ErrColumnFull, "restore")
+	})
+	t.Run("RestoreTask restores subtasks along with the task or none of them", func(t *testing.T) {
+		t.Parallel()
+		service, repository := newFakeService(task.DeleteCascade)
+		projects := task.NewProjectService(repository.projects, repository)
+		project, err := task.NewAddProjectCommand("project", "", []task.Column{
+			task.NewColumn(0, "To do", 0),
+			task.NewColumn(0, "Doing", 1),
+		})
+		require.NoError(t, err, "command")
+		_, err = projects.AddProject(project)
+		require.NoError(t, err, "add project")
+		parent, err := addTask(t, service, "parent", nil)
+		require.NoError(t, err, "add task")
+		command, err := task.NewAddTaskCommand("child", "", nil, taskID(parent.Id()), nil, nil, projectID(1), columnID(2))
+		require.NoError(t, err, "command")
+		_, err = service.AddTask(command, "alice")
+		require.NoError(t, err, "add subtask")
+		require.NoError(t, service.DeleteTask(parent.Id(), "alice"), "delete task")
+		_, err = addTask(t, service, "other", columnID(2))
+		require.NoError(t, err, "fill the column of the subtask")
+
+		_, err = service.RestoreTask(parent.Id(), "alice")
+		require.ErrorIs(t, err, task.ErrColumnFull)
+		_, err = service.GetTask(parent.Id())
+		require.Equal(t, task.ErrNotFound, err, "the task must stay in the trash along with its subtask")
+		trash, err := service.ListTrash()
+		require.NoError(t, err, "list trash")
+		require.Len(t, trash, 2)
+	})
+	t.Run("MoveTask changes column and records it in history", func(t *testing.T) {
+		t.Parallel()
+		service, projects := setup(t)
+		_, err := addTask(t, service, "first", nil)
+		require.NoError(t, err, "add task")
+		_, err = addTask(t, service, "second", columnID(3))
+		require.NoError(t, err, "add task")
+
+		command, err := task.NewMoveTaskCommand(1, columnID(3), nil, taskID(2))
+		require.NoError(t, err, "command")
+		result, err := service.MoveTask(command, "bob")
+		require.NoError(t, err, "unexpected error")
+		require.Equal(t, columnID(3), result.ColumnID())
+		require.Equal(t, map[string][]string{
+			"To do": {},
+			"Doing": {},
+			"Done":  {"first", "second"},
+		}, board(t, projects))
+
+		revisions, err := service.ListRevisions(1)
+		require.NoError(t, err, "list revisions")
+		require.Equal(t, []task.FieldChange{
+			task.NewFieldChange("columnId", task.ColumnID(1), task.ColumnID(3)),
+		}, revisions[len(revisions)-1].Changes())
+	})
+	t.Run("MoveTask keeps the column when anchors are invalid", func(t *testing.T) {
+		t.Parallel()
+		service, projects := setup(t)
+		_, err := addTask(t, service, "first", nil)
+		require.NoError(t, err, "add task")
+		_, err = addTask(t, service, "second", columnID(3))
+		require.NoError(t, err, "add task")
+		_, err = addTask(t, service, "third", nil)
+		require.NoError(t, err, "add task")
+
+		command, err := task.NewMoveTaskCommand(1, columnID(3), nil, taskID(1337))
+		require.NoError(t, err, "command")
+		_, err = service.MoveTask(command, "bob")
+		require.ErrorIs(t, err, task.ErrValidation, "missing anchor")
+		command, err = task.NewMoveTaskCommand(1, columnID(3), taskID(3), nil)
+		require.NoError(t, err, "command")
+		_, err = service.MoveTask(command, "bob")
+		require.ErrorIs(t, err, task.ErrValidation, "anchor in another column")
+
+		result, err := service.GetTask(1)
+		require.NoError(t, err, "get task")
+		require.Equal(t, task.Version(1), result.Version(), "nothing must be saved")
+		require.Equal(t, map[string][]string{
+			"To do": {"first", "third"},
+			"Doing": {},
+			"Done":  {"second"},
+		}, board(t, projects))
+	})
+	t.Run("MoveTask orders and rebalances tasks of the column only", func(t *testing.T) {
+		t.Parallel()
+		service, projects := setup(t)
+		for _, sample := range []struct {
+			title  string
+			column *task.ColumnID
+		}{{"first", nil}, {"second", columnID(3)}, {"third", columnID(3)}, {"fourth", nil}} {
+			_, err := addTask(t, service, sample.title, sample.column)
+			require.NoError(t, err, "add task")
+		}
+		rank := func(id task.ID) task.Rank {
+			entity, err := service.GetTask(id)
+			require.NoError(t, err, "get task")
+			return entity.Rank()
+		}
+		first, fourth := rank(1), rank(4)
+
+		// Moving the tasks before each other in turn halves the room before the first one each time.
+		for i := 0; i < 300; i++ {
+			moved, other := task.ID(3-i%2), task.ID(2+i%2)
+			command, err := task.NewMoveTaskCommand(moved, nil, nil, taskID(other))
+			require.NoError(t, err, "command")
+			_, err = service.MoveTask(command, "alice")
+			require.NoError(t, err, "move %d", i)
+		}
+
+		require.Equal(t, first, rank(1), "tasks of other columns must keep their ranks")
+		require.Equal(t, fourth, rank(4), "tasks of other columns must keep their ranks")
+		require.Equal(t, map[string][]string{
+			"To do": {"first", "fourth"},
+			"Doing": {},
+			"Done":  {"second", "third"},
+		}, board(t, projects))
+	})
+	t.Run("UpdateProject keeps columns by id", func(t *testing.T) {
+		t.Parallel()
+		service, projects := setup(t)
+		_, err := addTask(t, service, "first", columnID(3))
+		require.NoError(t, err, "add task")
+
+		command, err := task.NewUpdateProjectCommand(1, "renamed", "", []task.Column{
+			task.NewColumn(0, "Ideas", 0),
+			task.NewColumn(3, "Finished", 0),
+		})
+		require.NoError(t, err, "command")
+		result, err := projects.UpdateProject(command)
+		require.NoError(t, err, "unexpected error")
+		require.Equal(t, []task.Column{task.NewColumn(5, "Ideas", 0), task.NewColumn(3, "Finished", 0)}, result.Columns())
+		require.Equal(t, map[string][]string{"Ideas": {}, "Finished": {"first"}}, board(t, projects))
+	})
+	t.Run("UpdateProject refuses to remove column with tasks", func(t *testing.T) {
+		t.Parallel()
+		service, projects := setup(t)
+		_, err := addTask(t, service, "first", columnID(3))
+		require.NoError(t, err, "add task")
+		err = service.DeleteTask(1, "alice")
+		require.NoError(t, err, "delete task")
+
+		command, err := task.NewUpdateProjectCommand(1, "project", "", []task.Column{task.NewColumn(1, "To do", 0)})
+		require.NoError(t, err, "command")
+		_, err = projects.UpdateProject(command)
+		require.ErrorIs(t, err, task.ErrHasTasks)
+		command, err = task.NewUpdateProjectCommand(1, "project", "", []task.Column{task.NewColumn(4, "Backlog", 0)})
+		require.NoError(t, err, "command")
+		_, err = projects.UpdateProject(command)
+		require.ErrorIs(t, err, task.ErrValidation, "column of another project")
+	})
+	t.Run("UpdateProject refuses limit below task count", func(t *testing.T) {
+		t.Parallel()
+		service, projects := setup(t)
+		for _, title := range []string{"first", "second"} {
+			_, err := addTask(t, service, title, nil)
+			require.NoError(t, err, "add task")
+		}
+
+		command, err := task.NewUpdateProjectCommand(1, "project", "", []task.Column{
+			task.NewColumn(1, "To do", 1),
+			task.NewColumn(2, "Doing", 1),
+			task.NewColumn(3, "Done", 0),
+		})
+		require.NoError(t, err, "command")
+		_, err = projects.UpdateProject(command)
+		require.ErrorIs(t, err, task.ErrColumnFull)
+	})
+	t.Run("DeleteProject refuses project with tasks", func(t *testing.T) {
+		t.Parallel()
+		service, projects := setup(t)
+		_, err := addTask(t, service, "first", nil)
+		require.NoError(t, err, "add task")
+
+		err = projects.DeleteProject(1)
+		require.ErrorIs(t, err, task.ErrHasTasks)
+		err = projects.DeleteProject(2)
+		require.NoError(t, err, "empty project")
+		_, err = projects.GetProject(2)
+		require.Equal(t, task.ErrNotFound, err)
+	})
+}
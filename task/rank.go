@@ -0,0 +1,219 @@
+package task
+
+import (
+	"fmt"
+	"strings"
+)
+
+const (
+	rankDigits = "0123456789abcdefghijklmnopqrstuvwxyz"
+	rankBase   = len(rankDigits)
+	// rankMaxLength triggers rebalancing, which gives the tasks short ranks again.
+	rankMaxLength = 32
+	// rankAppendLength is how many digits of the last rank are incremented to rank the task appended after it.
+	// It leaves room for over a billion tasks appended after the first one.
+	rankAppendLength = 6
+)
+
+// Rank orders tasks manually. Ranks are compared as strings, so a task can be put between any two others
+// by giving it a rank in between, without changing the others. Ranks consist of rankDigits and never end with "0",
+// so there is always room for another rank before any of them. Empty rank is lower than any other.
+type Rank string
+
+// rankAfter returns a rank greater than the given one. The first rank is in the middle, leaving room before it.
+// Following ones increment the first rankAppendLength digits of the given rank with carry, dropping the rest,
+// so ranks of tasks appended one after another keep the same length. Only when those digits are all at maximum,
+// one more digit is taken.
+func rankAfter(rank Rank) Rank {
+	if rank == "" {
+		return Rank(rankDigits[rankBase/2])
+	}
+	for length := rankAppendLength; ; length++ {
+		digits := make([]byte, length)
+		for i := range digits {
+			digits[i] = digitOrZero(string(rank), i)
+		}
+		for i := length - 1; i >= 0; i-- {
+			if digit := rankDigit(digits[i]); digit < rankBase-1 {
+				digits[i] = rankDigits[digit+1]
+				return Rank(strings.TrimRight(string(digits), "0"))
+			}
+			digits[i] = rankDigits[0]
+		}
+	}
+}
+
+// ranksAfter returns count of increasing ranks following the given one.
+func ranksAfter(rank Rank, count int) []Rank {
+	result := make([]Rank, count)
+	for i := range result {
+		rank = rankAfter(rank)
+		result[i] = rank
+	}
+	return result
+}
+
+// rankAt returns rank for a task put at the position among the ordered tasks. It reports false when there is no room
+// for a short enough rank, and the tasks need to be rebalanced.
+func rankAt(ordered []Task, position int) (Rank, bool) {
+	var lower Rank
+	if position > 0 {
+		lower = ordered[position-1].Rank()
+	}
+	var rank Rank
+	if position == len(ordered) {
+		rank = rankAfter(lower)
+	} else {
+		upper := ordered[position].Rank()
+		if lower >= upper {
+			return "", false
+		}
+		rank = Rank(midpoint(string(lower), string(upper)))
+	}
+	return rank, len(rank) <= rankMaxLength
+}
+
+// midpoint expects a < b, or b to be empty, meaning no upper bound.
+func midpoint(a string, b string) string {
+	if b != "" {
+		n := 0
+		for n < len(b) && digitOrZero(a, n) == b[n] {
+			n++
+		}
+		if n > 0 {
+			rest := ""
+			if n < len(a) {
+				rest = a[n:]
+			}
+			return b[:n] + midpoint(rest, b[n:])
+		}
+	}
+
+	lowerDigit := 0
+	if a != "" {
+		lowerDigit = rankDigit(a[0])
+	}
+	upperDigit := rankBase
+	if b != "" {
+		upperDigit = rankDigit(b[0])
+	}
+	if upperDigit-lowerDigit > 1 {
+		return string(rankDigits[(lowerDigit+upperDigit)/2])
+	}
+	// Digits are consecutive, so the first digit of b alone is in between, unless b is that digit only.
+	if len(b) > 1 {
+		return b[:1]
+	}
+	rest := ""
+	if a != "" {
+		rest = a[1:]
+	}
+	return string(rankDigits[lowerDigit]) + midpoint(rest, "")
+}
+
+// evenRanks returns count of increasing ranks spread evenly, leaving room around each of them.
+func evenRanks(count int) []Rank {
+	length := 1
+	capacity := rankBase
+	for capacity < (count+1)*rankBase {
+		length++
+		capacity *= rankBase
+	}
+
+	step := capacity / (count + 1)
+	result := make([]Rank, count)
+	for i := range result {
+		value := (i + 1) * step
+		digits := make([]byte, length)
+		for j := length - 1; j >= 0; j-- {
+			digits[j] = rankDigits[value%rankBase]
+			value /= rankBase
+		}
+		result[i] = Rank(strings.TrimRight(string(digits), "0"))
+	}
+	return result
+}
+
+func digitOrZero(s string, i int) byte {
+	if i < len(s) {
+		return s[i]
+	}
+	return rankDigits[0]
+}
+
+func rankDigit(c byte) int {
+	return strings.IndexByte(rankDigits, c)
+}
+
+// MoveTaskCommand puts a task right after one task, right before another, or between two adjacent ones of the column
+// the task ends up in.
+// It can also move the task to another column of its project, with or without the anchors.
+type MoveTaskCommand struct {
+	id       ID
+	columnID *ColumnID
+	after    *ID
+	before   *ID
+}
+
+// NewMoveTaskCommand requires a column or at least one of the anchors. When both anchors are given,
+// they must be adjacent. Without anchors, the task keeps its rank.
+func NewMoveTaskCommand(id ID, columnID *ColumnID, after *ID, before *ID) (MoveTaskCommand, error) {
+	if columnID == nil && after == nil && before == nil {
+		return MoveTaskCommand{}, fmt.Errorf("%w: column, after or before task is required", ErrValidation)
+	}
+	if (after != nil && *after == id) || (before != nil && *before == id) {
+		return MoveTaskCommand{}, fmt.Errorf("%w: task cannot be moved next to itself", ErrValidation)
+	}
+	if after != nil && before != nil && *after == *before {
+		return MoveTaskCommand{}, fmt.Errorf("%w: after and before tasks must differ", ErrValidation)
+	}
+
+	return MoveTaskCommand{id: id, columnID: columnID, after: after, before: before}, nil
+}
+
+func (m MoveTaskCommand) Id() ID {
+	return m.id
+}
+
+func (m MoveTaskCommand) ColumnID() *ColumnID {
+	return m.columnID
+}
+
+func (m MoveTaskCommand) After() *ID {
+	return m.after
+}
+
+func (m MoveTaskCommand) Before() *ID {
+	return m.before
+}
+
+// position returns index in the ordered tasks, not including the moved one, where the moved task belongs.
+func (m MoveTaskCommand) position(ordered []Task) (int, error) {
+	if m.after == nil {
+		return anchorIndex(ordered, *m.before)
+	}
+	index, err := anchorIndex(ordered, *m.after)
+	if err != nil {
+		return 0, err
+	}
+	index++
+	if m.before != nil {
+		next, err := anchorIndex(ordered, *m.before)
+		if err != nil {
+			return 0, err
+		}
+		if next != index {
+			return 0, fmt.Errorf("%w: tasks %d and %d are not adjacent", ErrValidation, *m.after, *m.before)
+		}
+	}
+	return index, nil
+}
+
+func anchorIndex(ordered []Task, id ID) (int, error) {
+	for i, entity := range ordered {
+		if entity.Id() == id {
+			return i, nil
+		}
+	}
+	return 0, fmt.Errorf("%w: task %d does not exist in the same column", ErrValidation, id)
+}
@@ -0,0 +1,27 @@
+package task
+
+import "time"
+
+// Recurrence makes the task repeat according to the rule, starting with the due date it was set up with.
+// The start is kept for subsequent occurrences, so that COUNT and UNTIL apply to the whole series.
+type Recurrence struct {
+	rule  RecurrenceRule
+	start time.Time
+}
+
+func NewRecurrence(rule RecurrenceRule, start time.Time) Recurrence {
+	return Recurrence{rule: rule, start: start}
+}
+
+func (r Recurrence) Rule() RecurrenceRule {
+	return r.rule
+}
+
+func (r Recurrence) Start() time.Time {
+	return r.start
+}
+
+// Occurrences returns up to limit occurrences later than after.
+func (r Recurrence) Occurrences(after time.Time, limit int) []time.Time {
+	return r.rule.Occurrences(r.start, after, limit)
+}
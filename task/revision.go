@@ -0,0 +1,272 @@
+package task
+
+import (
+	"time"
+)
+
+// Revision is an immutable record of a single saved change of a task. Revisions are numbered with the version
+// the task reached by the change, so the first one is 1.
+type Revision struct {
+	taskID    ID
+	number    Version
+	actor     string
+	createdAt time.Time
+	state     RevisionState
+	changes   []FieldChange
+}
+
+// RevisionSnapshot holds the entire state of a Revision, just like Snapshot does for Task.
+type RevisionSnapshot struct {
+	TaskID    ID
+	Number    Version
+	Actor     string
+	CreatedAt time.Time
+	State     RevisionState
+	Changes   []FieldChange
+}
+
+func NewRevision(snapshot RevisionSnapshot) Revision {
+	return Revision{
+		taskID:    snapshot.TaskID,
+		number:    snapshot.Number,
+		actor:     snapshot.Actor,
+		createdAt: snapshot.CreatedAt,
+		state:     snapshot.State,
+		changes:   snapshot.Changes,
+	}
+}
+
+// newRevision records the change from one state of the task to another. Before is nil for new tasks.
+func newRevision(before *Task, after Task, actor string, at time.Time) Revision {
+	previous := RevisionState{}
+	if before != nil {
+		previous = revisionState(*before)
+	}
+	state := revisionState(after)
+
+	return Revision{
+		taskID:    after.Id(),
+		number:    after.Version(),
+		actor:     actor,
+		createdAt: at,
+		state:     state,
+		changes:   diffStates(previous, state),
+	}
+}
+
+func (r Revision) TaskID() ID {
+	return r.taskID
+}
+
+func (r Revision) Number() Version {
+	return r.number
+}
+
+func (r Revision) Actor() string {
+	return r.actor
+}
+
+func (r Revision) CreatedAt() time.Time {
+	return r.createdAt
+}
+
+// State is how the task looked right after the change.
+func (r Revision) State() RevisionState {
+	return r.state
+}
+
+// Changes lists fields changed by the revision, compared to the previous one.
+func (r Revision) Changes() []FieldChange {
+	return r.changes
+}
+
+// RevisionState holds fields of a task covered by the history.
+type RevisionState struct {
+	Title       string
+	Description string
+	Status      Status
+	DueAt       *time.Time
+	ParentID    *ID
+	ProjectID   *ProjectID
+	ColumnID    *ColumnID
+	Tags        []string
+	// Recurrence is the rule in canonical form, see RecurrenceRule.String.
+	Recurrence *string
+	// Checklist has items in order, each formatted like "[x] Buy milk".
+	Checklist []string
+	Deleted   bool
+}
+
+func revisionState(task Task) RevisionState {
+	var recurrence *string
+	if task.Recurrence() != nil {
+		rule := task.Recurrence().Rule().String()
+		recurrence = &rule
+	}
+
+	return RevisionState{
+		Title:       task.Title(),
+		Description: task.Description(),
+		Status:      task.Status(),
+		DueAt:       task.DueAt(),
+		ParentID:    task.ParentID(),
+		ProjectID:   task.ProjectID(),
+		ColumnID:    task.ColumnID(),
+		Tags:        task.Tags(),
+		Recurrence:  recurrence,
+		Checklist:   checklistLines(task.Checklist()),
+		Deleted:     task.DeletedAt() != nil,
+	}
+}
+
+// FieldChange is a single field changed between two states of a task.
+// Values have the type of the field, or are nil when the field is not set.
+type FieldChange struct {
+	field  string
+	before any
+	after  any
+}
+
+func NewFieldChange(field string, before any, after any) FieldChange {
+	return FieldChange{field: field, before: before, after: after}
+}
+
+func (c FieldChange) Field() string {
+	return c.field
+}
+
+func (c FieldChange) Before() any {
+	return c.before
+}
+
+func (c FieldChange) After() any {
+	return c.after
+}
+
+// diffStates lists changed fields in a fixed order, so the result does not depend on how the states were made.
+func diffStates(before RevisionState, after RevisionState) []FieldChange {
+	changes := []FieldChange{}
+	add := func(field string, changed bool, before any, after any) {
+		if changed {
+			changes = append(changes, NewFieldChange(field, before, after))
+		}
+	}
+
+	add("title", before.Title != after.Title, before.Title, after.Title)
+	add("description", before.Description != after.Description, before.Description, after.Description)
+	add("status", before.Status != after.Status, before.Status, after.Status)
+	add("dueAt", !sameTime(before.DueAt, after.DueAt), timeValue(before.DueAt), timeValue(after.DueAt))
+	add("parentId", !sameID(before.ParentID, after.ParentID), idValue(before.ParentID), idValue(after.ParentID))
+	add(
+		"projectId",
+		!sameProjectID(before.ProjectID, after.ProjectID),
+		projectIDValue(before.ProjectID),
+		projectIDValue(after.ProjectID),
+	)
+	add(
+		"columnId",
+		!sameColumnID(before.ColumnID, after.ColumnID),
+		columnIDValue(before.ColumnID),
+		columnIDValue(after.ColumnID),
+	)
+	add("tags", !sameStrings(before.Tags, after.Tags), stringsValue(before.Tags), stringsValue(after.Tags))
+	add(
+		"recurrence",
+		!sameString(before.Recurrence, after.Recurrence),
+		stringValue(before.Recurrence),
+		stringValue(after.Recurrence),
+	)
+	add(
+		"checklist",
+		!sameStrings(before.Checklist, after.Checklist),
+		stringsValue(before.Checklist),
+		stringsValue(after.Checklist),
+	)
+	add("deleted", before.Deleted != after.Deleted, before.Deleted, after.Deleted)
+
+	return changes
+}
+
+func sameTime(a *time.Time, b *time.Time) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return a.Equal(*b) && a.Location().String() == b.Location().String()
+}
+
+func sameString(a *string, b *string) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return *a == *b
+}
+
+func sameProjectID(a *ProjectID, b *ProjectID) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return *a == *b
+}
+
+func sameColumnID(a *ColumnID, b *ColumnID) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return *a == *b
+}
+
+func sameStrings(a []string, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// Pointers are dereferenced, so values of FieldChange can be compared and printed as they are.
+
+func timeValue(t *time.Time) any {
+	if t == nil {
+		return nil
+	}
+	return *t
+}
+
+func idValue(id *ID) any {
+	if id == nil {
+		return nil
+	}
+	return *id
+}
+
+func projectIDValue(id *ProjectID) any {
+	if id == nil {
+		return nil
+	}
+	return *id
+}
+
+func columnIDValue(id *ColumnID) any {
+	if id == nil {
+		return nil
+	}
+	return *id
+}
+
+func stringValue(s *string) any {
+	if s == nil {
+		return nil
+	}
+	return *s
+}
+
+func stringsValue(values []string) any {
+	if values == nil {
+		return []string{}
+	}
+	return values
+}
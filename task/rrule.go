@@ -0,0 +1,521 @@
+package task
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// RecurrenceRule is a subset of RFC 5545 RRULE, e.g. FREQ=WEEKLY;BYDAY=MO.
+// Supported parts are FREQ (DAILY, WEEKLY, MONTHLY, YEARLY), INTERVAL, COUNT, UNTIL,
+// BYDAY, BYMONTHDAY, BYMONTH and WKST. Others are rejected, rather than silently ignored.
+type RecurrenceRule struct {
+	frequency  Frequency
+	interval   int
+	count      int
+	until      *ruleUntil
+	byDay      []ruleWeekday
+	byMonthDay []int
+	byMonth    []time.Month
+	weekStart  time.Weekday
+}
+
+type Frequency string
+
+const (
+	FrequencyDaily   Frequency = "DAILY"
+	FrequencyWeekly  Frequency = "WEEKLY"
+	FrequencyMonthly Frequency = "MONTHLY"
+	FrequencyYearly  Frequency = "YEARLY"
+)
+
+// calendarCycleYears is how long the Gregorian calendar takes to repeat, weekdays included. A rule which finds
+// no occurrence within that many years of its periods never finds one, e.g. FREQ=YEARLY;BYMONTH=2;BYMONTHDAY=30,
+// while sparse rules such as FREQ=DAILY;BYMONTH=2;BYMONTHDAY=29 keep going over years without a match.
+const calendarCycleYears = 400
+
+// periodsPerYear is the most periods of the frequency a year spans.
+var periodsPerYear = map[Frequency]int{
+	FrequencyDaily:   366,
+	FrequencyWeekly:  53,
+	FrequencyMonthly: 12,
+	FrequencyYearly:  1,
+}
+
+var weekdayCodes = map[string]time.Weekday{
+	"SU": time.Sunday,
+	"MO": time.Monday,
+	"TU": time.Tuesday,
+	"WE": time.Wednesday,
+	"TH": time.Thursday,
+	"FR": time.Friday,
+	"SA": time.Saturday,
+}
+
+// ruleWeekday is BYDAY entry. Ordinal is 0 for every such weekday, otherwise it selects e.g. first (1) or last (-1) one.
+type ruleWeekday struct {
+	ordinal int
+	weekday time.Weekday
+}
+
+// ruleUntil keeps UNTIL as written, because date and local time forms depend on the timezone of the start.
+type ruleUntil struct {
+	value  time.Time
+	layout string
+}
+
+const (
+	untilDate  = "20060102"
+	untilLocal = "20060102T150405"
+	untilUTC   = "20060102T150405Z"
+)
+
+func ParseRecurrenceRule(value string) (RecurrenceRule, error) {
+	rule := RecurrenceRule{interval: 1, weekStart: time.Monday}
+	value = strings.TrimPrefix(strings.ToUpper(strings.TrimSpace(value)), "RRULE:")
+	seen := map[string]bool{}
+	for _, part := range strings.Split(value, ";") {
+		name, partValue, ok := strings.Cut(part, "=")
+		if !ok || partValue == "" {
+			return RecurrenceRule{}, ruleError("malformed part %q", part)
+		}
+		if seen[name] {
+			return RecurrenceRule{}, ruleError("%s is specified more than once", name)
+		}
+		seen[name] = true
+
+		var err error
+		switch name {
+		case "FREQ":
+			rule.frequency, err = parseFrequency(partValue)
+		case "INTERVAL":
+			rule.interval, err = parseRuleNumber(name, partValue, 1, 0)
+		case "COUNT":
+			rule.count, err = parseRuleNumber(name, partValue, 1, 0)
+		case "UNTIL":
+			rule.until, err = parseUntil(partValue)
+		case "BYDAY":
+			rule.byDay, err = parseByDay(partValue)
+		case "BYMONTHDAY":
+			rule.byMonthDay, err = parseRuleNumbers(name, partValue, -31, 31)
+		case "BYMONTH":
+			var months []int
+			months, err = parseRuleNumbers(name, partValue, 1, 12)
+			for _, month := range months {
+				rule.byMonth = append(rule.byMonth, time.Month(month))
+			}
+		case "WKST":
+			var ok bool
+			rule.weekStart, ok = weekdayCodes[partValue]
+			if !ok {
+				err = ruleError("unknown WKST %q", partValue)
+			}
+		default:
+			err = ruleError("%s is not supported", name)
+		}
+		if err != nil {
+			return RecurrenceRule{}, err
+		}
+	}
+
+	if rule.frequency == "" {
+		return RecurrenceRule{}, ruleError("FREQ is required")
+	}
+	if rule.count > 0 && rule.until != nil {
+		return RecurrenceRule{}, ruleError("COUNT and UNTIL cannot be used together")
+	}
+	if rule.frequency == FrequencyWeekly && len(rule.byMonthDay) > 0 {
+		return RecurrenceRule{}, ruleError("BYMONTHDAY cannot be used with WEEKLY frequency")
+	}
+	for _, day := range rule.byDay {
+		if day.ordinal != 0 && rule.frequency != FrequencyMonthly && rule.frequency != FrequencyYearly {
+			return RecurrenceRule{}, ruleError("BYDAY ordinals require MONTHLY or YEARLY frequency")
+		}
+	}
+
+	return rule, nil
+}
+
+func (r RecurrenceRule) Frequency() Frequency {
+	return r.frequency
+}
+
+// String returns the rule in canonical form, which parses back into the same rule.
+func (r RecurrenceRule) String() string {
+	parts := []string{"FREQ=" + string(r.frequency)}
+	if r.interval > 1 {
+		parts = append(parts, "INTERVAL="+strconv.Itoa(r.interval))
+	}
+	if r.count > 0 {
+		parts = append(parts, "COUNT="+strconv.Itoa(r.count))
+	}
+	if r.until != nil {
+		parts = append(parts, "UNTIL="+r.until.value.Format(r.until.layout))
+	}
+	if len(r.byMonth) > 0 {
+		months := make([]string, len(r.byMonth))
+		for i, month := range r.byMonth {
+			months[i] = strconv.Itoa(int(month))
+		}
+		parts = append(parts, "BYMONTH="+strings.Join(months, ","))
+	}
+	if len(r.byMonthDay) > 0 {
+		days := make([]string, len(r.byMonthDay))
+		for i, day := range r.byMonthDay {
+			days[i] = strconv.Itoa(day)
+		}
+		parts = append(parts, "BYMONTHDAY="+strings.Join(days, ","))
+	}
+	if len(r.byDay) > 0 {
+		days := make([]string, len(r.byDay))
+		for i, day := range r.byDay {
+			days[i] = day.String()
+		}
+		parts = append(parts, "BYDAY="+strings.Join(days, ","))
+	}
+	if r.weekStart != time.Monday {
+		parts = append(parts, "WKST="+weekdayCode(r.weekStart))
+	}
+	return strings.Join(parts, ";")
+}
+
+// Occurrences returns up to limit occurrences later than after.
+// The start is always the first occurrence, as in RFC 5545 DTSTART. Times of day and timezone are taken from it.
+func (r RecurrenceRule) Occurrences(start time.Time, after time.Time, limit int) []time.Time {
+	var result []time.Time
+	r.iterate(start, func(occurrence time.Time) bool {
+		if occurrence.After(after) {
+			result = append(result, occurrence)
+		}
+		return len(result) < limit
+	})
+	return result
+}
+
+// iterate calls yield with subsequent occurrences, until it returns false or the rule ends.
+func (r RecurrenceRule) iterate(start time.Time, yield func(time.Time) bool) {
+	var until *time.Time
+	if r.until != nil {
+		limit := r.until.in(start.Location())
+		until = &limit
+	}
+	emitted := 0
+	emit := func(occurrence time.Time) bool {
+		if until != nil && occurrence.After(*until) {
+			return false
+		}
+		emitted++
+		if !yield(occurrence) {
+			return false
+		}
+		return r.count == 0 || emitted < r.count
+	}
+
+	if !emit(start) {
+		return
+	}
+	maxEmptyPeriods := calendarCycleYears * periodsPerYear[r.frequency]
+	emptyPeriods := 0
+	for period := 0; emptyPeriods < maxEmptyPeriods; period++ {
+		candidates := r.candidates(start, period*r.interval)
+		if len(candidates) == 0 {
+			emptyPeriods++
+			continue
+		}
+		emptyPeriods = 0
+		for _, candidate := range candidates {
+			if !candidate.After(start) {
+				continue
+			}
+			if !emit(candidate) {
+				return
+			}
+		}
+		if candidates[0].Year() > 9999 {
+			return
+		}
+	}
+}
+
+// candidates returns sorted dates within the period, which is offset from the one containing the start.
+func (r RecurrenceRule) candidates(start time.Time, offset int) []time.Time {
+	year, month, day := start.Date()
+	var days []time.Time
+	switch r.frequency {
+	case FrequencyDaily:
+		date := civilDate(year, month, day+offset)
+		if r.matchesMonth(date) && r.matchesMonthDay(date) && r.matchesWeekday(date) {
+			days = append(days, date)
+		}
+	case FrequencyWeekly:
+		weekStart := civilDate(year, month, day-int(start.Weekday()-r.weekStart+7)%7+offset*7)
+		weekdays := r.byDay
+		if len(weekdays) == 0 {
+			weekdays = []ruleWeekday{{weekday: start.Weekday()}}
+		}
+		for _, weekday := range weekdays {
+			date := weekStart.AddDate(0, 0, int(weekday.weekday-r.weekStart+7)%7)
+			if r.matchesMonth(date) {
+				days = append(days, date)
+			}
+		}
+	case FrequencyMonthly:
+		first := civilDate(year, month+time.Month(offset), 1)
+		if r.matchesMonth(first) {
+			days = r.monthDays(first, day)
+		}
+	case FrequencyYearly:
+		days = r.yearDays(year+offset, month, day)
+	}
+
+	result := make([]time.Time, 0, len(days))
+	for _, date := range days {
+		result = append(result, time.Date(
+			date.Year(),
+			date.Month(),
+			date.Day(),
+			start.Hour(),
+			start.Minute(),
+			start.Second(),
+			start.Nanosecond(),
+			start.Location(),
+		))
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Before(result[j]) })
+	return result
+}
+
+// monthDays expands the month starting with the given day according to BYMONTHDAY and BYDAY.
+// Without them, the day of month of the start is used.
+func (r RecurrenceRule) monthDays(first time.Time, startDay int) []time.Time {
+	last := first.AddDate(0, 1, -1)
+	var result []time.Time
+	switch {
+	case len(r.byMonthDay) > 0:
+		for _, monthDay := range r.byMonthDay {
+			if monthDay < 0 {
+				monthDay = last.Day() + monthDay + 1
+			}
+			if monthDay < 1 || monthDay > last.Day() {
+				continue
+			}
+			date := first.AddDate(0, 0, monthDay-1)
+			if len(r.byDay) == 0 || matchesAnyWeekday(r.byDay, date, first, last) {
+				result = append(result, date)
+			}
+		}
+	case len(r.byDay) > 0:
+		for date := first; !date.After(last); date = date.AddDate(0, 0, 1) {
+			if matchesAnyWeekday(r.byDay, date, first, last) {
+				result = append(result, date)
+			}
+		}
+	case startDay <= last.Day():
+		result = append(result, first.AddDate(0, 0, startDay-1))
+	}
+	return unique(result)
+}
+
+// yearDays expands the year. BYMONTH selects months to expand, otherwise BYMONTHDAY applies to every month.
+// BYDAY without BYMONTH and BYMONTHDAY counts ordinals within the whole year, e.g. 20MO is the 20th Monday.
+func (r RecurrenceRule) yearDays(year int, startMonth time.Month, startDay int) []time.Time {
+	months := r.byMonth
+	switch {
+	case len(months) > 0:
+	case len(r.byMonthDay) > 0:
+		months = []time.Month{1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12}
+	case len(r.byDay) > 0:
+		first := civilDate(year, time.January, 1)
+		last := civilDate(year, time.December, 31)
+		var result []time.Time
+		for date := first; !date.After(last); date = date.AddDate(0, 0, 1) {
+			if matchesAnyWeekday(r.byDay, date, first, last) {
+				result = append(result, date)
+			}
+		}
+		return result
+	default:
+		months = []time.Month{startMonth}
+	}
+
+	var result []time.Time
+	for _, month := range months {
+		result = append(result, r.monthDays(civilDate(year, month, 1), startDay)...)
+	}
+	return unique(result)
+}
+
+func (r RecurrenceRule) matchesMonth(date time.Time) bool {
+	if len(r.byMonth) == 0 {
+		return true
+	}
+	for _, month := range r.byMonth {
+		if date.Month() == month {
+			return true
+		}
+	}
+	return false
+}
+
+func (r RecurrenceRule) matchesMonthDay(date time.Time) bool {
+	if len(r.byMonthDay) == 0 {
+		return true
+	}
+	daysInMonth := civilDate(date.Year(), date.Month()+1, 0).Day()
+	for _, monthDay := range r.byMonthDay {
+		if monthDay == date.Day() || daysInMonth+monthDay+1 == date.Day() {
+			return true
+		}
+	}
+	return false
+}
+
+func (r RecurrenceRule) matchesWeekday(date time.Time) bool {
+	return len(r.byDay) == 0 || matchesAnyWeekday(r.byDay, date, date, date)
+}
+
+// matchesAnyWeekday checks the date against BYDAY entries. Ordinals are counted within the range from first to last.
+func matchesAnyWeekday(weekdays []ruleWeekday, date time.Time, first time.Time, last time.Time) bool {
+	for _, weekday := range weekdays {
+		if date.Weekday() != weekday.weekday {
+			continue
+		}
+		switch {
+		case weekday.ordinal == 0:
+			return true
+		case weekday.ordinal > 0 && daysBetween(first, date)/7+1 == weekday.ordinal:
+			return true
+		case weekday.ordinal < 0 && daysBetween(date, last)/7+1 == -weekday.ordinal:
+			return true
+		}
+	}
+	return false
+}
+
+func (d ruleWeekday) String() string {
+	if d.ordinal == 0 {
+		return weekdayCode(d.weekday)
+	}
+	return strconv.Itoa(d.ordinal) + weekdayCode(d.weekday)
+}
+
+func (u ruleUntil) in(location *time.Location) time.Time {
+	switch u.layout {
+	case untilUTC:
+		return u.value
+	case untilDate:
+		// Date is inclusive, so the whole day counts.
+		return time.Date(u.value.Year(), u.value.Month(), u.value.Day(), 23, 59, 59, 999999999, location)
+	default:
+		return time.Date(
+			u.value.Year(),
+			u.value.Month(),
+			u.value.Day(),
+			u.value.Hour(),
+			u.value.Minute(),
+			u.value.Second(),
+			0,
+			location,
+		)
+	}
+}
+
+func parseFrequency(value string) (Frequency, error) {
+	frequency := Frequency(value)
+	switch frequency {
+	case FrequencyDaily, FrequencyWeekly, FrequencyMonthly, FrequencyYearly:
+		return frequency, nil
+	default:
+		return "", ruleError("FREQ %q is not supported", value)
+	}
+}
+
+func parseUntil(value string) (*ruleUntil, error) {
+	for _, layout := range []string{untilDate, untilLocal, untilUTC} {
+		parsed, err := time.Parse(layout, value)
+		if err == nil {
+			return &ruleUntil{value: parsed, layout: layout}, nil
+		}
+	}
+	return nil, ruleError("UNTIL %q is neither date nor date-time", value)
+}
+
+func parseByDay(value string) ([]ruleWeekday, error) {
+	var result []ruleWeekday
+	for _, item := range strings.Split(value, ",") {
+		if len(item) < 2 {
+			return nil, ruleError("unknown BYDAY %q", item)
+		}
+		weekday, ok := weekdayCodes[item[len(item)-2:]]
+		if !ok {
+			return nil, ruleError("unknown BYDAY %q", item)
+		}
+		ordinal := 0
+		if prefix := item[:len(item)-2]; prefix != "" {
+			var err error
+			ordinal, err = strconv.Atoi(prefix)
+			if err != nil || ordinal == 0 || ordinal < -53 || ordinal > 53 {
+				return nil, ruleError("invalid BYDAY ordinal %q", item)
+			}
+		}
+		result = append(result, ruleWeekday{ordinal: ordinal, weekday: weekday})
+	}
+	return result, nil
+}
+
+// parseRuleNumber parses positive number. Maximum of 0 means there's no upper limit.
+func parseRuleNumber(name string, value string, min int, max int) (int, error) {
+	number, err := strconv.Atoi(value)
+	if err != nil || number < min || (max != 0 && number > max) {
+		return 0, ruleError("invalid %s %q", name, value)
+	}
+	return number, nil
+}
+
+// parseRuleNumbers parses comma separated list of non-zero numbers in the given range.
+func parseRuleNumbers(name string, value string, min int, max int) ([]int, error) {
+	var result []int
+	for _, item := range strings.Split(value, ",") {
+		number, err := strconv.Atoi(item)
+		if err != nil || number == 0 || number < min || number > max {
+			return nil, ruleError("invalid %s %q", name, item)
+		}
+		result = append(result, number)
+	}
+	return result, nil
+}
+
+func ruleError(format string, args ...any) error {
+	return fmt.Errorf("%w: recurrence rule: %s", ErrValidation, fmt.Sprintf(format, args...))
+}
+
+func weekdayCode(weekday time.Weekday) string {
+	for code, day := range weekdayCodes {
+		if day == weekday {
+			return code
+		}
+	}
+	return ""
+}
+
+// civilDate creates date without time of day. Overflowing values are normalized, e.g. day 0 is the last day of the previous month.
+func civilDate(year int, month time.Month, day int) time.Time {
+	return time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+}
+
+func daysBetween(from time.Time, to time.Time) int {
+	return int(to.Sub(from).Hours() / 24)
+}
+
+func unique(dates []time.Time) []time.Time {
+	sort.Slice(dates, func(i, j int) bool { return dates[i].Before(dates[j]) })
+	result := dates[:0]
+	for i, date := range dates {
+		if i == 0 || !date.Equal(dates[i-1]) {
+			result = append(result, date)
+		}
+	}
+	return result
+}
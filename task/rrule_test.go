@@ -0,0 +1,270 @@
+package task_test
+
+import (
+	"demo-app-go/task"
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+func TestParseRecurrenceRule(t *testing.T) {
+	t.Run("parses into canonical form", func(t *testing.T) {
+		samples := map[string]string{
+			"FREQ=WEEKLY;BYDAY=MO":                          "FREQ=WEEKLY;BYDAY=MO",
+			"rrule:freq=daily;interval=1":                   "FREQ=DAILY",
+			"FREQ=MONTHLY;BYDAY=-1FR,2MO;COUNT=3":           "FREQ=MONTHLY;COUNT=3;BYDAY=-1FR,2MO",
+			"BYMONTH=6,7;FREQ=YEARLY;INTERVAL=2":            "FREQ=YEARLY;INTERVAL=2;BYMONTH=6,7",
+			"FREQ=MONTHLY;BYMONTHDAY=1,-1;UNTIL=20231231":   "FREQ=MONTHLY;UNTIL=20231231;BYMONTHDAY=1,-1",
+			"FREQ=WEEKLY;UNTIL=19971224T000000Z;WKST=SU":    "FREQ=WEEKLY;UNTIL=19971224T000000Z;WKST=SU",
+			"FREQ=DAILY;UNTIL=19971224T090000;BYDAY=MO,TU":  "FREQ=DAILY;UNTIL=19971224T090000;BYDAY=MO,TU",
+			"FREQ=YEARLY;BYDAY=20MO":                        "FREQ=YEARLY;BYDAY=20MO",
+			"  FREQ=MONTHLY;BYDAY=FR;BYMONTHDAY=13;WKST=MO": "FREQ=MONTHLY;BYMONTHDAY=13;BYDAY=FR",
+		}
+		for input, expected := range samples {
+			input, expected := input, expected
+			t.Run(input, func(t *testing.T) {
+				t.Parallel()
+				result, err := task.ParseRecurrenceRule(input)
+				require.NoError(t, err, "unexpected error")
+				require.Equal(t, expected, result.String())
+
+				again, err := task.ParseRecurrenceRule(result.String())
+				require.NoError(t, err, "canonical form must parse")
+				require.Equal(t, result, again)
+			})
+		}
+	})
+	t.Run("fails at validation", func(t *testing.T) {
+		samples := map[string]string{
+			"empty":                  "",
+			"no frequency":           "BYDAY=MO",
+			"unknown frequency":      "FREQ=FORTNIGHTLY",
+			"hourly frequency":       "FREQ=HOURLY",
+			"malformed part":         "FREQ=DAILY;COUNT",
+			"repeated part":          "FREQ=DAILY;FREQ=WEEKLY",
+			"zero interval":          "FREQ=DAILY;INTERVAL=0",
+			"negative count":         "FREQ=DAILY;COUNT=-1",
+			"count and until":        "FREQ=DAILY;COUNT=2;UNTIL=20230101",
+			"malformed until":        "FREQ=DAILY;UNTIL=2023-01-01",
+			"unknown weekday":        "FREQ=WEEKLY;BYDAY=XX",
+			"zero ordinal":           "FREQ=MONTHLY;BYDAY=0MO",
+			"ordinal out of range":   "FREQ=YEARLY;BYDAY=54MO",
+			"ordinal in weekly":      "FREQ=WEEKLY;BYDAY=1MO",
+			"month day out of range": "FREQ=MONTHLY;BYMONTHDAY=32",
+			"zero month day":         "FREQ=MONTHLY;BYMONTHDAY=0",
+			"month day in weekly":    "FREQ=WEEKLY;BYMONTHDAY=1",
+			"month out of range":     "FREQ=YEARLY;BYMONTH=13",
+			"unknown week start":     "FREQ=WEEKLY;WKST=XX",
+			"unsupported part":       "FREQ=MONTHLY;BYSETPOS=-1",
+		}
+		for name, input := range samples {
+			input := input
+			t.Run(name, func(t *testing.T) {
+				t.Parallel()
+				_, err := task.ParseRecurrenceRule(input)
+				require.ErrorIs(t, err, task.ErrValidation)
+				require.ErrorContains(t, err, "recurrence rule")
+			})
+		}
+	})
+}
+
+func TestRecurrenceRule_Occurrences(t *testing.T) {
+	newYork, err := time.LoadLocation("America/New_York")
+	require.NoError(t, err, "load location")
+	// at returns 9:00 in New York on the given day, like in RFC 5545 examples.
+	at := func(year int, month time.Month, day int) time.Time {
+		return time.Date(year, month, day, 9, 0, 0, 0, newYork)
+	}
+
+	// Most samples come from RFC 5545 section 3.8.5.3. Expected occurrences include the start.
+	samples := map[string]struct {
+		rule     string
+		start    time.Time
+		limit    int
+		expected []time.Time
+	}{
+		"daily for 10 occurrences": {
+			rule:  "FREQ=DAILY;COUNT=10",
+			start: at(1997, 9, 2),
+			limit: 100,
+			expected: []time.Time{
+				at(1997, 9, 2), at(1997, 9, 3), at(1997, 9, 4), at(1997, 9, 5), at(1997, 9, 6),
+				at(1997, 9, 7), at(1997, 9, 8), at(1997, 9, 9), at(1997, 9, 10), at(1997, 9, 11),
+			},
+		},
+		"every other day, across daylight saving time change": {
+			rule:  "FREQ=DAILY;INTERVAL=2",
+			start: at(1997, 10, 23),
+			limit: 4,
+			expected: []time.Time{
+				at(1997, 10, 23), at(1997, 10, 25), at(1997, 10, 27), at(1997, 10, 29),
+			},
+		},
+		"daily in January only": {
+			rule:     "FREQ=DAILY;BYMONTH=1",
+			start:    at(1998, 1, 30),
+			limit:    3,
+			expected: []time.Time{at(1998, 1, 30), at(1998, 1, 31), at(1999, 1, 1)},
+		},
+		"weekly until UTC time": {
+			rule:  "FREQ=WEEKLY;UNTIL=19971007T000000Z",
+			start: at(1997, 9, 2),
+			limit: 100,
+			expected: []time.Time{
+				at(1997, 9, 2), at(1997, 9, 9), at(1997, 9, 16), at(1997, 9, 23), at(1997, 9, 30),
+			},
+		},
+		"weekly until inclusive date": {
+			rule:     "FREQ=WEEKLY;UNTIL=19970916",
+			start:    at(1997, 9, 2),
+			limit:    100,
+			expected: []time.Time{at(1997, 9, 2), at(1997, 9, 9), at(1997, 9, 16)},
+		},
+		"every other week on Tuesday and Thursday": {
+			rule:  "FREQ=WEEKLY;INTERVAL=2;COUNT=8;WKST=SU;BYDAY=TU,TH",
+			start: at(1997, 9, 2),
+			limit: 100,
+			expected: []time.Time{
+				at(1997, 9, 2), at(1997, 9, 4), at(1997, 9, 16), at(1997, 9, 18),
+				at(1997, 9, 30), at(1997, 10, 2), at(1997, 10, 14), at(1997, 10, 16),
+			},
+		},
+		"week starting on Monday": {
+			rule:     "FREQ=WEEKLY;INTERVAL=2;COUNT=4;BYDAY=TU,SU;WKST=MO",
+			start:    at(1997, 8, 5),
+			limit:    100,
+			expected: []time.Time{at(1997, 8, 5), at(1997, 8, 10), at(1997, 8, 19), at(1997, 8, 24)},
+		},
+		"week starting on Sunday": {
+			rule:     "FREQ=WEEKLY;INTERVAL=2;COUNT=4;BYDAY=TU,SU;WKST=SU",
+			start:    at(1997, 8, 5),
+			limit:    100,
+			expected: []time.Time{at(1997, 8, 5), at(1997, 8, 17), at(1997, 8, 19), at(1997, 8, 31)},
+		},
+		"monthly on the first Friday": {
+			rule:  "FREQ=MONTHLY;COUNT=10;BYDAY=1FR",
+			start: at(1997, 9, 5),
+			limit: 100,
+			expected: []time.Time{
+				at(1997, 9, 5), at(1997, 10, 3), at(1997, 11, 7), at(1997, 12, 5), at(1998, 1, 2),
+				at(1998, 2, 6), at(1998, 3, 6), at(1998, 4, 3), at(1998, 5, 1), at(1998, 6, 5),
+			},
+		},
+		"monthly on the second-to-last Monday": {
+			rule:  "FREQ=MONTHLY;COUNT=6;BYDAY=-2MO",
+			start: at(1997, 9, 22),
+			limit: 100,
+			expected: []time.Time{
+				at(1997, 9, 22), at(1997, 10, 20), at(1997, 11, 17),
+				at(1997, 12, 22), at(1998, 1, 19), at(1998, 2, 16),
+			},
+		},
+		"monthly on the third-to-the-last day": {
+			rule:  "FREQ=MONTHLY;BYMONTHDAY=-3",
+			start: at(1997, 9, 28),
+			limit: 6,
+			expected: []time.Time{
+				at(1997, 9, 28), at(1997, 10, 29), at(1997, 11, 28),
+				at(1997, 12, 29), at(1998, 1, 29), at(1998, 2, 26),
+			},
+		},
+		"monthly on the 2nd and 15th": {
+			rule:  "FREQ=MONTHLY;COUNT=6;BYMONTHDAY=2,15",
+			start: at(1997, 9, 2),
+			limit: 100,
+			expected: []time.Time{
+				at(1997, 9, 2), at(1997, 9, 15), at(1997, 10, 2),
+				at(1997, 10, 15), at(1997, 11, 2), at(1997, 11, 15),
+			},
+		},
+		"monthly skips months without the day": {
+			rule:     "FREQ=MONTHLY",
+			start:    at(2023, 1, 31),
+			limit:    3,
+			expected: []time.Time{at(2023, 1, 31), at(2023, 3, 31), at(2023, 5, 31)},
+		},
+		"every Friday the 13th": {
+			rule:  "FREQ=MONTHLY;BYDAY=FR;BYMONTHDAY=13",
+			start: at(1997, 9, 2),
+			limit: 5,
+			expected: []time.Time{
+				at(1997, 9, 2), at(1998, 2, 13), at(1998, 3, 13), at(1998, 11, 13), at(1999, 8, 13),
+			},
+		},
+		"yearly in June and July": {
+			rule:  "FREQ=YEARLY;COUNT=6;BYMONTH=6,7",
+			start: at(1997, 6, 10),
+			limit: 100,
+			expected: []time.Time{
+				at(1997, 6, 10), at(1997, 7, 10), at(1998, 6, 10),
+				at(1998, 7, 10), at(1999, 6, 10), at(1999, 7, 10),
+			},
+		},
+		"yearly on the 20th Monday": {
+			rule:     "FREQ=YEARLY;BYDAY=20MO",
+			start:    at(1997, 5, 19),
+			limit:    3,
+			expected: []time.Time{at(1997, 5, 19), at(1998, 5, 18), at(1999, 5, 17)},
+		},
+		"yearly on Thursdays in March": {
+			rule:  "FREQ=YEARLY;BYMONTH=3;BYDAY=TH",
+			start: at(1997, 3, 13),
+			limit: 6,
+			expected: []time.Time{
+				at(1997, 3, 13), at(1997, 3, 20), at(1997, 3, 27),
+				at(1998, 3, 5), at(1998, 3, 12), at(1998, 3, 19),
+			},
+		},
+		"yearly on leap day": {
+			rule:     "FREQ=YEARLY",
+			start:    at(2024, 2, 29),
+			limit:    3,
+			expected: []time.Time{at(2024, 2, 29), at(2028, 2, 29), at(2032, 2, 29)},
+		},
+		"daily on leap day": {
+			rule:     "FREQ=DAILY;BYMONTH=2;BYMONTHDAY=29",
+			start:    at(2024, 2, 29),
+			limit:    3,
+			expected: []time.Time{at(2024, 2, 29), at(2028, 2, 29), at(2032, 2, 29)},
+		},
+		"daily on leap day skips a century year": {
+			rule:     "FREQ=DAILY;BYMONTH=2;BYMONTHDAY=29",
+			start:    at(2096, 2, 29),
+			limit:    2,
+			expected: []time.Time{at(2096, 2, 29), at(2104, 2, 29)},
+		},
+		"daily rule which never matches ends after the start": {
+			rule:     "FREQ=DAILY;BYMONTH=2;BYMONTHDAY=30",
+			start:    at(2023, 1, 1),
+			limit:    3,
+			expected: []time.Time{at(2023, 1, 1)},
+		},
+		"rule which never matches ends after the start": {
+			rule:     "FREQ=YEARLY;BYMONTH=2;BYMONTHDAY=30",
+			start:    at(2023, 1, 1),
+			limit:    3,
+			expected: []time.Time{at(2023, 1, 1)},
+		},
+	}
+	for name, sample := range samples {
+		sample := sample
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			rule, err := task.ParseRecurrenceRule(sample.rule)
+			require.NoError(t, err, "parse rule")
+
+			result := rule.Occurrences(sample.start, sample.start.Add(-time.Nanosecond), sample.limit)
+			require.Equal(t, sample.expected, result)
+		})
+	}
+
+	t.Run("returns occurrences after the given time", func(t *testing.T) {
+		t.Parallel()
+		rule, err := task.ParseRecurrenceRule("FREQ=WEEKLY;BYDAY=MO;COUNT=4")
+		require.NoError(t, err, "parse rule")
+
+		result := rule.Occurrences(at(2023, 1, 2), at(2023, 1, 9), 10)
+		require.Equal(t, []time.Time{at(2023, 1, 16), at(2023, 1, 23)}, result)
+	})
+}
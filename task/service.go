@@ -1,29 +1,980 @@
 package task
 
+import (
+	"demo-app-go/eventbus"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+)
+
+// taskRepository is expected to return an error matching ErrNotFound when the task does not exist.
+// Add starts tasks at version 1. Save increments the version, but only when the stored one matches the task,
+// otherwise it returns an error matching ErrVersionMismatch.
+// Tasks in the trash are saved like any other, but GetByID does not find them. GetFromTrash finds only them.
+// Delete removes the task permanently, detaching its remaining subtasks.
+// Add, Save and Delete store the ChangeRecord in the same transaction as the task. Add calls record with the new task.
+// List returns tasks ordered by rank, ties resolved by id.
 type taskRepository interface {
-	List() ([]Task, error)
+	List(filter ListFilter) ([]Task, error)
 	GetByID(id ID) (Task, error)
-	Add(addTask AddTaskCommand) (Task, error)
-	Save(task Task) error
-	Delete(id ID) error
-}
-
-//type Service struct {
-//	repository taskRepository
-//}
-//
-//func (s *Service) ListTasks() ([]Task, error) {
-//
-//}
-//
-//func (s *Service) GetTask(id ID) (Task, error) {
-//
-//}
-//
-//func (s *Service) AddTask(command AddTaskCommand) (Task, error) {
-//
-//}
-
-//func (s *Service)
-
-//func (s *Service)
+	GetFromTrash(id ID) (Task, error)
+	Add(addTask AddTaskCommand, record func(Task) ChangeRecord) (Task, error)
+	// AddTree adds all tasks of the tree in a single transaction, calling record with each of them.
+	AddTree(addTree AddTaskTreeCommand, record func(Task) ChangeRecord) (Tree, error)
+	Save(task Task, record ChangeRecord) error
+	// SaveAll saves the tasks like Save does, in a single transaction. None is saved when any of them fails.
+	SaveAll(changes []TaskChange) error
+	// SaveAndAdd saves the task and adds another one in a single transaction, calling record with the added task.
+	SaveAndAdd(task Task, record ChangeRecord, add AddTaskCommand, recordAdded func(Task) ChangeRecord) (Task, error)
+	Delete(id ID, record ChangeRecord) error
+	// LastRank returns the highest rank of all tasks, including the ones in the trash. It is empty when there are none.
+	LastRank() (Rank, error)
+	// SaveRanks changes ranks of the tasks at once, without changing anything else, including their version.
+	SaveRanks(ranks map[ID]Rank) error
+	// SaveWithRanks saves the task like Save does and changes ranks like SaveRanks does, in a single transaction.
+	SaveWithRanks(task Task, record ChangeRecord, ranks map[ID]Rank) error
+	ListDependencies() ([]Dependency, error)
+	AddDependency(dependency Dependency) error
+	RemoveDependency(dependency Dependency) error
+	ListTags() ([]TagUsage, error)
+	RenameTag(from string, to string) error
+	MergeTags(from string, into string) error
+}
+
+// revisionRepository reads revisions stored by taskRepository.
+// It is expected to return an error matching ErrNotFound when the revision does not exist.
+type revisionRepository interface {
+	// List returns revisions of the task, oldest first.
+	List(taskID ID) ([]Revision, error)
+	Get(taskID ID, number Version) (Revision, error)
+}
+
+// ChangeRecord describes a change of a task, to be stored along with it.
+// Revision is nil when the task is removed permanently, as its history goes away with it.
+// Events are meant for an outbox, from where they are relayed to subscribers once the change is committed.
+type ChangeRecord struct {
+	Revision *Revision
+	Events   []eventbus.Event
+}
+
+// TaskChange is a task to be saved along with the record of its change.
+type TaskChange struct {
+	Task   Task
+	Record ChangeRecord
+}
+
+// Service is the application layer for tasks.
+// It is the single place enforcing task rules, regardless of whether the call comes from HTTP, CLI or tests.
+// Every saved change is recorded as a revision, attributed to the actor passed to the method making it,
+// along with events describing it.
+type Service struct {
+	repository   taskRepository
+	revisions    revisionRepository
+	projects     projectRepository
+	deletePolicy DeletePolicy
+}
+
+func NewService(
+	repository taskRepository,
+	revisions revisionRepository,
+	projects projectRepository,
+	deletePolicy DeletePolicy,
+) *Service {
+	return &Service{repository: repository, revisions: revisions, projects: projects, deletePolicy: deletePolicy}
+}
+
+func (s *Service) ListTasks(filter ListFilter) ([]Task, error) {
+	return s.repository.List(filter)
+}
+
+func (s *Service) GetTask(id ID) (Task, error) {
+	entity, err := s.repository.GetByID(id)
+	if err != nil {
+		return Task{}, notFound(err)
+	}
+
+	return entity, nil
+}
+
+func (s *Service) AddTask(command AddTaskCommand, actor string) (Task, error) {
+	if command.ParentID() != nil {
+		ancestors, err := s.ancestors(*command.ParentID())
+		if err != nil {
+			return Task{}, err
+		}
+		err = validateParent(0, ancestors, 1)
+		if err != nil {
+			return Task{}, err
+		}
+	}
+	if command.ProjectID() != nil {
+		column, err := s.placeIntoColumn(*command.ProjectID(), command.ColumnID(), nil, 0)
+		if err != nil {
+			return Task{}, err
+		}
+		command.columnID = &column
+	}
+
+	return s.add(command, actor)
+}
+
+func (s *Service) UpdateTask(command UpdateTaskCommand, actor string) (Task, error) {
+	entity, err := s.GetTask(command.Id())
+	if err != nil {
+		return Task{}, err
+	}
+
+	if command.ParentID() != nil && !sameID(command.ParentID(), entity.ParentID()) {
+		err = s.validateMove(entity, *command.ParentID())
+		if err != nil {
+			return Task{}, err
+		}
+	}
+	if command.ProjectID() != nil {
+		var current *ColumnID
+		if sameProjectID(command.ProjectID(), entity.ProjectID()) {
+			current = entity.ColumnID()
+		}
+		column, err := s.placeIntoColumn(*command.ProjectID(), command.ColumnID(), current, 0)
+		if err != nil {
+			return Task{}, err
+		}
+		command.columnID = &column
+	}
+
+	before := entity
+	err = entity.Update(command)
+	if err != nil {
+		return Task{}, err
+	}
+	err = s.save(&entity, before, actor)
+	if err != nil {
+		return Task{}, notFound(err)
+	}
+
+	return entity, nil
+}
+
+func (s *Service) TransitionTask(id ID, status Status, actor string) (Task, error) {
+	entity, err := s.GetTask(id)
+	if err != nil {
+		return Task{}, err
+	}
+
+	blockers, err := s.ListBlockers(id)
+	if err != nil {
+		return Task{}, err
+	}
+	err = validateBlockers(status, blockers)
+	if err != nil {
+		return Task{}, err
+	}
+
+	before := entity
+	err = entity.TransitionTo(status)
+	if err != nil {
+		return Task{}, err
+	}
+	var next AddTaskCommand
+	hasNext := false
+	if status == StatusDone {
+		next, hasNext = entity.completeOccurrence(time.Now())
+	}
+	if hasNext {
+		err = s.saveWithNext(&entity, before, next, actor)
+	} else {
+		err = s.save(&entity, before, actor)
+	}
+	if err != nil {
+		return Task{}, notFound(err)
+	}
+
+	return entity, nil
+}
+
+// PreviewOccurrences returns due dates of upcoming occurrences of the recurring task.
+// For tasks which do not recur, the result is empty.
+func (s *Service) PreviewOccurrences(id ID, limit int) ([]time.Time, error) {
+	entity, err := s.GetTask(id)
+	if err != nil {
+		return nil, err
+	}
+	if entity.Recurrence() == nil || entity.DueAt() == nil {
+		return []time.Time{}, nil
+	}
+
+	return entity.Recurrence().Occurrences(*entity.DueAt(), limit), nil
+}
+
+// ListSubtasks returns direct subtasks of the task.
+func (s *Service) ListSubtasks(id ID) ([]Task, error) {
+	_, err := s.GetTask(id)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.repository.List(ListFilter{ParentID: &id})
+}
+
+// GetTaskTree returns the task along with all of its subtasks, nested.
+func (s *Service) GetTaskTree(id ID) (Tree, error) {
+	entity, err := s.GetTask(id)
+	if err != nil {
+		return Tree{}, err
+	}
+
+	return s.tree(entity)
+}
+
+// DeleteTask moves the task to the trash, handling its subtasks according to the delete policy.
+// Subtasks deleted along with the task share its deletion time, so they can be restored together.
+// The task and its subtasks are saved at once, so either all of them change or none does.
+func (s *Service) DeleteTask(id ID, actor string) error {
+	tree, err := s.GetTaskTree(id)
+	if err != nil {
+		return err
+	}
+	now := time.Now()
+
+	var changes []TaskChange
+	change := func(entity Task, apply func(entity *Task)) {
+		before := entity
+		apply(&entity)
+		_, record := recordChanged(entity, before, actor)
+		changes = append(changes, TaskChange{Task: entity, Record: record})
+	}
+	switch {
+	case len(tree.Subtasks()) == 0:
+	case s.deletePolicy == DeleteRefuse:
+		return fmt.Errorf("%w: task %d cannot be deleted", ErrHasSubtasks, id)
+	case s.deletePolicy == DeleteOrphan:
+		for _, subtask := range tree.Subtasks() {
+			change(subtask.Task(), func(entity *Task) { entity.detach() })
+		}
+	case s.deletePolicy == DeleteCascade:
+		for _, descendant := range tree.descendants() {
+			change(descendant, func(entity *Task) { entity.moveToTrash(now) })
+		}
+	default:
+		return fmt.Errorf("unsupported delete policy %q", s.deletePolicy)
+	}
+	change(tree.Task(), func(entity *Task) { entity.moveToTrash(now) })
+
+	return notFound(s.repository.SaveAll(changes))
+}
+
+// ListTrash returns deleted tasks, which can still be restored.
+func (s *Service) ListTrash() ([]Task, error) {
+	return s.repository.List(ListFilter{Deleted: true})
+}
+
+// RestoreTask takes the task out of the trash, along with subtasks deleted at the same time.
+// When its parent is no longer available, the task is restored as a top level one.
+// The task and its subtasks are saved at once, so either all of them are restored or none is.
+func (s *Service) RestoreTask(id ID, actor string) (Task, error) {
+	entity, err := s.repository.GetFromTrash(id)
+	if err != nil {
+		return Task{}, notFound(err)
+	}
+	before := entity
+	if entity.ParentID() != nil {
+		_, err = s.repository.GetByID(*entity.ParentID())
+		if errors.Is(err, ErrNotFound) {
+			entity.detach()
+		} else if err != nil {
+			return Task{}, err
+		}
+	}
+
+	restored, changes, err := s.restore(entity, before, *entity.DeletedAt(), actor, map[ColumnID]int{})
+	if err != nil {
+		return Task{}, err
+	}
+	err = s.repository.SaveAll(changes)
+	if err != nil {
+		return Task{}, notFound(err)
+	}
+
+	return restored, nil
+}
+
+// restore returns the changes taking the task and its subtasks deleted at the same time out of the trash, along with
+// the task as it is once saved. Entering counts the tasks restored into each column so far, which are not saved yet.
+func (s *Service) restore(
+	entity Task,
+	before Task,
+	deletedAt time.Time,
+	actor string,
+	entering map[ColumnID]int,
+) (Task, []TaskChange, error) {
+	if entity.ProjectID() != nil && entity.ColumnID() != nil {
+		column, err := s.placeIntoColumn(*entity.ProjectID(), entity.ColumnID(), nil, entering[*entity.ColumnID()])
+		if err != nil {
+			return Task{}, nil, err
+		}
+		entering[column]++
+	}
+	entity.restore()
+	restored, record := recordChanged(entity, before, actor)
+	changes := []TaskChange{{Task: entity, Record: record}}
+
+	children, err := s.repository.List(ListFilter{ParentID: idPointer(entity.Id()), Deleted: true})
+	if err != nil {
+		return Task{}, nil, err
+	}
+	for _, child := range children {
+		if child.DeletedAt() == nil || !child.DeletedAt().Equal(deletedAt) {
+			continue
+		}
+		_, childChanges, err := s.restore(child, child, deletedAt, actor, entering)
+		if err != nil {
+			return Task{}, nil, err
+		}
+		changes = append(changes, childChanges...)
+	}
+
+	return restored, changes, nil
+}
+
+// PurgeTrash permanently removes tasks deleted before the given time. It returns how many were removed.
+func (s *Service) PurgeTrash(deletedBefore time.Time) (int, error) {
+	deleted, err := s.repository.List(ListFilter{Deleted: true})
+	if err != nil {
+		return 0, err
+	}
+
+	count := 0
+	for _, entity := range deleted {
+		if !entity.DeletedAt().Before(deletedBefore) {
+			continue
+		}
+		purged := TaskDeleted{TaskID: entity.Id(), Permanent: true, At: time.Now()}
+		err = s.repository.Delete(entity.Id(), ChangeRecord{Events: []eventbus.Event{purged}})
+		if errors.Is(err, ErrNotFound) {
+			continue
+		}
+		if err != nil {
+			return count, err
+		}
+		count++
+	}
+
+	return count, nil
+}
+
+// ListRevisions returns the history of the task, oldest first. Tasks in the trash have their history kept.
+func (s *Service) ListRevisions(id ID) ([]Revision, error) {
+	_, err := s.repository.GetByID(id)
+	if errors.Is(err, ErrNotFound) {
+		_, err = s.repository.GetFromTrash(id)
+	}
+	if err != nil {
+		return nil, notFound(err)
+	}
+
+	return s.revisions.List(id)
+}
+
+func (s *Service) GetRevision(id ID, number Version) (Revision, error) {
+	revision, err := s.revisions.Get(id, number)
+	if err != nil {
+		return Revision{}, notFound(err)
+	}
+
+	return revision, nil
+}
+
+// DiffRevisions lists fields which differ between two revisions of the task. Their order does not matter.
+func (s *Service) DiffRevisions(id ID, from Version, to Version) ([]FieldChange, error) {
+	fromRevision, err := s.GetRevision(id, from)
+	if err != nil {
+		return nil, err
+	}
+	toRevision, err := s.GetRevision(id, to)
+	if err != nil {
+		return nil, err
+	}
+
+	return diffStates(fromRevision.State(), toRevision.State()), nil
+}
+
+// RevertTask brings back fields of the task as they were at the given revision. The revert is a new revision itself.
+// Status is left as it is, since it changes only by transitions. Tasks in the trash have to be restored first.
+func (s *Service) RevertTask(id ID, number Version, actor string) (Task, error) {
+	revision, err := s.GetRevision(id, number)
+	if err != nil {
+		return Task{}, err
+	}
+	state := revision.State()
+	var rule *RecurrenceRule
+	if state.Recurrence != nil {
+		parsed, err := ParseRecurrenceRule(*state.Recurrence)
+		if err != nil {
+			return Task{}, err
+		}
+		rule = &parsed
+	}
+
+	command, err := NewUpdateTaskCommand(
+		id,
+		state.Title,
+		state.Description,
+		state.DueAt,
+		state.ParentID,
+		state.Tags,
+		rule,
+		state.ProjectID,
+		state.ColumnID,
+		nil,
+	)
+	if err != nil {
+		return Task{}, err
+	}
+	return s.UpdateTask(command, actor)
+}
+
+func (s *Service) AddChecklistItem(id ID, text string, actor string) (Task, ChecklistItem, error) {
+	var item ChecklistItem
+	entity, err := s.changeChecklist(id, actor, func(entity *Task) error {
+		var err error
+		item, err = entity.AddChecklistItem(text)
+		return err
+	})
+	return entity, item, err
+}
+
+func (s *Service) ToggleChecklistItem(id ID, itemID ChecklistItemID, actor string) (Task, error) {
+	return s.changeChecklist(id, actor, func(entity *Task) error {
+		_, err := entity.ToggleChecklistItem(itemID)
+		return err
+	})
+}
+
+func (s *Service) MoveChecklistItem(id ID, itemID ChecklistItemID, position int, actor string) (Task, error) {
+	return s.changeChecklist(id, actor, func(entity *Task) error {
+		return entity.MoveChecklistItem(itemID, position)
+	})
+}
+
+func (s *Service) RemoveChecklistItem(id ID, itemID ChecklistItemID, actor string) (Task, error) {
+	return s.changeChecklist(id, actor, func(entity *Task) error {
+		return entity.RemoveChecklistItem(itemID)
+	})
+}
+
+func (s *Service) changeChecklist(id ID, actor string, change func(entity *Task) error) (Task, error) {
+	entity, err := s.GetTask(id)
+	if err != nil {
+		return Task{}, err
+	}
+
+	before := entity
+	err = change(&entity)
+	if err != nil {
+		return Task{}, err
+	}
+	err = s.save(&entity, before, actor)
+	if err != nil {
+		return Task{}, notFound(err)
+	}
+
+	return entity, nil
+}
+
+// MoveTask changes the manual order of tasks in the column of a project board, or of the tasks outside boards.
+// Only the moved task gets a new rank, unless the ranks around are too close to each other. All tasks of the column
+// are rebalanced then. Ranks are not part of the history, so changing them does not change the version of any task.
+// Moving the task to another column does. The new column and the ranks are saved at once, once the anchors are found.
+func (s *Service) MoveTask(command MoveTaskCommand, actor string) (Task, error) {
+	entity, err := s.GetTask(command.Id())
+	if err != nil {
+		return Task{}, err
+	}
+	before := entity
+	if command.ColumnID() != nil && !sameColumnID(command.ColumnID(), entity.ColumnID()) {
+		if entity.ProjectID() == nil {
+			return Task{}, fmt.Errorf("%w: task %d is not in a project", ErrValidation, entity.Id())
+		}
+		column, err := s.placeIntoColumn(*entity.ProjectID(), command.ColumnID(), entity.ColumnID(), 0)
+		if err != nil {
+			return Task{}, err
+		}
+		entity.moveToColumn(column)
+	}
+	ranks := map[ID]Rank{}
+	if command.After() != nil || command.Before() != nil {
+		ranks, err = s.rankNextToAnchors(entity, command)
+		if err != nil {
+			return Task{}, err
+		}
+		entity.rank = ranks[entity.Id()]
+	}
+
+	if sameColumnID(entity.ColumnID(), before.ColumnID()) {
+		if len(ranks) == 0 {
+			return entity, nil
+		}
+		err = s.repository.SaveRanks(ranks)
+		if err != nil {
+			return Task{}, notFound(err)
+		}
+		return entity, nil
+	}
+	after, record := recordChanged(entity, before, actor)
+	err = s.repository.SaveWithRanks(entity, record, ranks)
+	if err != nil {
+		return Task{}, notFound(err)
+	}
+	return after, nil
+}
+
+// rankNextToAnchors returns ranks putting the task next to the anchors of the command, among the tasks of its column.
+// Usually only the task gets a new rank, but when there is no room for a short enough one, all of them do.
+func (s *Service) rankNextToAnchors(entity Task, command MoveTaskCommand) (map[ID]Rank, error) {
+	tasks, err := s.repository.List(ListFilter{ColumnID: entity.ColumnID()})
+	if err != nil {
+		return nil, err
+	}
+	ordered := make([]Task, 0, len(tasks))
+	for _, other := range tasks {
+		// Without a column, the filter matches all tasks, but only the ones outside boards are ordered along.
+		if other.Id() != entity.Id() && sameColumnID(other.ColumnID(), entity.ColumnID()) {
+			ordered = append(ordered, other)
+		}
+	}
+	position, err := command.position(ordered)
+	if err != nil {
+		return nil, err
+	}
+
+	if rank, ok := rankAt(ordered, position); ok {
+		return map[ID]Rank{entity.Id(): rank}, nil
+	}
+	ordered = append(ordered[:position], append([]Task{entity}, ordered[position:]...)...)
+	ranks := evenRanks(len(ordered))
+	changes := make(map[ID]Rank, len(ordered))
+	for i, other := range ordered {
+		changes[other.Id()] = ranks[i]
+	}
+	return changes, nil
+}
+
+// AddDependency makes one task block another. Adding existing dependency again has no effect.
+func (s *Service) AddDependency(dependency Dependency) error {
+	_, err := s.GetTask(dependency.BlockedID())
+	if err != nil {
+		return err
+	}
+	_, err = s.repository.GetByID(dependency.BlockerID())
+	if errors.Is(err, ErrNotFound) {
+		return fmt.Errorf("%w: blocking task %d does not exist", ErrValidation, dependency.BlockerID())
+	}
+	if err != nil {
+		return err
+	}
+
+	graph, err := s.dependencyGraph()
+	if err != nil {
+		return err
+	}
+	if graph.Contains(dependency) {
+		return nil
+	}
+	err = graph.validate(dependency)
+	if err != nil {
+		return err
+	}
+
+	return s.repository.AddDependency(dependency)
+}
+
+func (s *Service) RemoveDependency(dependency Dependency) error {
+	return notFound(s.repository.RemoveDependency(dependency))
+}
+
+// ListBlockers returns tasks which block the given one, including finished ones.
+func (s *Service) ListBlockers(id ID) ([]Task, error) {
+	_, err := s.GetTask(id)
+	if err != nil {
+		return nil, err
+	}
+	graph, err := s.dependencyGraph()
+	if err != nil {
+		return nil, err
+	}
+
+	return s.getTasks(graph.Blockers(id))
+}
+
+// ListDependents returns tasks blocked by the given one.
+func (s *Service) ListDependents(id ID) ([]Task, error) {
+	_, err := s.GetTask(id)
+	if err != nil {
+		return nil, err
+	}
+	graph, err := s.dependencyGraph()
+	if err != nil {
+		return nil, err
+	}
+
+	return s.getTasks(graph.Dependents(id))
+}
+
+// ListActionableTasks answers "what can I work on now": open tasks which are neither in blocked status,
+// nor blocked by any open task. They come in manual order, by rank, ties resolved by id.
+func (s *Service) ListActionableTasks() ([]Task, error) {
+	open, err := s.repository.List(ListFilter{Statuses: OpenStatuses()})
+	if err != nil {
+		return nil, err
+	}
+	graph, err := s.dependencyGraph()
+	if err != nil {
+		return nil, err
+	}
+
+	openIDs := make(map[ID]bool, len(open))
+	for _, entity := range open {
+		openIDs[entity.Id()] = true
+	}
+	result := make([]Task, 0, len(open))
+	for _, entity := range open {
+		blocked := entity.Status() == StatusBlocked
+		for _, blockerID := range graph.Blockers(entity.Id()) {
+			blocked = blocked || openIDs[blockerID]
+		}
+		if !blocked {
+			result = append(result, entity)
+		}
+	}
+
+	return result, nil
+}
+
+// ListTags returns all tags along with number of tasks labeled with them.
+func (s *Service) ListTags() ([]TagUsage, error) {
+	return s.repository.ListTags()
+}
+
+// RenameTag changes tag name on all tasks. Renaming to an existing tag is refused, MergeTags is meant for that.
+func (s *Service) RenameTag(from string, to string) error {
+	from, to, err := normalizeTagPair(from, to)
+	if err != nil {
+		return err
+	}
+	if from == to {
+		return nil
+	}
+
+	return notFound(s.repository.RenameTag(from, to))
+}
+
+// MergeTags replaces one tag with another on all tasks, removing the former.
+func (s *Service) MergeTags(from string, into string) error {
+	from, into, err := normalizeTagPair(from, into)
+	if err != nil {
+		return err
+	}
+	if from == into {
+		return fmt.Errorf("%w: tag cannot be merged into itself", ErrValidation)
+	}
+
+	return notFound(s.repository.MergeTags(from, into))
+}
+
+func (s *Service) dependencyGraph() (DependencyGraph, error) {
+	dependencies, err := s.repository.ListDependencies()
+	if err != nil {
+		return DependencyGraph{}, err
+	}
+
+	return NewDependencyGraph(dependencies), nil
+}
+
+// getTasks loads tasks by ids, skipping the ones which no longer exist.
+func (s *Service) getTasks(ids []ID) ([]Task, error) {
+	result := make([]Task, 0, len(ids))
+	for _, id := range ids {
+		entity, err := s.repository.GetByID(id)
+		if errors.Is(err, ErrNotFound) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, entity)
+	}
+
+	return result, nil
+}
+
+func (s *Service) tree(entity Task) (Tree, error) {
+	children, err := s.repository.List(ListFilter{ParentID: idPointer(entity.Id())})
+	if err != nil {
+		return Tree{}, err
+	}
+
+	subtasks := make([]Tree, len(children))
+	for i, child := range children {
+		subtasks[i], err = s.tree(child)
+		if err != nil {
+			return Tree{}, err
+		}
+	}
+
+	return NewTree(entity, subtasks), nil
+}
+
+// ancestors lists the task and its parents up to the top level task.
+// Missing task is reported as validation error, since it's referenced by another task.
+func (s *Service) ancestors(id ID) ([]ID, error) {
+	var result []ID
+	current := &id
+	for current != nil {
+		entity, err := s.repository.GetByID(*current)
+		if errors.Is(err, ErrNotFound) {
+			return nil, fmt.Errorf("%w: parent task %d does not exist", ErrValidation, *current)
+		}
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, entity.Id())
+		// The hierarchy is never deeper than MaxDepth, unless the data is broken. Don't loop forever then.
+		if len(result) > MaxDepth {
+			break
+		}
+		current = entity.ParentID()
+	}
+
+	return result, nil
+}
+
+func (s *Service) validateMove(entity Task, parentID ID) error {
+	ancestors, err := s.ancestors(parentID)
+	if err != nil {
+		return err
+	}
+	tree, err := s.tree(entity)
+	if err != nil {
+		return err
+	}
+
+	return validateParent(entity.Id(), ancestors, tree.Height())
+}
+
+// placeIntoColumn returns the column of the project for a task currently in the given column, if any.
+// Without columnID, the task stays in the current column, or goes to the first one when it comes from elsewhere.
+// A task entering the column is refused when the column is full, counting other tasks entering it at the same time,
+// which are not stored yet.
+func (s *Service) placeIntoColumn(
+	projectID ProjectID,
+	columnID *ColumnID,
+	current *ColumnID,
+	entering int,
+) (ColumnID, error) {
+	project, err := s.projects.GetByID(projectID)
+	if errors.Is(err, ErrNotFound) {
+		return 0, fmt.Errorf("%w: project %d does not exist", ErrValidation, projectID)
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	var column Column
+	var ok bool
+	switch {
+	case columnID != nil:
+		column, ok = project.Column(*columnID)
+		if !ok {
+			return 0, fmt.Errorf("%w: column %d does not belong to project %d", ErrValidation, *columnID, projectID)
+		}
+	case current != nil:
+		column, ok = project.Column(*current)
+	}
+	if !ok {
+		column = project.Columns()[0]
+	}
+	if sameColumnID(&column.id, current) || column.Limit() == 0 {
+		return column.Id(), nil
+	}
+
+	tasks, err := s.repository.List(ListFilter{ColumnID: &column.id})
+	if err != nil {
+		return 0, err
+	}
+	if !column.accepts(len(tasks) + entering) {
+		return 0, fmt.Errorf("%w: %q holds up to %d tasks", ErrColumnFull, column.Name(), column.Limit())
+	}
+	return column.Id(), nil
+}
+
+// add ranks the new task after all existing ones. Tasks added at the same time may share the rank,
+// until one of them is moved.
+func (s *Service) add(command AddTaskCommand, actor string) (Task, error) {
+	ranks, err := s.nextRanks(1)
+	if err != nil {
+		return Task{}, err
+	}
+	command.rank = ranks[0]
+
+	return s.repository.Add(command, recordCreated(actor))
+}
+
+// addTree adds the task along with its subtasks, validating placement of the root task like AddTask does.
+// Subtasks are ranked right after their parent.
+func (s *Service) addTree(command AddTaskTreeCommand, actor string) (Tree, error) {
+	root := command.task
+	ancestors := []ID{}
+	if root.ParentID() != nil {
+		var err error
+		ancestors, err = s.ancestors(*root.ParentID())
+		if err != nil {
+			return Tree{}, err
+		}
+	}
+	err := validateParent(0, ancestors, command.height())
+	if err != nil {
+		return Tree{}, err
+	}
+	if root.ProjectID() != nil {
+		column, err := s.placeIntoColumn(*root.ProjectID(), root.ColumnID(), nil, 0)
+		if err != nil {
+			return Tree{}, err
+		}
+		command.task.columnID = &column
+	}
+
+	ranks, err := s.nextRanks(command.size())
+	if err != nil {
+		return Tree{}, err
+	}
+	command.rank(ranks)
+
+	return s.repository.AddTree(command, recordCreated(actor))
+}
+
+// nextRanks returns count of ranks following the ranks of all tasks, for tasks added one after another.
+// When they would grow too long, all tasks, including the ones in the trash, are rebalanced first. Rebalancing keeps
+// their order, so the ranks hold even when adding the tasks fails afterwards.
+func (s *Service) nextRanks(count int) ([]Rank, error) {
+	last, err := s.repository.LastRank()
+	if err != nil {
+		return nil, err
+	}
+	ranks := ranksAfter(last, count)
+	tooLong := false
+	for _, rank := range ranks {
+		tooLong = tooLong || len(rank) > rankMaxLength
+	}
+	if !tooLong {
+		return ranks, nil
+	}
+
+	tasks, err := s.repository.List(ListFilter{})
+	if err != nil {
+		return nil, err
+	}
+	deleted, err := s.repository.List(ListFilter{Deleted: true})
+	if err != nil {
+		return nil, err
+	}
+	tasks = append(tasks, deleted...)
+	sort.SliceStable(tasks, func(i, j int) bool {
+		if tasks[i].Rank() != tasks[j].Rank() {
+			return tasks[i].Rank() < tasks[j].Rank()
+		}
+		return tasks[i].Id() < tasks[j].Id()
+	})
+	ranks = evenRanks(len(tasks) + count)
+	changes := make(map[ID]Rank, len(tasks))
+	for i, entity := range tasks {
+		changes[entity.Id()] = ranks[i]
+	}
+	err = s.repository.SaveRanks(changes)
+	if err != nil {
+		return nil, err
+	}
+	return ranks[len(tasks):], nil
+}
+
+// recordCreated records the first revision of a new task, along with TaskCreated event.
+func recordCreated(actor string) func(Task) ChangeRecord {
+	return func(entity Task) ChangeRecord {
+		revision := newRevision(nil, entity, actor, entity.CreatedAt())
+		created := TaskCreated{Task: entity, Actor: actor, At: entity.CreatedAt()}
+		return ChangeRecord{Revision: &revision, Events: []eventbus.Event{created}}
+	}
+}
+
+// save stores the task along with the change since before, following the version incremented by the repository.
+func (s *Service) save(entity *Task, before Task, actor string) error {
+	after, record := recordChanged(*entity, before, actor)
+	err := s.repository.Save(*entity, record)
+	if err != nil {
+		return err
+	}
+	*entity = after
+	return nil
+}
+
+// saveWithNext stores the completed occurrence like save does, adding the next one ranked after all existing tasks
+// in the same transaction, so that the series does not end when either of them fails.
+func (s *Service) saveWithNext(entity *Task, before Task, next AddTaskCommand, actor string) error {
+	ranks, err := s.nextRanks(1)
+	if err != nil {
+		return err
+	}
+	next.rank = ranks[0]
+
+	after, record := recordChanged(*entity, before, actor)
+	_, err = s.repository.SaveAndAdd(*entity, record, next, recordCreated(actor))
+	if err != nil {
+		return err
+	}
+	*entity = after
+	return nil
+}
+
+// recordChanged returns the task as it is once saved by the repository, which increments its version,
+// along with the record of the change since before.
+func recordChanged(entity Task, before Task, actor string) (Task, ChangeRecord) {
+	after := entity
+	after.version++
+	revision := newRevision(&before, after, actor, time.Now())
+	record := ChangeRecord{Revision: &revision}
+	if event := newRevisionEvent(revision, after); event != nil {
+		record.Events = append(record.Events, event)
+	}
+	return after, record
+}
+
+// notFound replaces repository specific not found errors with ErrNotFound, passing other errors as they are.
+func notFound(err error) error {
+	if errors.Is(err, ErrNotFound) {
+		return ErrNotFound
+	}
+	return err
+}
+
+func idPointer(id ID) *ID {
+	return &id
+}
+
+func sameID(a *ID, b *ID) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return *a == *b
+}
+
+func normalizeTagPair(a string, b string) (string, string, error) {
+	a, err := NormalizeTag(a)
+	if err != nil {
+		return "", "", err
+	}
+	b, err = NormalizeTag(b)
+	if err != nil {
+		return "", "", err
+	}
+	return a, b, nil
+}
@@ -0,0 +1,1324 @@
+package task_test
+
+import (
+	"demo-app-go/eventbus"
+	"demo-app-go/task"
+	"fmt"
+	"github.com/stretchr/testify/require"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+)
+
+// fakeRepository keeps tasks in a map and fails the same way as storage.TaskRepository when a task is missing.
+// Recorded revisions go to its fakeRevisionRepository, events are collected as they would be in the outbox.
+// Projects the tasks are placed into are kept by its fakeProjectRepository.
+type fakeRepository struct {
+	tasks        map[task.ID]task.Task
+	nextID       task.ID
+	dependencies []task.Dependency
+	revisions    *fakeRevisionRepository
+	projects     *fakeProjectRepository
+	events       []eventbus.Event
+}
+
+func newFakeRepository() *fakeRepository {
+	return &fakeRepository{
+		tasks:     map[task.ID]task.Task{},
+		nextID:    1,
+		revisions: newFakeRevisionRepository(),
+		projects:  newFakeProjectRepository(),
+	}
+}
+
+func newFakeService(policy task.DeletePolicy) (*task.Service, *fakeRepository) {
+	repository := newFakeRepository()
+	return task.NewService(repository, repository.revisions, repository.projects, policy), repository
+}
+
+// noRecord adds tasks without history, like data created before it was recorded.
+func noRecord(task.Task) task.ChangeRecord {
+	return task.ChangeRecord{}
+}
+
+func (r *fakeRepository) List(filter task.ListFilter) ([]task.Task, error) {
+	result := make([]task.Task, 0, len(r.tasks))
+	for id := task.ID(1); id < r.nextID; id++ {
+		if entity, ok := r.tasks[id]; ok && filter.Matches(entity) {
+			result = append(result, entity)
+		}
+	}
+	sort.SliceStable(result, func(i, j int) bool { return result[i].Rank() < result[j].Rank() })
+	return result, nil
+}
+
+func (r *fakeRepository) GetByID(id task.ID) (task.Task, error) {
+	entity, ok := r.tasks[id]
+	if !ok || entity.DeletedAt() != nil {
+		return task.Task{}, wrapNotFound()
+	}
+	return entity, nil
+}
+
+func (r *fakeRepository) GetFromTrash(id task.ID) (task.Task, error) {
+	entity, ok := r.tasks[id]
+	if !ok || entity.DeletedAt() == nil {
+		return task.Task{}, wrapNotFound()
+	}
+	return entity, nil
+}
+
+func (r *fakeRepository) Add(addTask task.AddTaskCommand, record func(task.Task) task.ChangeRecord) (task.Task, error) {
+	return r.insert(addTask, addTask.ParentID(), record), nil
+}
+
+func (r *fakeRepository) AddTree(
+	addTree task.AddTaskTreeCommand,
+	record func(task.Task) task.ChangeRecord,
+) (task.Tree, error) {
+	return r.insertTree(addTree, addTree.Task().ParentID(), record), nil
+}
+
+func (r *fakeRepository) insertTree(
+	addTree task.AddTaskTreeCommand,
+	parentID *task.ID,
+	record func(task.Task) task.ChangeRecord,
+) task.Tree {
+	entity := r.insert(addTree.Task(), parentID, record)
+	id := entity.Id()
+	subtasks := make([]task.Tree, len(addTree.Subtasks()))
+	for i, subtask := range addTree.Subtasks() {
+		subtasks[i] = r.insertTree(subtask, &id, record)
+	}
+	return task.NewTree(entity, subtasks)
+}
+
+func (r *fakeRepository) insert(
+	addTask task.AddTaskCommand,
+	parentID *task.ID,
+	record func(task.Task) task.ChangeRecord,
+) task.Task {
+	entity := task.NewTask(task.Snapshot{
+		Id:          r.nextID,
+		Title:       addTask.Title(),
+		Description: addTask.Description(),
+		Status:      addTask.Status(),
+		DueAt:       addTask.DueAt(),
+		ParentID:    parentID,
+		ProjectID:   addTask.ProjectID(),
+		ColumnID:    addTask.ColumnID(),
+		Tags:        addTask.Tags(),
+		Recurrence:  addTask.Recurrence(),
+		Checklist:   addTask.Checklist(),
+		Rank:        addTask.Rank(),
+		Version:     1,
+		CreatedAt:   addTask.CreatedAt(),
+	})
+	r.tasks[entity.Id()] = entity
+	r.nextID++
+	r.record(record(entity))
+	return entity
+}
+
+func (r *fakeRepository) Save(entity task.Task, record task.ChangeRecord) error {
+	stored, ok := r.tasks[entity.Id()]
+	if !ok {
+		return wrapNotFound()
+	}
+	if stored.Version() != entity.Version() {
+		return task.ErrVersionMismatch
+	}
+	snapshot := entity.Snapshot()
+	snapshot.Version++
+	r.tasks[entity.Id()] = task.NewTask(snapshot)
+	r.record(record)
+	return nil
+}
+
+func (r *fakeRepository) SaveAll(changes []task.TaskChange) error {
+	for _, change := range changes {
+		stored, ok := r.tasks[change.Task.Id()]
+		if !ok {
+			return wrapNotFound()
+		}
+		if stored.Version() != change.Task.Version() {
+			return task.ErrVersionMismatch
+		}
+	}
+	for _, change := range changes {
+		err := r.Save(change.Task, change.Record)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (r *fakeRepository) SaveAndAdd(
+	entity task.Task,
+	record task.ChangeRecord,
+	addTask task.AddTaskCommand,
+	recordAdded func(task.Task) task.ChangeRecord,
+) (task.Task, error) {
+	err := r.Save(entity, record)
+	if err != nil {
+		return task.Task{}, err
+	}
+	return r.insert(addTask, addTask.ParentID(), recordAdded), nil
+}
+
+func (r *fakeRepository) Delete(id task.ID, record task.ChangeRecord) error {
+	if _, ok := r.tasks[id]; !ok {
+		return wrapNotFound()
+	}
+	delete(r.tasks, id)
+	for childID, child := range r.tasks {
+		if child.ParentID() != nil && *child.ParentID() == id {
+			snapshot := child.Snapshot()
+			snapshot.ParentID = nil
+			r.tasks[childID] = task.NewTask(snapshot)
+		}
+	}
+	r.record(record)
+	return nil
+}
+
+func (r *fakeRepository) LastRank() (task.Rank, error) {
+	var result task.Rank
+	for _, entity := range r.tasks {
+		if entity.Rank() > result {
+			result = entity.Rank()
+		}
+	}
+	return result, nil
+}
+
+func (r *fakeRepository) SaveRanks(ranks map[task.ID]task.Rank) error {
+	for id := range ranks {
+		if _, ok := r.tasks[id]; !ok {
+			return wrapNotFound()
+		}
+	}
+	for id, rank := range ranks {
+		snapshot := r.tasks[id].Snapshot()
+		snapshot.Rank = rank
+		r.tasks[id] = task.NewTask(snapshot)
+	}
+	return nil
+}
+
+func (r *fakeRepository) SaveWithRanks(entity task.Task, record task.ChangeRecord, ranks map[task.ID]task.Rank) error {
+	for id := range ranks {
+		if _, ok := r.tasks[id]; !ok {
+			return wrapNotFound()
+		}
+	}
+	err := r.Save(entity, record)
+	if err != nil {
+		return err
+	}
+	return r.SaveRanks(ranks)
+}
+
+func (r *fakeRepository) record(record task.ChangeRecord) {
+	if record.Revision != nil {
+		r.revisions.revisions = append(r.revisions.revisions, *record.Revision)
+	}
+	r.events = append(r.events, record.Events...)
+}
+
+func (r *fakeRepository) ListDependencies() ([]task.Dependency, error) {
+	return r.dependencies, nil
+}
+
+func (r *fakeRepository) AddDependency(dependency task.Dependency) error {
+	r.dependencies = append(r.dependencies, dependency)
+	return nil
+}
+
+func (r *fakeRepository) RemoveDependency(dependency task.Dependency) error {
+	for i, existing := range r.dependencies {
+		if existing == dependency {
+			r.dependencies = append(r.dependencies[:i], r.dependencies[i+1:]...)
+			return nil
+		}
+	}
+	return wrapNotFound()
+}
+
+func (r *fakeRepository) ListTags() ([]task.TagUsage, error) {
+	counts := map[string]int{}
+	for _, entity := range r.tasks {
+		for _, tag := range entity.Tags() {
+			counts[tag]++
+		}
+	}
+	result := make([]task.TagUsage, 0, len(counts))
+	for name, count := range counts {
+		result = append(result, task.NewTagUsage(name, count))
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Name() < result[j].Name() })
+	return result, nil
+}
+
+func (r *fakeRepository) RenameTag(from string, to string) error {
+	return r.replaceTag(from, to, true)
+}
+
+func (r *fakeRepository) MergeTags(from string, into string) error {
+	return r.replaceTag(from, into, false)
+}
+
+func (r *fakeRepository) replaceTag(from string, to string, mustBeNew bool) error {
+	found := false
+	for _, entity := range r.tasks {
+		found = found || entity.HasTag(from)
+		if mustBeNew && entity.HasTag(to) {
+			return task.ErrTagExists
+		}
+	}
+	if !found {
+		return wrapNotFound()
+	}
+	for id, entity := range r.tasks {
+		if !entity.HasTag(from) {
+			continue
+		}
+		snapshot := entity.Snapshot()
+		snapshot.Tags = append(snapshot.Tags, to)
+		for i, tag := range snapshot.Tags {
+			if tag == from {
+				snapshot.Tags = append(snapshot.Tags[:i], snapshot.Tags[i+1:]...)
+				break
+			}
+		}
+		snapshot.Tags, _ = task.NormalizeTags(snapshot.Tags)
+		r.tasks[id] = task.NewTask(snapshot)
+	}
+	return nil
+}
+
+// fakeRevisionRepository keeps revisions of all tasks in the order they were added.
+type fakeRevisionRepository struct {
+	revisions []task.Revision
+}
+
+func newFakeRevisionRepository() *fakeRevisionRepository {
+	return &fakeRevisionRepository{}
+}
+
+func (r *fakeRevisionRepository) List(taskID task.ID) ([]task.Revision, error) {
+	result := []task.Revision{}
+	for _, revision := range r.revisions {
+		if revision.TaskID() == taskID {
+			result = append(result, revision)
+		}
+	}
+	return result, nil
+}
+
+func (r *fakeRevisionRepository) Get(taskID task.ID, number task.Version) (task.Revision, error) {
+	for _, revision := range r.revisions {
+		if revision.TaskID() == taskID && revision.Number() == number {
+			return revision, nil
+		}
+	}
+	return task.Revision{}, wrapNotFound()
+}
+
+// wrapNotFound mimics repositories returning their own error, which only matches task.ErrNotFound.
+func wrapNotFound() error {
+	return fmt.Errorf("fake resource %w", task.ErrNotFound)
+}
+
+func TestService(t *testing.T) {
+	setup := func(t *testing.T) *task.Service {
+		service, _ := newFakeService(task.DeleteRefuse)
+		for _, title := range []string{"first", "second"} {
+			command, err := task.NewAddTaskCommand(title, "", nil, nil, nil, nil, nil, nil)
+			require.NoError(t, err, "command")
+			_, err = service.AddTask(command, "alice")
+			require.NoError(t, err, "add task")
+		}
+		return service
+	}
+
+	t.Run("ListTasks", func(t *testing.T) {
+		t.Run("returns all tasks", func(t *testing.T) {
+			t.Parallel()
+			service := setup(t)
+
+			result, err := service.ListTasks(task.ListFilter{})
+			require.NoError(t, err, "unexpected error")
+
+			require.Len(t, result, 2)
+			require.Equal(t, "first", result[0].Title())
+			require.Equal(t, "second", result[1].Title())
+		})
+		t.Run("filters by status", func(t *testing.T) {
+			t.Parallel()
+			service := setup(t)
+			_, err := service.TransitionTask(2, task.StatusInProgress, "alice")
+			require.NoError(t, err, "transition")
+
+			result, err := service.ListTasks(task.ListFilter{Statuses: []task.Status{task.StatusInProgress}})
+			require.NoError(t, err, "unexpected error")
+
+			require.Len(t, result, 1)
+			require.Equal(t, task.ID(2), result[0].Id())
+		})
+	})
+
+	t.Run("ListTasks by due date", func(t *testing.T) {
+		t.Parallel()
+		service := setup(t)
+		soon := time.Now().Add(time.Hour)
+		later := time.Now().Add(48 * time.Hour)
+		for _, dueAt := range []time.Time{soon, later} {
+			dueAt := dueAt
+			command, err := task.NewAddTaskCommand("due", "", &dueAt, nil, nil, nil, nil, nil)
+			require.NoError(t, err, "command")
+			_, err = service.AddTask(command, "alice")
+			require.NoError(t, err, "add task")
+		}
+		tomorrow := time.Now().Add(24 * time.Hour)
+
+		result, err := service.ListTasks(task.ListFilter{DueBefore: &tomorrow})
+		require.NoError(t, err, "unexpected error")
+		require.Len(t, result, 1)
+		require.Equal(t, task.ID(3), result[0].Id())
+
+		result, err = service.ListTasks(task.ListFilter{DueAfter: &tomorrow})
+		require.NoError(t, err, "unexpected error")
+		require.Len(t, result, 1)
+		require.Equal(t, task.ID(4), result[0].Id())
+
+		result, err = service.ListTasks(task.ListFilter{OverdueAt: &tomorrow})
+		require.NoError(t, err, "unexpected error")
+		require.Len(t, result, 1)
+		require.Equal(t, task.ID(3), result[0].Id())
+	})
+
+	t.Run("GetTask", func(t *testing.T) {
+		t.Run("returns task", func(t *testing.T) {
+			t.Parallel()
+			service := setup(t)
+
+			result, err := service.GetTask(2)
+			require.NoError(t, err, "unexpected error")
+
+			require.Equal(t, task.ID(2), result.Id())
+			require.Equal(t, "second", result.Title())
+		})
+		t.Run("returns ErrNotFound", func(t *testing.T) {
+			t.Parallel()
+			service := setup(t)
+
+			_, err := service.GetTask(1337)
+			require.Equal(t, task.ErrNotFound, err)
+		})
+	})
+
+	t.Run("AddTask", func(t *testing.T) {
+		t.Parallel()
+		service := setup(t)
+		command, err := task.NewAddTaskCommand("  third ", "description", nil, nil, nil, nil, nil, nil)
+		require.NoError(t, err, "command")
+
+		result, err := service.AddTask(command, "alice")
+		require.NoError(t, err, "unexpected error")
+
+		require.Equal(t, task.ID(3), result.Id())
+		require.Equal(t, "third", result.Title())
+		require.Equal(t, "description", result.Description())
+		require.Nil(t, result.UpdatedAt())
+	})
+
+	t.Run("UpdateTask", func(t *testing.T) {
+		t.Run("updates and saves task", func(t *testing.T) {
+			t.Parallel()
+			service := setup(t)
+			command, err := task.NewUpdateTaskCommand(1, "changed", "description", nil, nil, nil, nil, nil, nil, nil)
+			require.NoError(t, err, "command")
+
+			result, err := service.UpdateTask(command, "alice")
+			require.NoError(t, err, "unexpected error")
+			require.Equal(t, "changed", result.Title())
+			require.NotNil(t, result.UpdatedAt())
+			require.Equal(t, task.Version(2), result.Version())
+
+			stored, err := service.GetTask(1)
+			require.NoError(t, err, "get task")
+			require.Equal(t, result, stored)
+		})
+		t.Run("returns ErrNotFound", func(t *testing.T) {
+			t.Parallel()
+			service := setup(t)
+			command, err := task.NewUpdateTaskCommand(1337, "changed", "", nil, nil, nil, nil, nil, nil, nil)
+			require.NoError(t, err, "command")
+
+			_, err = service.UpdateTask(command, "alice")
+			require.Equal(t, task.ErrNotFound, err)
+		})
+		t.Run("rejects stale expected version", func(t *testing.T) {
+			t.Parallel()
+			service := setup(t)
+			command, err := task.NewUpdateTaskCommand(1, "changed", "", nil, nil, nil, nil, nil, nil, []task.Version{1})
+			require.NoError(t, err, "command")
+			_, err = service.UpdateTask(command, "alice")
+			require.NoError(t, err, "first update")
+
+			_, err = service.UpdateTask(command, "alice")
+			require.ErrorIs(t, err, task.ErrVersionMismatch)
+		})
+	})
+
+	t.Run("TransitionTask", func(t *testing.T) {
+		t.Run("changes and saves status", func(t *testing.T) {
+			t.Parallel()
+			service := setup(t)
+
+			result, err := service.TransitionTask(1, task.StatusDone, "alice")
+			require.NoError(t, err, "unexpected error")
+			require.Equal(t, task.StatusDone, result.Status())
+
+			stored, err := service.GetTask(1)
+			require.NoError(t, err, "get task")
+			require.Equal(t, task.StatusDone, stored.Status())
+		})
+		t.Run("rejects illegal move", func(t *testing.T) {
+			t.Parallel()
+			service := setup(t)
+			_, err := service.TransitionTask(1, task.StatusCancelled, "alice")
+			require.NoError(t, err, "cancel")
+
+			_, err = service.TransitionTask(1, task.StatusDone, "alice")
+			require.ErrorIs(t, err, task.ErrInvalidTransition)
+
+			stored, err := service.GetTask(1)
+			require.NoError(t, err, "get task")
+			require.Equal(t, task.StatusCancelled, stored.Status())
+		})
+		t.Run("returns ErrNotFound", func(t *testing.T) {
+			t.Parallel()
+			service := setup(t)
+
+			_, err := service.TransitionTask(1337, task.StatusDone, "alice")
+			require.Equal(t, task.ErrNotFound, err)
+		})
+	})
+
+	t.Run("subtasks", func(t *testing.T) {
+		// addChain adds tasks nested one under another, under the given parent.
+		addChain := func(t *testing.T, service *task.Service, parentID task.ID, length int) task.ID {
+			for i := 0; i < length; i++ {
+				id := parentID
+				command, err := task.NewAddTaskCommand(fmt.Sprintf("subtask of %d", id), "", nil, &id, nil, nil, nil, nil)
+				require.NoError(t, err, "command")
+				entity, err := service.AddTask(command, "alice")
+				require.NoError(t, err, "add subtask")
+				parentID = entity.Id()
+			}
+			return parentID
+		}
+
+		t.Run("ListSubtasks returns direct children", func(t *testing.T) {
+			t.Parallel()
+			service := setup(t)
+			addChain(t, service, 1, 2)
+			addChain(t, service, 1, 1)
+
+			result, err := service.ListSubtasks(1)
+			require.NoError(t, err, "unexpected error")
+			require.Len(t, result, 2)
+			require.Equal(t, task.ID(3), result[0].Id())
+			require.Equal(t, task.ID(5), result[1].Id())
+		})
+		t.Run("GetTaskTree nests subtasks", func(t *testing.T) {
+			t.Parallel()
+			service := setup(t)
+			addChain(t, service, 1, 2)
+
+			result, err := service.GetTaskTree(1)
+			require.NoError(t, err, "unexpected error")
+			require.Equal(t, 3, result.Height())
+			require.Equal(t, task.ID(3), result.Subtasks()[0].Task().Id())
+			require.Equal(t, task.ID(4), result.Subtasks()[0].Subtasks()[0].Task().Id())
+		})
+		t.Run("AddTask rejects missing parent", func(t *testing.T) {
+			t.Parallel()
+			service := setup(t)
+			parentID := task.ID(1337)
+			command, err := task.NewAddTaskCommand("orphan", "", nil, &parentID, nil, nil, nil, nil)
+			require.NoError(t, err, "command")
+
+			_, err = service.AddTask(command, "alice")
+			require.ErrorIs(t, err, task.ErrValidation)
+		})
+		t.Run("AddTask enforces maximum depth", func(t *testing.T) {
+			t.Parallel()
+			service := setup(t)
+			deepest := addChain(t, service, 1, task.MaxDepth-1)
+			command, err := task.NewAddTaskCommand("too deep", "", nil, &deepest, nil, nil, nil, nil)
+			require.NoError(t, err, "command")
+
+			_, err = service.AddTask(command, "alice")
+			require.ErrorIs(t, err, task.ErrValidation)
+			require.ErrorContains(t, err, "cannot be nested deeper")
+		})
+		t.Run("UpdateTask rejects cycle", func(t *testing.T) {
+			t.Parallel()
+			service := setup(t)
+			grandchild := addChain(t, service, 1, 2)
+			command, err := task.NewUpdateTaskCommand(1, "first", "", nil, &grandchild, nil, nil, nil, nil, nil)
+			require.NoError(t, err, "command")
+
+			_, err = service.UpdateTask(command, "alice")
+			require.ErrorIs(t, err, task.ErrValidation)
+			require.ErrorContains(t, err, "own subtask")
+		})
+		t.Run("UpdateTask enforces maximum depth of moved subtree", func(t *testing.T) {
+			t.Parallel()
+			service := setup(t)
+			addChain(t, service, 2, 2)
+			deepest := addChain(t, service, 1, task.MaxDepth-3)
+			command, err := task.NewUpdateTaskCommand(2, "second", "", nil, &deepest, nil, nil, nil, nil, nil)
+			require.NoError(t, err, "command")
+
+			_, err = service.UpdateTask(command, "alice")
+			require.ErrorIs(t, err, task.ErrValidation)
+		})
+	})
+
+	t.Run("dependencies", func(t *testing.T) {
+		// block makes the first task block the second one.
+		block := func(t *testing.T, service *task.Service, blockerID task.ID, blockedID task.ID) error {
+			dependency, err := task.NewDependency(blockerID, blockedID)
+			require.NoError(t, err, "dependency")
+			return service.AddDependency(dependency)
+		}
+
+		t.Run("blocked task cannot be started until blocker is done", func(t *testing.T) {
+			t.Parallel()
+			service := setup(t)
+			require.NoError(t, block(t, service, 1, 2), "add dependency")
+
+			_, err := service.TransitionTask(2, task.StatusInProgress, "alice")
+			require.ErrorIs(t, err, task.ErrBlocked)
+			_, err = service.TransitionTask(2, task.StatusDone, "alice")
+			require.ErrorIs(t, err, task.ErrBlocked)
+			_, err = service.TransitionTask(2, task.StatusCancelled, "alice")
+			require.NoError(t, err, "cancelling blocked task is allowed")
+
+			_, err = service.TransitionTask(1, task.StatusDone, "alice")
+			require.NoError(t, err, "complete blocker")
+			_, err = service.TransitionTask(2, task.StatusTodo, "alice")
+			require.NoError(t, err, "reopen")
+			_, err = service.TransitionTask(2, task.StatusInProgress, "alice")
+			require.NoError(t, err, "unblocked task can be started")
+		})
+		t.Run("AddDependency rejects cycle", func(t *testing.T) {
+			t.Parallel()
+			service := setup(t)
+			command, err := task.NewAddTaskCommand("third", "", nil, nil, nil, nil, nil, nil)
+			require.NoError(t, err, "command")
+			_, err = service.AddTask(command, "alice")
+			require.NoError(t, err, "add task")
+			require.NoError(t, block(t, service, 1, 2), "add dependency")
+			require.NoError(t, block(t, service, 2, 3), "add dependency")
+
+			err = block(t, service, 3, 1)
+			require.ErrorIs(t, err, task.ErrValidation)
+			require.ErrorContains(t, err, "cycle")
+		})
+		t.Run("AddDependency rejects missing tasks", func(t *testing.T) {
+			t.Parallel()
+			service := setup(t)
+
+			require.Equal(t, task.ErrNotFound, block(t, service, 1, 1337))
+			require.ErrorIs(t, block(t, service, 1337, 1), task.ErrValidation)
+		})
+		t.Run("lists blockers and dependents", func(t *testing.T) {
+			t.Parallel()
+			service := setup(t)
+			require.NoError(t, block(t, service, 1, 2), "add dependency")
+			require.NoError(t, block(t, service, 1, 2), "adding again has no effect")
+
+			blockers, err := service.ListBlockers(2)
+			require.NoError(t, err, "unexpected error")
+			require.Len(t, blockers, 1)
+			require.Equal(t, task.ID(1), blockers[0].Id())
+
+			dependents, err := service.ListDependents(1)
+			require.NoError(t, err, "unexpected error")
+			require.Len(t, dependents, 1)
+			require.Equal(t, task.ID(2), dependents[0].Id())
+
+			dependency, err := task.NewDependency(1, 2)
+			require.NoError(t, err, "dependency")
+			require.NoError(t, service.RemoveDependency(dependency), "remove dependency")
+			require.Equal(t, task.ErrNotFound, service.RemoveDependency(dependency))
+		})
+		t.Run("ListActionableTasks skips blocked and finished tasks", func(t *testing.T) {
+			t.Parallel()
+			service := setup(t)
+			for _, title := range []string{"third", "fourth"} {
+				command, err := task.NewAddTaskCommand(title, "", nil, nil, nil, nil, nil, nil)
+				require.NoError(t, err, "command")
+				_, err = service.AddTask(command, "alice")
+				require.NoError(t, err, "add task")
+			}
+			require.NoError(t, block(t, service, 3, 1), "add dependency")
+			require.NoError(t, block(t, service, 4, 2), "add dependency")
+			_, err := service.TransitionTask(4, task.StatusDone, "alice")
+			require.NoError(t, err, "complete task")
+
+			result, err := service.ListActionableTasks()
+			require.NoError(t, err, "unexpected error")
+			require.Len(t, result, 2)
+			require.Equal(t, task.ID(2), result[0].Id())
+			require.Equal(t, task.ID(3), result[1].Id())
+		})
+		t.Run("ListActionableTasks skips chained and blocked status tasks, keeping manual order", func(t *testing.T) {
+			t.Parallel()
+			service := setup(t)
+			for _, title := range []string{"third", "fourth", "fifth"} {
+				command, err := task.NewAddTaskCommand(title, "", nil, nil, nil, nil, nil, nil)
+				require.NoError(t, err, "command")
+				_, err = service.AddTask(command, "alice")
+				require.NoError(t, err, "add task")
+			}
+			require.NoError(t, block(t, service, 1, 2), "add dependency")
+			require.NoError(t, block(t, service, 2, 3), "add dependency")
+			_, err := service.TransitionTask(4, task.StatusBlocked, "alice")
+			require.NoError(t, err, "block task")
+			before := task.ID(1)
+			move, err := task.NewMoveTaskCommand(5, nil, nil, &before)
+			require.NoError(t, err, "command")
+			_, err = service.MoveTask(move, "alice")
+			require.NoError(t, err, "move task")
+
+			result, err := service.ListActionableTasks()
+			require.NoError(t, err, "unexpected error")
+			ids := make([]task.ID, len(result))
+			for i, entity := range result {
+				ids[i] = entity.Id()
+			}
+			require.Equal(t, []task.ID{5, 1}, ids)
+		})
+	})
+
+	t.Run("recurrence", func(t *testing.T) {
+		setupRecurring := func(t *testing.T, dueAt time.Time) *task.Service {
+			service, _ := newFakeService(task.DeleteRefuse)
+			rule, err := task.ParseRecurrenceRule("FREQ=DAILY;COUNT=3")
+			require.NoError(t, err, "parse rule")
+			command, err := task.NewAddTaskCommand("water plants", "", &dueAt, nil, []string{"home"}, &rule, nil, nil)
+			require.NoError(t, err, "command")
+			_, err = service.AddTask(command, "alice")
+			require.NoError(t, err, "add task")
+			return service
+		}
+
+		t.Run("completing occurrence adds the next one", func(t *testing.T) {
+			t.Parallel()
+			dueAt := time.Now().Add(time.Hour).Truncate(time.Second)
+			service := setupRecurring(t, dueAt)
+
+			completed, err := service.TransitionTask(1, task.StatusDone, "alice")
+			require.NoError(t, err, "unexpected error")
+			require.Nil(t, completed.Recurrence(), "recurrence is handed over to the next occurrence")
+
+			next, err := service.GetTask(2)
+			require.NoError(t, err, "next occurrence")
+			require.Equal(t, "water plants", next.Title())
+			require.Equal(t, task.StatusTodo, next.Status())
+			require.Equal(t, []string{"home"}, next.Tags())
+			require.Equal(t, dueAt.AddDate(0, 0, 1), *next.DueAt())
+			require.Equal(t, dueAt, next.Recurrence().Start(), "series keeps its start")
+
+			_, err = service.TransitionTask(2, task.StatusDone, "alice")
+			require.NoError(t, err, "complete second occurrence")
+			_, err = service.TransitionTask(3, task.StatusDone, "alice")
+			require.NoError(t, err, "complete last occurrence")
+			_, err = service.GetTask(4)
+			require.Equal(t, task.ErrNotFound, err, "series ends after COUNT occurrences")
+		})
+		t.Run("PreviewOccurrences", func(t *testing.T) {
+			t.Parallel()
+			dueAt := time.Now().Add(time.Hour).Truncate(time.Second)
+			service := setupRecurring(t, dueAt)
+
+			result, err := service.PreviewOccurrences(1, 5)
+			require.NoError(t, err, "unexpected error")
+			require.Equal(t, []time.Time{dueAt.AddDate(0, 0, 1), dueAt.AddDate(0, 0, 2)}, result)
+
+			result, err = service.PreviewOccurrences(1, 1)
+			require.NoError(t, err, "unexpected error")
+			require.Len(t, result, 1)
+		})
+		t.Run("recurrence requires due date", func(t *testing.T) {
+			t.Parallel()
+			rule, err := task.ParseRecurrenceRule("FREQ=DAILY")
+			require.NoError(t, err, "parse rule")
+
+			_, err = task.NewAddTaskCommand("water plants", "", nil, nil, nil, &rule, nil, nil)
+			require.ErrorIs(t, err, task.ErrValidation)
+		})
+	})
+
+	t.Run("tags", func(t *testing.T) {
+		setupTags := func(t *testing.T) *task.Service {
+			service, _ := newFakeService(task.DeleteRefuse)
+			for _, tags := range [][]string{{"Bug", "ui "}, {"bug"}, {"feature", "UI"}} {
+				command, err := task.NewAddTaskCommand("tagged", "", nil, nil, tags, nil, nil, nil)
+				require.NoError(t, err, "command")
+				_, err = service.AddTask(command, "alice")
+				require.NoError(t, err, "add task")
+			}
+			return service
+		}
+		ids := func(tasks []task.Task) []task.ID {
+			result := make([]task.ID, len(tasks))
+			for i, entity := range tasks {
+				result[i] = entity.Id()
+			}
+			return result
+		}
+
+		t.Run("ListTasks matches all tags", func(t *testing.T) {
+			t.Parallel()
+			service := setupTags(t)
+
+			result, err := service.ListTasks(task.ListFilter{Tags: []string{"bug", "ui"}})
+			require.NoError(t, err, "unexpected error")
+			require.Equal(t, []task.ID{1}, ids(result))
+		})
+		t.Run("ListTasks matches any tag", func(t *testing.T) {
+			t.Parallel()
+			service := setupTags(t)
+
+			result, err := service.ListTasks(task.ListFilter{Tags: []string{"bug", "feature"}, AnyTag: true})
+			require.NoError(t, err, "unexpected error")
+			require.Equal(t, []task.ID{1, 2, 3}, ids(result))
+		})
+		t.Run("ListTags counts usage", func(t *testing.T) {
+			t.Parallel()
+			service := setupTags(t)
+
+			result, err := service.ListTags()
+			require.NoError(t, err, "unexpected error")
+			require.Equal(t, []task.TagUsage{
+				task.NewTagUsage("bug", 2),
+				task.NewTagUsage("feature", 1),
+				task.NewTagUsage("ui", 2),
+			}, result)
+		})
+		t.Run("RenameTag refuses existing tag", func(t *testing.T) {
+			t.Parallel()
+			service := setupTags(t)
+
+			err := service.RenameTag("UI", " Bug")
+			require.ErrorIs(t, err, task.ErrTagExists)
+			require.Equal(t, task.ErrNotFound, service.RenameTag("missing", "other"))
+		})
+		t.Run("MergeTags", func(t *testing.T) {
+			t.Parallel()
+			service := setupTags(t)
+
+			err := service.MergeTags("ui", "bug")
+			require.NoError(t, err, "unexpected error")
+			first, err := service.GetTask(1)
+			require.NoError(t, err, "get task")
+			require.Equal(t, []string{"bug"}, first.Tags())
+			third, err := service.GetTask(3)
+			require.NoError(t, err, "get task")
+			require.Equal(t, []string{"bug", "feature"}, third.Tags())
+
+			require.ErrorIs(t, service.MergeTags("bug", "BUG"), task.ErrValidation)
+		})
+	})
+
+	t.Run("DeleteTask with subtasks", func(t *testing.T) {
+		setupTree := func(t *testing.T, policy task.DeletePolicy) *task.Service {
+			service, _ := newFakeService(policy)
+			var parentID *task.ID
+			for _, title := range []string{"root", "child", "grandchild"} {
+				command, err := task.NewAddTaskCommand(title, "", nil, parentID, nil, nil, nil, nil)
+				require.NoError(t, err, "command")
+				entity, err := service.AddTask(command, "alice")
+				require.NoError(t, err, "add task")
+				id := entity.Id()
+				parentID = &id
+			}
+			return service
+		}
+
+		t.Run("refuse", func(t *testing.T) {
+			t.Parallel()
+			service := setupTree(t, task.DeleteRefuse)
+
+			err := service.DeleteTask(1, "alice")
+			require.ErrorIs(t, err, task.ErrHasSubtasks)
+			_, err = service.GetTask(1)
+			require.NoError(t, err, "task must not be deleted")
+		})
+		t.Run("orphan", func(t *testing.T) {
+			t.Parallel()
+			service := setupTree(t, task.DeleteOrphan)
+
+			err := service.DeleteTask(1, "alice")
+			require.NoError(t, err, "unexpected error")
+			child, err := service.GetTask(2)
+			require.NoError(t, err, "child must not be deleted")
+			require.Nil(t, child.ParentID())
+			grandchild, err := service.GetTask(3)
+			require.NoError(t, err, "grandchild must not be deleted")
+			require.Equal(t, task.ID(2), *grandchild.ParentID())
+		})
+		t.Run("cascade", func(t *testing.T) {
+			t.Parallel()
+			service := setupTree(t, task.DeleteCascade)
+
+			err := service.DeleteTask(1, "alice")
+			require.NoError(t, err, "unexpected error")
+			result, err := service.ListTasks(task.ListFilter{})
+			require.NoError(t, err, "list tasks")
+			require.Empty(t, result)
+		})
+	})
+
+	t.Run("DeleteTask", func(t *testing.T) {
+		t.Run("deletes task", func(t *testing.T) {
+			t.Parallel()
+			service := setup(t)
+
+			err := service.DeleteTask(1, "alice")
+			require.NoError(t, err, "unexpected error")
+
+			_, err = service.GetTask(1)
+			require.Equal(t, task.ErrNotFound, err)
+		})
+		t.Run("returns ErrNotFound", func(t *testing.T) {
+			t.Parallel()
+			service := setup(t)
+
+			err := service.DeleteTask(1337, "alice")
+			require.Equal(t, task.ErrNotFound, err)
+		})
+	})
+
+	t.Run("history", func(t *testing.T) {
+		setupHistory := func(t *testing.T) *task.Service {
+			service := setup(t)
+			command, err := task.NewUpdateTaskCommand(1, "changed", "details", nil, nil, []string{"b"}, nil, nil, nil, nil)
+			require.NoError(t, err, "command")
+			_, err = service.UpdateTask(command, "bob")
+			require.NoError(t, err, "update task")
+			_, err = service.TransitionTask(1, task.StatusInProgress, "carol")
+			require.NoError(t, err, "transition task")
+			return service
+		}
+
+		t.Run("ListRevisions records every change with its actor", func(t *testing.T) {
+			t.Parallel()
+			service := setupHistory(t)
+
+			result, err := service.ListRevisions(1)
+			require.NoError(t, err, "unexpected error")
+			require.Len(t, result, 3)
+			for i, actor := range []string{"alice", "bob", "carol"} {
+				require.Equal(t, task.Version(i+1), result[i].Number())
+				require.Equal(t, actor, result[i].Actor())
+			}
+			require.Equal(t, "first", result[0].State().Title)
+			require.Equal(t, []task.FieldChange{
+				task.NewFieldChange("title", "first", "changed"),
+				task.NewFieldChange("description", "", "details"),
+				task.NewFieldChange("tags", []string{}, []string{"b"}),
+			}, result[1].Changes())
+			require.Equal(t, []task.FieldChange{
+				task.NewFieldChange("status", task.StatusTodo, task.StatusInProgress),
+			}, result[2].Changes())
+		})
+		t.Run("ListRevisions returns ErrNotFound", func(t *testing.T) {
+			t.Parallel()
+			service := setupHistory(t)
+
+			_, err := service.ListRevisions(1337)
+			require.Equal(t, task.ErrNotFound, err)
+		})
+		t.Run("GetRevision returns ErrNotFound", func(t *testing.T) {
+			t.Parallel()
+			service := setupHistory(t)
+
+			_, err := service.GetRevision(1, 4)
+			require.Equal(t, task.ErrNotFound, err)
+		})
+		t.Run("DiffRevisions compares any two revisions", func(t *testing.T) {
+			t.Parallel()
+			service := setupHistory(t)
+
+			result, err := service.DiffRevisions(1, 3, 1)
+			require.NoError(t, err, "unexpected error")
+			require.Equal(t, []task.FieldChange{
+				task.NewFieldChange("title", "changed", "first"),
+				task.NewFieldChange("description", "details", ""),
+				task.NewFieldChange("status", task.StatusInProgress, task.StatusTodo),
+				task.NewFieldChange("tags", []string{"b"}, []string{}),
+			}, result)
+		})
+		t.Run("RevertTask brings back fields except status", func(t *testing.T) {
+			t.Parallel()
+			service := setupHistory(t)
+
+			result, err := service.RevertTask(1, 1, "dave")
+			require.NoError(t, err, "unexpected error")
+			require.Equal(t, "first", result.Title())
+			require.Equal(t, "", result.Description())
+			require.Empty(t, result.Tags())
+			require.Equal(t, task.StatusInProgress, result.Status())
+
+			revisions, err := service.ListRevisions(1)
+			require.NoError(t, err, "list revisions")
+			require.Len(t, revisions, 4)
+			require.Equal(t, "dave", revisions[3].Actor())
+		})
+	})
+
+	t.Run("trash", func(t *testing.T) {
+		setupTree := func(t *testing.T) *task.Service {
+			service, _ := newFakeService(task.DeleteCascade)
+			var parentID *task.ID
+			for _, title := range []string{"root", "child", "grandchild"} {
+				command, err := task.NewAddTaskCommand(title, "", nil, parentID, nil, nil, nil, nil)
+				require.NoError(t, err, "command")
+				entity, err := service.AddTask(command, "alice")
+				require.NoError(t, err, "add task")
+				id := entity.Id()
+				parentID = &id
+			}
+			return service
+		}
+
+		t.Run("ListTrash returns deleted tasks only", func(t *testing.T) {
+			t.Parallel()
+			service := setup(t)
+			err := service.DeleteTask(1, "alice")
+			require.NoError(t, err, "delete task")
+
+			result, err := service.ListTrash()
+			require.NoError(t, err, "unexpected error")
+			require.Len(t, result, 1)
+			require.Equal(t, task.ID(1), result[0].Id())
+			require.NotNil(t, result[0].DeletedAt())
+
+			result, err = service.ListTasks(task.ListFilter{})
+			require.NoError(t, err, "list tasks")
+			require.Len(t, result, 1)
+			require.Equal(t, task.ID(2), result[0].Id())
+		})
+		t.Run("RestoreTask brings back subtasks deleted along with the task", func(t *testing.T) {
+			t.Parallel()
+			service := setupTree(t)
+			err := service.DeleteTask(3, "alice")
+			require.NoError(t, err, "delete grandchild alone")
+			err = service.DeleteTask(1, "alice")
+			require.NoError(t, err, "delete root")
+
+			result, err := service.RestoreTask(1, "alice")
+			require.NoError(t, err, "unexpected error")
+			require.Nil(t, result.DeletedAt())
+			_, err = service.GetTask(2)
+			require.NoError(t, err, "child must be restored")
+			_, err = service.GetTask(3)
+			require.Equal(t, task.ErrNotFound, err, "grandchild was deleted separately")
+		})
+		t.Run("RestoreTask detaches task from deleted parent", func(t *testing.T) {
+			t.Parallel()
+			service := setupTree(t)
+			err := service.DeleteTask(1, "alice")
+			require.NoError(t, err, "delete root")
+
+			result, err := service.RestoreTask(2, "alice")
+			require.NoError(t, err, "unexpected error")
+			require.Nil(t, result.ParentID())
+			grandchild, err := service.GetTask(3)
+			require.NoError(t, err, "grandchild must be restored")
+			require.Equal(t, task.ID(2), *grandchild.ParentID())
+		})
+		t.Run("RestoreTask returns ErrNotFound for task outside the trash", func(t *testing.T) {
+			t.Parallel()
+			service := setup(t)
+
+			_, err := service.RestoreTask(1, "alice")
+			require.Equal(t, task.ErrNotFound, err)
+		})
+		t.Run("PurgeTrash removes tasks deleted before given time", func(t *testing.T) {
+			t.Parallel()
+			service := setupTree(t)
+			err := service.DeleteTask(3, "alice")
+			require.NoError(t, err, "delete grandchild")
+			cutoff := time.Now()
+			time.Sleep(time.Millisecond)
+			err = service.DeleteTask(1, "alice")
+			require.NoError(t, err, "delete root")
+
+			count, err := service.PurgeTrash(cutoff)
+			require.NoError(t, err, "unexpected error")
+			require.Equal(t, 1, count)
+			_, err = service.RestoreTask(3, "alice")
+			require.Equal(t, task.ErrNotFound, err, "grandchild must be purged")
+			result, err := service.ListTrash()
+			require.NoError(t, err, "list trash")
+			require.Len(t, result, 2)
+		})
+	})
+
+	t.Run("manual order", func(t *testing.T) {
+		setup := func(t *testing.T) *task.Service {
+			service := setup(t)
+			command, err := task.NewAddTaskCommand("third", "", nil, nil, nil, nil, nil, nil)
+			require.NoError(t, err, "command")
+			_, err = service.AddTask(command, "alice")
+			require.NoError(t, err, "add task")
+			return service
+		}
+		move := func(t *testing.T, service *task.Service, id task.ID, after *task.ID, before *task.ID) (task.Task, error) {
+			command, err := task.NewMoveTaskCommand(id, nil, after, before)
+			require.NoError(t, err, "command")
+			return service.MoveTask(command, "alice")
+		}
+		anchor := func(id task.ID) *task.ID {
+			return &id
+		}
+		order := func(t *testing.T, service *task.Service) []string {
+			result, err := service.ListTasks(task.ListFilter{})
+			require.NoError(t, err, "list tasks")
+			titles := make([]string, len(result))
+			for i, entity := range result {
+				titles[i] = entity.Title()
+			}
+			return titles
+		}
+
+		t.Run("new tasks are added at the end", func(t *testing.T) {
+			t.Parallel()
+			service := setup(t)
+			require.Equal(t, []string{"first", "second", "third"}, order(t, service))
+		})
+		t.Run("ranks of appended tasks stay short", func(t *testing.T) {
+			t.Parallel()
+			service, _ := newFakeService(task.DeleteRefuse)
+
+			var last task.Rank
+			for i := 0; i < 3000; i++ {
+				command, err := task.NewAddTaskCommand(fmt.Sprintf("task %d", i), "", nil, nil, nil, nil, nil, nil)
+				require.NoError(t, err, "command")
+				entity, err := service.AddTask(command, "alice")
+				require.NoError(t, err, "add task %d", i)
+				require.Greater(t, entity.Rank(), last, "add task %d", i)
+				require.LessOrEqual(t, len(entity.Rank()), 32, "add task %d", i)
+				last = entity.Rank()
+			}
+		})
+		t.Run("AddTask rebalances ranks which would grow too long", func(t *testing.T) {
+			t.Parallel()
+			service, repository := newFakeService(task.DeleteRefuse)
+			for _, title := range []string{"first", "second"} {
+				command, err := task.NewAddTaskCommand(title, "", nil, nil, nil, nil, nil, nil)
+				require.NoError(t, err, "command")
+				_, err = service.AddTask(command, "alice")
+				require.NoError(t, err, "add task")
+			}
+			require.NoError(t, service.DeleteTask(2, "alice"), "delete task")
+			err := repository.SaveRanks(map[task.ID]task.Rank{1: "y", 2: task.Rank(strings.Repeat("z", 32))})
+			require.NoError(t, err, "save ranks")
+
+			command, err := task.NewAddTaskCommand("third", "", nil, nil, nil, nil, nil, nil)
+			require.NoError(t, err, "command")
+			added, err := service.AddTask(command, "alice")
+			require.NoError(t, err, "unexpected error")
+			require.LessOrEqual(t, len(added.Rank()), 32)
+			first, err := service.GetTask(1)
+			require.NoError(t, err, "get task")
+			deleted, err := service.RestoreTask(2, "alice")
+			require.NoError(t, err, "restore task")
+			require.Less(t, first.Rank(), deleted.Rank(), "order of all tasks must be kept")
+			require.Less(t, deleted.Rank(), added.Rank(), "order of all tasks must be kept")
+		})
+		t.Run("MoveTask puts task next to anchors", func(t *testing.T) {
+			t.Parallel()
+			service := setup(t)
+
+			result, err := move(t, service, 3, nil, anchor(1))
+			require.NoError(t, err, "before first")
+			require.Equal(t, task.Version(1), result.Version(), "version must not change")
+			require.Equal(t, []string{"third", "first", "second"}, order(t, service))
+			_, err = move(t, service, 3, anchor(2), nil)
+			require.NoError(t, err, "after last")
+			require.Equal(t, []string{"first", "second", "third"}, order(t, service))
+			_, err = move(t, service, 3, anchor(1), anchor(2))
+			require.NoError(t, err, "between")
+			require.Equal(t, []string{"first", "third", "second"}, order(t, service))
+		})
+		t.Run("MoveTask rejects invalid anchors", func(t *testing.T) {
+			t.Parallel()
+			service := setup(t)
+
+			_, err := move(t, service, 1, anchor(2), anchor(4))
+			require.ErrorIs(t, err, task.ErrValidation, "missing anchor")
+			_, err = move(t, service, 3, anchor(2), anchor(1))
+			require.ErrorIs(t, err, task.ErrValidation, "anchors not adjacent")
+			_, err = move(t, service, 4, anchor(1), nil)
+			require.Equal(t, task.ErrNotFound, err, "missing task")
+			_, err = task.NewMoveTaskCommand(1, nil, nil, nil)
+			require.ErrorIs(t, err, task.ErrValidation, "no anchor")
+			_, err = task.NewMoveTaskCommand(1, nil, anchor(1), nil)
+			require.ErrorIs(t, err, task.ErrValidation, "own anchor")
+		})
+		t.Run("MoveTask rebalances ranks which grow too long", func(t *testing.T) {
+			t.Parallel()
+			service := setup(t)
+
+			// Moving back and forth between the same tasks halves the room between ranks each time.
+			for i := 0; i < 300; i++ {
+				moved, other := task.ID(2+i%2), task.ID(3-i%2)
+				result, err := move(t, service, moved, anchor(1), anchor(other))
+				require.NoError(t, err, "move %d", i)
+				require.LessOrEqual(t, len(result.Rank()), 32, "move %d", i)
+				titles := map[task.ID]string{1: "first", 2: "second", 3: "third"}
+				require.Equal(t, []string{"first", titles[moved], titles[other]}, order(t, service), "move %d", i)
+			}
+		})
+	})
+	t.Run("CloneTask", func(t *testing.T) {
+		// setup adds task 3 "parent" with description, tag and checklist, under it subtask 4 "child"
+		// with its own subtask 5 "grandchild".
+		setup := func(t *testing.T) *task.Service {
+			service := setup(t)
+			var parentID *task.ID
+			for _, title := range []string{"parent", "child", "grandchild"} {
+				command, err := task.NewAddTaskCommand(title, "details", nil, parentID, []string{"ops"}, nil, nil, nil)
+				require.NoError(t, err, "command")
+				entity, err := service.AddTask(command, "alice")
+				require.NoError(t, err, "add task")
+				id := entity.Id()
+				parentID = &id
+			}
+			_, item, err := service.AddChecklistItem(3, "step", "alice")
+			require.NoError(t, err, "add checklist item")
+			_, err = service.ToggleChecklistItem(3, item.Id(), "alice")
+			require.NoError(t, err, "toggle checklist item")
+			_, err = service.TransitionTask(3, task.StatusInProgress, "alice")
+			require.NoError(t, err, "transition")
+			return service
+		}
+
+		t.Run("copies chosen content of the task only", func(t *testing.T) {
+			t.Parallel()
+			service := setup(t)
+
+			result, err := service.CloneTask(3, task.CloneOptions{Tags: true, Checklist: true}, "bob")
+			require.NoError(t, err, "unexpected error")
+			clone := result.Tree().Task()
+			require.Equal(t, task.ID(6), clone.Id())
+			require.Equal(t, "parent", clone.Title())
+			require.Empty(t, clone.Description())
+			require.Equal(t, []string{"ops"}, clone.Tags())
+			require.Equal(t, task.StatusTodo, clone.Status())
+			require.Len(t, clone.Checklist(), 1)
+			require.Equal(t, "step", clone.Checklist()[0].Text())
+			require.False(t, clone.Checklist()[0].Done())
+			require.Empty(t, result.Tree().Subtasks())
+			require.Equal(t, map[task.ID]task.ID{3: 6}, result.IDs())
+
+			original, err := service.GetTask(3)
+			require.NoError(t, err, "get original")
+			require.True(t, original.Checklist()[0].Done(), "original is unchanged")
+		})
+		t.Run("copies subtasks", func(t *testing.T) {
+			t.Parallel()
+			service := setup(t)
+
+			result, err := service.CloneTask(4, task.CloneOptions{Description: true, Subtasks: true}, "bob")
+			require.NoError(t, err, "unexpected error")
+			require.Equal(t, map[task.ID]task.ID{4: 6, 5: 7}, result.IDs())
+			require.Equal(t, task.ID(3), *result.Tree().Task().ParentID(), "copy stays under the same parent")
+			grandchild := result.Tree().Subtasks()[0].Task()
+			require.Equal(t, task.ID(6), *grandchild.ParentID())
+			require.Equal(t, "details", grandchild.Description())
+			require.Empty(t, grandchild.Tags())
+
+			subtasks, err := service.ListSubtasks(3)
+			require.NoError(t, err, "list subtasks")
+			require.Len(t, subtasks, 2)
+		})
+		t.Run("refuses missing task", func(t *testing.T) {
+			t.Parallel()
+			service := setup(t)
+
+			_, err := service.CloneTask(6, task.CloneOptions{}, "bob")
+			require.Equal(t, task.ErrNotFound, err)
+		})
+	})
+	t.Run("records events of saved changes in order", func(t *testing.T) {
+		t.Parallel()
+		service, repository := newFakeService(task.DeleteRefuse)
+		command, err := task.NewAddTaskCommand("first", "", nil, nil, nil, nil, nil, nil)
+		require.NoError(t, err, "command")
+		_, err = service.AddTask(command, "alice")
+		require.NoError(t, err, "add task")
+		_, err = service.TransitionTask(1, task.StatusInProgress, "bob")
+		require.NoError(t, err, "transition task")
+		update, err := task.NewUpdateTaskCommand(1, "first", "", nil, nil, nil, nil, nil, nil, nil)
+		require.NoError(t, err, "command")
+		_, err = service.UpdateTask(update, "bob")
+		require.NoError(t, err, "update without changes")
+		err = service.DeleteTask(1, "carol")
+		require.NoError(t, err, "delete task")
+		count, err := service.PurgeTrash(time.Now().Add(time.Second))
+		require.NoError(t, err, "purge trash")
+		require.Equal(t, 1, count)
+
+		require.Len(t, repository.events, 4)
+		created, ok := repository.events[0].(task.TaskCreated)
+		require.True(t, ok, "created")
+		require.Equal(t, "first", created.Task.Title())
+		require.Equal(t, "alice", created.Actor)
+		updated, ok := repository.events[1].(task.TaskUpdated)
+		require.True(t, ok, "updated")
+		require.Equal(t, []task.FieldChange{
+			task.NewFieldChange("status", task.StatusTodo, task.StatusInProgress),
+		}, updated.Changes)
+		deleted, ok := repository.events[2].(task.TaskDeleted)
+		require.True(t, ok, "deleted")
+		require.Equal(t, task.TaskDeleted{TaskID: 1, Actor: "carol", At: deleted.At}, deleted)
+		purged, ok := repository.events[3].(task.TaskDeleted)
+		require.True(t, ok, "purged")
+		require.True(t, purged.Permanent)
+		for _, event := range repository.events {
+			require.Equal(t, repository.events[0].Key(), event.Key())
+		}
+	})
+	t.Run("checklist changes are recorded in history", func(t *testing.T) {
+		t.Parallel()
+		service := setup(t)
+
+		_, item, err := service.AddChecklistItem(1, "step", "alice")
+		require.NoError(t, err, "add item")
+		result, err := service.ToggleChecklistItem(1, item.Id(), "bob")
+		require.NoError(t, err, "unexpected error")
+		require.True(t, result.Checklist()[0].Done())
+		_, err = service.RemoveChecklistItem(1, 2, "bob")
+		require.ErrorIs(t, err, task.ErrNotFound, "missing item")
+
+		revisions, err := service.ListRevisions(1)
+		require.NoError(t, err, "list revisions")
+		require.Len(t, revisions, 3)
+		require.Equal(t, []task.FieldChange{
+			task.NewFieldChange("checklist", []string{"[ ] step"}, []string{"[x] step"}),
+		}, revisions[2].Changes())
+	})
+}
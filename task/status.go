@@ -0,0 +1,57 @@
+package task
+
+import (
+	"errors"
+	"fmt"
+)
+
+var ErrInvalidTransition = errors.New("invalid status transition")
+
+type Status string
+
+const (
+	StatusTodo       Status = "todo"
+	StatusInProgress Status = "in_progress"
+	StatusBlocked    Status = "blocked"
+	StatusDone       Status = "done"
+	StatusCancelled  Status = "cancelled"
+)
+
+// transitions lists statuses reachable from the given status.
+// Finished work can only be reopened, so cancelled task has to go through todo before it is done.
+var transitions = map[Status][]Status{
+	StatusTodo:       {StatusInProgress, StatusBlocked, StatusDone, StatusCancelled},
+	StatusInProgress: {StatusTodo, StatusBlocked, StatusDone, StatusCancelled},
+	StatusBlocked:    {StatusTodo, StatusInProgress, StatusCancelled},
+	StatusDone:       {StatusTodo},
+	StatusCancelled:  {StatusTodo},
+}
+
+// ParseStatus validates status coming from outside, e.g. request or database.
+func ParseStatus(value string) (Status, error) {
+	status := Status(value)
+	if _, ok := transitions[status]; !ok {
+		return "", fmt.Errorf("%w: unknown status %q", ErrValidation, value)
+	}
+	return status, nil
+}
+
+// CanTransitionTo reports whether the status can be changed to the target one.
+func (s Status) CanTransitionTo(target Status) bool {
+	for _, allowed := range transitions[s] {
+		if allowed == target {
+			return true
+		}
+	}
+	return false
+}
+
+// OpenStatuses lists statuses of work which is not finished yet.
+func OpenStatuses() []Status {
+	return []Status{StatusTodo, StatusInProgress, StatusBlocked}
+}
+
+// IsOpen reports whether the work is not finished yet.
+func (s Status) IsOpen() bool {
+	return s != StatusDone && s != StatusCancelled
+}
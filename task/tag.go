@@ -0,0 +1,68 @@
+package task
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"unicode/utf8"
+)
+
+const tagMaxLength = 50
+
+var ErrTagExists = errors.New("tag already exists")
+
+// NormalizeTag trims and lowercases the tag, so "Bug " and "bug" are the same tag.
+func NormalizeTag(tag string) (string, error) {
+	tag = strings.ToLower(strings.TrimSpace(tag))
+	if tag == "" {
+		return "", fmt.Errorf("%w: tag must not be empty", ErrValidation)
+	}
+	if utf8.RuneCountInString(tag) > tagMaxLength {
+		return "", fmt.Errorf("%w: tag must not be longer than %d characters", ErrValidation, tagMaxLength)
+	}
+	return tag, nil
+}
+
+// NormalizeTags normalizes each tag, removes duplicates and sorts them.
+func NormalizeTags(tags []string) ([]string, error) {
+	unique := make(map[string]bool, len(tags))
+	result := make([]string, 0, len(tags))
+	for _, tag := range tags {
+		tag, err := NormalizeTag(tag)
+		if err != nil {
+			return nil, err
+		}
+		if unique[tag] {
+			continue
+		}
+		unique[tag] = true
+		result = append(result, tag)
+	}
+	sort.Strings(result)
+	return result, nil
+}
+
+// TagUsage tells how many tasks are labeled with the tag.
+type TagUsage struct {
+	name  string
+	count int
+}
+
+func NewTagUsage(name string, count int) TagUsage {
+	return TagUsage{name: name, count: count}
+}
+
+func (u TagUsage) Name() string {
+	return u.name
+}
+
+func (u TagUsage) Count() int {
+	return u.count
+}
+
+func copyTags(tags []string) []string {
+	result := make([]string, len(tags))
+	copy(result, tags)
+	return result
+}
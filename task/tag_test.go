@@ -0,0 +1,41 @@
+package task_test
+
+import (
+	"demo-app-go/task"
+	"github.com/stretchr/testify/require"
+	"strings"
+	"testing"
+)
+
+func TestNormalizeTags(t *testing.T) {
+	t.Run("trims, lowercases, removes duplicates and sorts", func(t *testing.T) {
+		t.Parallel()
+		result, err := task.NormalizeTags([]string{" UI", "bug", "Bug ", "ui", "Ärger"})
+		require.NoError(t, err, "unexpected error")
+		require.Equal(t, []string{"bug", "ui", "ärger"}, result)
+	})
+	t.Run("no tags", func(t *testing.T) {
+		t.Parallel()
+		result, err := task.NormalizeTags(nil)
+		require.NoError(t, err, "unexpected error")
+		require.Empty(t, result)
+	})
+	t.Run("fails at validation", func(t *testing.T) {
+		samples := map[string]struct {
+			tag           string
+			expectedError string
+		}{
+			"empty tag":    {tag: "  ", expectedError: "tag must not be empty"},
+			"too long tag": {tag: strings.Repeat("ą", 51), expectedError: "tag must not be longer than 50 characters"},
+		}
+		for name, sample := range samples {
+			sample := sample
+			t.Run(name, func(t *testing.T) {
+				t.Parallel()
+				_, err := task.NormalizeTags([]string{"valid", sample.tag})
+				require.ErrorIs(t, err, task.ErrValidation)
+				require.ErrorContains(t, err, sample.expectedError)
+			})
+		}
+	})
+}
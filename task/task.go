@@ -1,24 +1,214 @@
 package task
 
-import "time"
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+var (
+	ErrNotFound   = errors.New("not found")
+	ErrValidation = errors.New("validation failed")
+	// ErrVersionMismatch means the task was changed by someone else since it was read.
+	ErrVersionMismatch = errors.New("version mismatch")
+)
 
 type ID uint
 
+// Version is incremented by every saved change of a task. Tasks start at version 1.
+type Version uint
+
 type Task struct {
 	id          ID
 	title       string
 	description string
-	createdAt   time.Time
-	updatedAt   *time.Time
+	status      Status
+	dueAt       *time.Time
+	parentID    *ID
+	projectID   *ProjectID
+	columnID    *ColumnID
+	tags        []string
+	recurrence  *Recurrence
+	checklist   []ChecklistItem
+	rank        Rank
+	// commentCount is read only, comments are managed by CommentService.
+	commentCount int
+	version      Version
+	createdAt    time.Time
+	updatedAt    *time.Time
+	deletedAt    *time.Time
+}
+
+// Snapshot holds the entire state of a Task, as it is persisted.
+// It's meant for repositories restoring tasks. Changes to existing tasks go through Task methods.
+type Snapshot struct {
+	Id          ID
+	Title       string
+	Description string
+	Status      Status
+	DueAt       *time.Time
+	ParentID    *ID
+	// ProjectID and ColumnID are either both set or both nil.
+	ProjectID  *ProjectID
+	ColumnID   *ColumnID
+	Tags       []string
+	Recurrence *Recurrence
+	// Checklist is ordered by position.
+	Checklist []ChecklistItem
+	Rank      Rank
+	// CommentCount is not persisted along with the task, but counted when it is loaded.
+	CommentCount int
+	Version      Version
+	CreatedAt    time.Time
+	UpdatedAt    *time.Time
+	DeletedAt    *time.Time
+}
+
+func NewTask(snapshot Snapshot) Task {
+	return Task{
+		id:           snapshot.Id,
+		title:        snapshot.Title,
+		description:  snapshot.Description,
+		status:       snapshot.Status,
+		dueAt:        snapshot.DueAt,
+		parentID:     snapshot.ParentID,
+		projectID:    snapshot.ProjectID,
+		columnID:     snapshot.ColumnID,
+		tags:         copyTags(snapshot.Tags),
+		recurrence:   snapshot.Recurrence,
+		checklist:    copyChecklist(snapshot.Checklist),
+		rank:         snapshot.Rank,
+		commentCount: snapshot.CommentCount,
+		version:      snapshot.Version,
+		createdAt:    snapshot.CreatedAt,
+		updatedAt:    snapshot.UpdatedAt,
+		deletedAt:    snapshot.DeletedAt,
+	}
+}
+
+// Snapshot returns copy of the task's state.
+func (t Task) Snapshot() Snapshot {
+	return Snapshot{
+		Id:           t.id,
+		Title:        t.title,
+		Description:  t.description,
+		Status:       t.status,
+		DueAt:        t.dueAt,
+		ParentID:     t.parentID,
+		ProjectID:    t.projectID,
+		ColumnID:     t.columnID,
+		Tags:         copyTags(t.tags),
+		Recurrence:   t.recurrence,
+		Checklist:    copyChecklist(t.checklist),
+		Rank:         t.rank,
+		CommentCount: t.commentCount,
+		Version:      t.version,
+		CreatedAt:    t.createdAt,
+		UpdatedAt:    t.updatedAt,
+		DeletedAt:    t.deletedAt,
+	}
+}
+
+// Update changes the task, unless the command expects another version than the current one.
+func (t *Task) Update(command UpdateTaskCommand) error {
+	if !command.expects(t.version) {
+		return fmt.Errorf("%w: expected one of %v, but task is at %d", ErrVersionMismatch, command.ExpectedVersions(), t.version)
+	}
+	err := validateDueAt(command.DueAt(), t.createdAt)
+	if err != nil {
+		return err
+	}
+
+	t.title = command.Title()
+	t.description = command.Description()
+	t.dueAt = command.DueAt()
+	t.parentID = command.ParentID()
+	t.projectID = command.ProjectID()
+	t.columnID = command.ColumnID()
+	t.tags = copyTags(command.Tags())
+	t.recurrence = t.updatedRecurrence(command.RecurrenceRule(), command.DueAt())
+	t.touch()
+	return nil
+}
+
+// updatedRecurrence keeps the start of the series, unless the rule itself is changed.
+func (t Task) updatedRecurrence(rule *RecurrenceRule, dueAt *time.Time) *Recurrence {
+	if rule == nil || dueAt == nil {
+		return nil
+	}
+	if t.recurrence != nil && t.recurrence.rule.String() == rule.String() {
+		return t.recurrence
+	}
+	recurrence := NewRecurrence(*rule, *dueAt)
+	return &recurrence
 }
 
-func NewTask(id ID, title string, description string, createdAt time.Time, updatedAt *time.Time) Task {
-	return Task{id: id, title: title, description: description, createdAt: createdAt, updatedAt: updatedAt}
+// TransitionTo changes the status, unless the move is not allowed by the task lifecycle.
+func (t *Task) TransitionTo(status Status) error {
+	if !t.status.CanTransitionTo(status) {
+		return fmt.Errorf("%w: from %s to %s", ErrInvalidTransition, t.status, status)
+	}
+	t.status = status
+	t.touch()
+	return nil
 }
 
-func (t *Task) Update(title string, description string) {
-	t.title = title
-	t.description = description
+// completeOccurrence hands the recurrence over to the next occurrence, which is returned as a command.
+// Occurrences missed while the task was overdue are skipped, so the next one is never due in the past.
+// The next occurrence stays in the column of the task, even beyond its limit, so completing the task never fails on it.
+func (t *Task) completeOccurrence(now time.Time) (AddTaskCommand, bool) {
+	if t.recurrence == nil || t.dueAt == nil {
+		return AddTaskCommand{}, false
+	}
+	after := *t.dueAt
+	if now.After(after) {
+		after = now
+	}
+	next := t.recurrence.Occurrences(after, 1)
+	recurrence := t.recurrence
+	t.recurrence = nil
+	if len(next) == 0 {
+		return AddTaskCommand{}, false
+	}
+
+	return AddTaskCommand{
+		title:       t.title,
+		description: t.description,
+		status:      StatusTodo,
+		dueAt:       &next[0],
+		parentID:    t.parentID,
+		projectID:   t.projectID,
+		columnID:    t.columnID,
+		tags:        copyTags(t.tags),
+		recurrence:  recurrence,
+		createdAt:   now,
+	}, true
+}
+
+// moveToTrash marks the task as deleted. It can be restored until the trash is purged.
+func (t *Task) moveToTrash(at time.Time) {
+	t.deletedAt = &at
+	t.touch()
+}
+
+func (t *Task) restore() {
+	t.deletedAt = nil
+	t.touch()
+}
+
+func (t *Task) moveToColumn(id ColumnID) {
+	t.columnID = &id
+	t.touch()
+}
+
+// detach turns the task into top level one, when its parent is deleted.
+func (t *Task) detach() {
+	t.parentID = nil
+	t.touch()
+}
+
+func (t *Task) touch() {
 	now := time.Now()
 	t.updatedAt = &now
 }
@@ -35,6 +225,69 @@ func (t Task) Description() string {
 	return t.description
 }
 
+func (t Task) Status() Status {
+	return t.status
+}
+
+// DueAt is optional. Its location is the timezone the due date was set in.
+func (t Task) DueAt() *time.Time {
+	return t.dueAt
+}
+
+// IsOverdue reports whether the task is still open after its due date.
+func (t Task) IsOverdue(now time.Time) bool {
+	return t.dueAt != nil && t.status.IsOpen() && now.After(*t.dueAt)
+}
+
+// ParentID is set for subtasks only.
+func (t Task) ParentID() *ID {
+	return t.parentID
+}
+
+// ProjectID is set for tasks on a project board.
+func (t Task) ProjectID() *ProjectID {
+	return t.projectID
+}
+
+// ColumnID is set along with ProjectID.
+func (t Task) ColumnID() *ColumnID {
+	return t.columnID
+}
+
+// Tags are normalized, see NormalizeTags.
+func (t Task) Tags() []string {
+	return copyTags(t.tags)
+}
+
+// HasTag expects normalized tag.
+func (t Task) HasTag(tag string) bool {
+	for _, existing := range t.tags {
+		if existing == tag {
+			return true
+		}
+	}
+	return false
+}
+
+// Recurrence is set for repeating tasks only.
+func (t Task) Recurrence() *Recurrence {
+	return t.recurrence
+}
+
+// Rank orders the task among others, see Service.MoveTask.
+func (t Task) Rank() Rank {
+	return t.rank
+}
+
+func (t Task) CommentCount() int {
+	return t.commentCount
+}
+
+// Version is the one the task was read at, see Service for how it changes on save.
+func (t Task) Version() Version {
+	return t.version
+}
+
 func (t Task) CreatedAt() time.Time {
 	return t.createdAt
 }
@@ -43,14 +296,81 @@ func (t Task) UpdatedAt() *time.Time {
 	return t.updatedAt
 }
 
+// DeletedAt is set for tasks in the trash.
+func (t Task) DeletedAt() *time.Time {
+	return t.deletedAt
+}
+
+// AddTaskCommand is used for creating new Task.
+// Unexported fields and NewAddTaskCommand ensure that the command is created in valid state and cannot be changed.
+// New tasks always start in StatusTodo, ranked after all existing tasks by Service.
 type AddTaskCommand struct {
 	title       string
 	description string
+	status      Status
+	dueAt       *time.Time
+	parentID    *ID
+	projectID   *ProjectID
+	columnID    *ColumnID
+	tags        []string
+	recurrence  *Recurrence
+	checklist   []ChecklistItem
+	rank        Rank
 	createdAt   time.Time
 }
 
-func NewAddTaskCommand(title string, description string) AddTaskCommand {
-	return AddTaskCommand{title: title, description: description, createdAt: time.Now()}
+// NewAddTaskCommand creates AddTaskCommand and validates the data.
+// Placement under the parent is validated by Service, as it requires knowing the parent's ancestors.
+// So is the column, which defaults to the first one of the project.
+func NewAddTaskCommand(
+	title string,
+	description string,
+	dueAt *time.Time,
+	parentID *ID,
+	tags []string,
+	recurrenceRule *RecurrenceRule,
+	projectID *ProjectID,
+	columnID *ColumnID,
+) (AddTaskCommand, error) {
+	title, err := validateTitle(title)
+	if err != nil {
+		return AddTaskCommand{}, err
+	}
+	err = validateColumn(projectID, columnID)
+	if err != nil {
+		return AddTaskCommand{}, err
+	}
+	err = validateRecurrence(recurrenceRule, dueAt)
+	if err != nil {
+		return AddTaskCommand{}, err
+	}
+	tags, err = NormalizeTags(tags)
+	if err != nil {
+		return AddTaskCommand{}, err
+	}
+	createdAt := time.Now()
+	err = validateDueAt(dueAt, createdAt)
+	if err != nil {
+		return AddTaskCommand{}, err
+	}
+	var recurrence *Recurrence
+	if recurrenceRule != nil {
+		value := NewRecurrence(*recurrenceRule, *dueAt)
+		recurrence = &value
+	}
+
+	return AddTaskCommand{
+		title:       title,
+		description: description,
+		status:      StatusTodo,
+		dueAt:       dueAt,
+		parentID:    parentID,
+		projectID:   projectID,
+		columnID:    columnID,
+		tags:        tags,
+		recurrence:  recurrence,
+		createdAt:   createdAt,
+	}, nil
 }
 
 func (a AddTaskCommand) Title() string {
@@ -61,6 +381,192 @@ func (a AddTaskCommand) Description() string {
 	return a.description
 }
 
+func (a AddTaskCommand) Status() Status {
+	return a.status
+}
+
+func (a AddTaskCommand) DueAt() *time.Time {
+	return a.dueAt
+}
+
+func (a AddTaskCommand) ParentID() *ID {
+	return a.parentID
+}
+
+func (a AddTaskCommand) ProjectID() *ProjectID {
+	return a.projectID
+}
+
+// ColumnID is always set for commands passed to repositories, when ProjectID is.
+func (a AddTaskCommand) ColumnID() *ColumnID {
+	return a.columnID
+}
+
+func (a AddTaskCommand) Tags() []string {
+	return copyTags(a.tags)
+}
+
+func (a AddTaskCommand) Recurrence() *Recurrence {
+	return a.recurrence
+}
+
+// Checklist is empty, unless the task comes from a template or a copy.
+func (a AddTaskCommand) Checklist() []ChecklistItem {
+	return copyChecklist(a.checklist)
+}
+
+func (a AddTaskCommand) Rank() Rank {
+	return a.rank
+}
+
 func (a AddTaskCommand) CreatedAt() time.Time {
 	return a.createdAt
 }
+
+// UpdateTaskCommand is used for updating existing Task.
+// Just like in AddTaskCommand, fields are not exported to ensure data validity and immutability.
+type UpdateTaskCommand struct {
+	id             ID
+	title          string
+	description    string
+	dueAt          *time.Time
+	parentID       *ID
+	projectID      *ProjectID
+	columnID       *ColumnID
+	tags           []string
+	recurrenceRule *RecurrenceRule
+	// expectedVersions is optional. When set, the update is rejected unless the task is at one of them.
+	expectedVersions []Version
+}
+
+// NewUpdateTaskCommand creates UpdateTaskCommand and validates the data.
+// Due date is validated against creation date of the task, once it's updated.
+// Without a column, the task stays in its column, unless it moves to another project.
+func NewUpdateTaskCommand(
+	id ID,
+	title string,
+	description string,
+	dueAt *time.Time,
+	parentID *ID,
+	tags []string,
+	recurrenceRule *RecurrenceRule,
+	projectID *ProjectID,
+	columnID *ColumnID,
+	expectedVersions []Version,
+) (UpdateTaskCommand, error) {
+	title, err := validateTitle(title)
+	if err != nil {
+		return UpdateTaskCommand{}, err
+	}
+	err = validateColumn(projectID, columnID)
+	if err != nil {
+		return UpdateTaskCommand{}, err
+	}
+	err = validateRecurrence(recurrenceRule, dueAt)
+	if err != nil {
+		return UpdateTaskCommand{}, err
+	}
+	if parentID != nil && *parentID == id {
+		return UpdateTaskCommand{}, fmt.Errorf("%w: task cannot be its own subtask", ErrValidation)
+	}
+	tags, err = NormalizeTags(tags)
+	if err != nil {
+		return UpdateTaskCommand{}, err
+	}
+
+	return UpdateTaskCommand{
+		id:               id,
+		title:            title,
+		description:      description,
+		dueAt:            dueAt,
+		parentID:         parentID,
+		projectID:        projectID,
+		columnID:         columnID,
+		tags:             tags,
+		recurrenceRule:   recurrenceRule,
+		expectedVersions: expectedVersions,
+	}, nil
+}
+
+func (u UpdateTaskCommand) Id() ID {
+	return u.id
+}
+
+func (u UpdateTaskCommand) Title() string {
+	return u.title
+}
+
+func (u UpdateTaskCommand) Description() string {
+	return u.description
+}
+
+func (u UpdateTaskCommand) DueAt() *time.Time {
+	return u.dueAt
+}
+
+func (u UpdateTaskCommand) ParentID() *ID {
+	return u.parentID
+}
+
+func (u UpdateTaskCommand) ProjectID() *ProjectID {
+	return u.projectID
+}
+
+// ColumnID is always set for commands passed to Task.Update, when ProjectID is.
+func (u UpdateTaskCommand) ColumnID() *ColumnID {
+	return u.columnID
+}
+
+func (u UpdateTaskCommand) Tags() []string {
+	return copyTags(u.tags)
+}
+
+func (u UpdateTaskCommand) RecurrenceRule() *RecurrenceRule {
+	return u.recurrenceRule
+}
+
+func (u UpdateTaskCommand) ExpectedVersions() []Version {
+	return u.expectedVersions
+}
+
+// expects tells whether the task can be updated at the version.
+func (u UpdateTaskCommand) expects(version Version) bool {
+	if len(u.expectedVersions) == 0 {
+		return true
+	}
+	for _, expected := range u.expectedVersions {
+		if expected == version {
+			return true
+		}
+	}
+	return false
+}
+
+func validateTitle(title string) (string, error) {
+	title = strings.TrimSpace(title)
+	if title == "" {
+		return "", fmt.Errorf("%w: title must not be empty", ErrValidation)
+	}
+	return title, nil
+}
+
+func validateDueAt(dueAt *time.Time, createdAt time.Time) error {
+	if dueAt != nil && dueAt.Before(createdAt) {
+		return fmt.Errorf("%w: due date must not be earlier than creation date", ErrValidation)
+	}
+	return nil
+}
+
+func validateColumn(projectID *ProjectID, columnID *ColumnID) error {
+	if columnID != nil && projectID == nil {
+		return fmt.Errorf("%w: column requires project", ErrValidation)
+	}
+	return nil
+}
+
+func validateRecurrence(rule *RecurrenceRule, dueAt *time.Time) error {
+	if rule != nil && dueAt == nil {
+		return fmt.Errorf("%w: recurring task requires due date", ErrValidation)
+	}
+	return nil
+}
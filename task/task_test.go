@@ -0,0 +1,171 @@
+package task_test
+
+import (
+	"demo-app-go/task"
+	"fmt"
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+func TestTask_TransitionTo(t *testing.T) {
+	samples := []struct {
+		from    task.Status
+		to      task.Status
+		allowed bool
+	}{
+		{from: task.StatusTodo, to: task.StatusInProgress, allowed: true},
+		{from: task.StatusTodo, to: task.StatusDone, allowed: true},
+		{from: task.StatusInProgress, to: task.StatusBlocked, allowed: true},
+		{from: task.StatusBlocked, to: task.StatusInProgress, allowed: true},
+		{from: task.StatusDone, to: task.StatusTodo, allowed: true},
+		{from: task.StatusCancelled, to: task.StatusTodo, allowed: true},
+		{from: task.StatusTodo, to: task.StatusTodo, allowed: false},
+		{from: task.StatusBlocked, to: task.StatusDone, allowed: false},
+		{from: task.StatusDone, to: task.StatusCancelled, allowed: false},
+		{from: task.StatusCancelled, to: task.StatusDone, allowed: false},
+	}
+	for _, sample := range samples {
+		sample := sample
+		t.Run(fmt.Sprintf("%s to %s", sample.from, sample.to), func(t *testing.T) {
+			t.Parallel()
+			entity := task.NewTask(task.Snapshot{Id: 1, Title: "title", Status: sample.from, CreatedAt: time.Now()})
+
+			err := entity.TransitionTo(sample.to)
+			if !sample.allowed {
+				require.ErrorIs(t, err, task.ErrInvalidTransition)
+				require.Equal(t, sample.from, entity.Status(), "status must not change")
+				require.Nil(t, entity.UpdatedAt(), "updatedAt must not change")
+				return
+			}
+			require.NoError(t, err, "unexpected error")
+			require.Equal(t, sample.to, entity.Status())
+			require.NotNil(t, entity.UpdatedAt())
+		})
+	}
+}
+
+func TestParseStatus(t *testing.T) {
+	t.Run("parses known status", func(t *testing.T) {
+		t.Parallel()
+		result, err := task.ParseStatus("in_progress")
+		require.NoError(t, err, "unexpected error")
+		require.Equal(t, task.StatusInProgress, result)
+	})
+	t.Run("rejects unknown status", func(t *testing.T) {
+		t.Parallel()
+		_, err := task.ParseStatus("finished")
+		require.ErrorIs(t, err, task.ErrValidation)
+	})
+}
+
+func TestNewAddTaskCommand(t *testing.T) {
+	t.Run("create successfully", func(t *testing.T) {
+		t.Parallel()
+		result, err := task.NewAddTaskCommand(" Buy milk ", "2 bottles", nil, nil, nil, nil, nil, nil)
+		require.NoError(t, err, "unexpected error")
+
+		require.Equal(t, "Buy milk", result.Title())
+		require.Equal(t, "2 bottles", result.Description())
+		require.Equal(t, task.StatusTodo, result.Status())
+		require.False(t, result.CreatedAt().IsZero(), "createdAt")
+	})
+	t.Run("fails at validation", func(t *testing.T) {
+		samples := map[string]string{
+			"title is empty":        "",
+			"title has only spaces": "   ",
+		}
+		for name, title := range samples {
+			title := title
+			t.Run(name, func(t *testing.T) {
+				t.Parallel()
+				_, err := task.NewAddTaskCommand(title, "", nil, nil, nil, nil, nil, nil)
+				require.ErrorIs(t, err, task.ErrValidation)
+				require.ErrorContains(t, err, "title must not be empty")
+			})
+		}
+	})
+}
+
+func TestNewAddTaskCommand_dueAt(t *testing.T) {
+	t.Run("keeps timezone", func(t *testing.T) {
+		t.Parallel()
+		location, err := time.LoadLocation("Europe/Warsaw")
+		require.NoError(t, err, "load location")
+		dueAt := time.Now().Add(time.Hour).In(location)
+
+		result, err := task.NewAddTaskCommand("title", "", &dueAt, nil, nil, nil, nil, nil)
+		require.NoError(t, err, "unexpected error")
+		require.Equal(t, location, result.DueAt().Location())
+	})
+	t.Run("rejects due date earlier than creation date", func(t *testing.T) {
+		t.Parallel()
+		dueAt := time.Now().Add(-time.Minute)
+
+		_, err := task.NewAddTaskCommand("title", "", &dueAt, nil, nil, nil, nil, nil)
+		require.ErrorIs(t, err, task.ErrValidation)
+		require.ErrorContains(t, err, "due date must not be earlier than creation date")
+	})
+}
+
+func TestTask_Update(t *testing.T) {
+	t.Run("rejects due date earlier than creation date", func(t *testing.T) {
+		t.Parallel()
+		createdAt := time.Now().Add(-time.Hour)
+		entity := task.NewTask(task.Snapshot{Id: 1, Title: "title", Status: task.StatusTodo, CreatedAt: createdAt})
+		dueAt := createdAt.Add(-time.Minute)
+		command, err := task.NewUpdateTaskCommand(1, "title", "", &dueAt, nil, nil, nil, nil, nil, nil)
+		require.NoError(t, err, "command")
+
+		err = entity.Update(command)
+		require.ErrorIs(t, err, task.ErrValidation)
+		require.Nil(t, entity.DueAt(), "dueAt must not change")
+	})
+}
+
+func TestTask_IsOverdue(t *testing.T) {
+	now := time.Now()
+	past := now.Add(-time.Hour)
+	future := now.Add(time.Hour)
+	samples := map[string]struct {
+		status   task.Status
+		dueAt    *time.Time
+		expected bool
+	}{
+		"no due date":       {status: task.StatusTodo, dueAt: nil, expected: false},
+		"due in the future": {status: task.StatusTodo, dueAt: &future, expected: false},
+		"past due and open": {status: task.StatusBlocked, dueAt: &past, expected: true},
+		"past due but done": {status: task.StatusDone, dueAt: &past, expected: false},
+	}
+	for name, sample := range samples {
+		sample := sample
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			entity := task.NewTask(task.Snapshot{
+				Id:        1,
+				Title:     "title",
+				Status:    sample.status,
+				DueAt:     sample.dueAt,
+				CreatedAt: now.Add(-2 * time.Hour),
+			})
+			require.Equal(t, sample.expected, entity.IsOverdue(now))
+		})
+	}
+}
+
+func TestNewUpdateTaskCommand(t *testing.T) {
+	t.Run("create successfully", func(t *testing.T) {
+		t.Parallel()
+		result, err := task.NewUpdateTaskCommand(16, " Buy milk ", "2 bottles", nil, nil, nil, nil, nil, nil, nil)
+		require.NoError(t, err, "unexpected error")
+
+		require.Equal(t, task.ID(16), result.Id())
+		require.Equal(t, "Buy milk", result.Title())
+		require.Equal(t, "2 bottles", result.Description())
+	})
+	t.Run("fails at validation", func(t *testing.T) {
+		t.Parallel()
+		_, err := task.NewUpdateTaskCommand(16, " ", "", nil, nil, nil, nil, nil, nil, nil)
+		require.ErrorIs(t, err, task.ErrValidation)
+	})
+}
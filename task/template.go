@@ -0,0 +1,430 @@
+package task
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+const (
+	templateNameMaxLength = 100
+	// templateMaxTasks limits the size of the tree created from a single template.
+	templateMaxTasks = 100
+)
+
+// templateVariable matches placeholders like {{name}}. Spaces inside the braces are allowed.
+var templateVariable = regexp.MustCompile(`\{\{\s*([A-Za-z_][A-Za-z0-9_]*)\s*\}\}`)
+
+type TemplateID uint
+
+// Template describes a task with its subtasks which is created over and over again, like an onboarding checklist.
+// Texts of the tasks may contain variables, e.g. {{name}}, which are substituted when the template is instantiated.
+type Template struct {
+	id        TemplateID
+	name      string
+	task      TemplateTask
+	createdAt time.Time
+	updatedAt *time.Time
+}
+
+// TemplateSnapshot holds the entire state of a Template, just like Snapshot does for Task.
+type TemplateSnapshot struct {
+	Id        TemplateID
+	Name      string
+	Task      TemplateTask
+	CreatedAt time.Time
+	UpdatedAt *time.Time
+}
+
+func NewTemplate(snapshot TemplateSnapshot) Template {
+	return Template{
+		id:        snapshot.Id,
+		name:      snapshot.Name,
+		task:      snapshot.Task,
+		createdAt: snapshot.CreatedAt,
+		updatedAt: snapshot.UpdatedAt,
+	}
+}
+
+func (t *Template) Update(command UpdateTemplateCommand) {
+	t.name = command.Name()
+	t.task = command.Task()
+	now := time.Now()
+	t.updatedAt = &now
+}
+
+func (t Template) Id() TemplateID {
+	return t.id
+}
+
+func (t Template) Name() string {
+	return t.name
+}
+
+// Task is the root of the tree created from the template.
+func (t Template) Task() TemplateTask {
+	return t.task
+}
+
+// Variables lists names of all variables used by the template, sorted.
+func (t Template) Variables() []string {
+	unique := map[string]bool{}
+	t.task.collectVariables(unique)
+	result := make([]string, 0, len(unique))
+	for name := range unique {
+		result = append(result, name)
+	}
+	sort.Strings(result)
+	return result
+}
+
+func (t Template) CreatedAt() time.Time {
+	return t.createdAt
+}
+
+func (t Template) UpdatedAt() *time.Time {
+	return t.updatedAt
+}
+
+// instantiate turns the template into a command adding the task tree. Placement given by the command applies
+// to the root task only, subtasks follow their parents.
+func (t Template) instantiate(command InstantiateTemplateCommand, now time.Time) (AddTaskTreeCommand, error) {
+	for _, name := range t.Variables() {
+		if _, ok := command.variables[name]; !ok {
+			return AddTaskTreeCommand{}, fmt.Errorf("%w: variable %q is missing", ErrValidation, name)
+		}
+	}
+
+	tree, err := t.task.instantiate(command.variables, now.In(command.location))
+	if err != nil {
+		return AddTaskTreeCommand{}, err
+	}
+	tree.task.parentID = command.parentID
+	tree.task.projectID = command.projectID
+	tree.task.columnID = command.columnID
+	return tree, nil
+}
+
+// TemplateTask is a single task of a template. Its texts may contain variables.
+type TemplateTask struct {
+	title       string
+	description string
+	tags        []string
+	checklist   []string
+	dueIn       *time.Duration
+	subtasks    []TemplateTask
+}
+
+// NewTemplateTask is meant both for restoring stored templates and for describing them in template commands,
+// which validate the whole tree. DueIn is relative to the moment the task gets created, nil means no due date.
+func NewTemplateTask(
+	title string,
+	description string,
+	tags []string,
+	checklist []string,
+	dueIn *time.Duration,
+	subtasks []TemplateTask,
+) TemplateTask {
+	return TemplateTask{
+		title:       title,
+		description: description,
+		tags:        tags,
+		checklist:   checklist,
+		dueIn:       dueIn,
+		subtasks:    subtasks,
+	}
+}
+
+func (t TemplateTask) Title() string {
+	return t.title
+}
+
+func (t TemplateTask) Description() string {
+	return t.description
+}
+
+// Tags are normalized only once the variables are substituted.
+func (t TemplateTask) Tags() []string {
+	return copyTags(t.tags)
+}
+
+// Checklist holds texts of the items, in order.
+func (t TemplateTask) Checklist() []string {
+	return copyTags(t.checklist)
+}
+
+func (t TemplateTask) DueIn() *time.Duration {
+	return t.dueIn
+}
+
+func (t TemplateTask) Subtasks() []TemplateTask {
+	return t.subtasks
+}
+
+func (t TemplateTask) height() int {
+	height := 0
+	for _, subtask := range t.subtasks {
+		if h := subtask.height(); h > height {
+			height = h
+		}
+	}
+	return height + 1
+}
+
+func (t TemplateTask) size() int {
+	size := 1
+	for _, subtask := range t.subtasks {
+		size += subtask.size()
+	}
+	return size
+}
+
+func (t TemplateTask) collectVariables(names map[string]bool) {
+	texts := append([]string{t.title, t.description}, t.tags...)
+	texts = append(texts, t.checklist...)
+	for _, text := range texts {
+		for _, match := range templateVariable.FindAllStringSubmatch(text, -1) {
+			names[match[1]] = true
+		}
+	}
+	for _, subtask := range t.subtasks {
+		subtask.collectVariables(names)
+	}
+}
+
+func (t TemplateTask) instantiate(variables map[string]string, now time.Time) (AddTaskTreeCommand, error) {
+	title, err := validateTitle(substitute(t.title, variables))
+	if err != nil {
+		return AddTaskTreeCommand{}, err
+	}
+	tags := make([]string, len(t.tags))
+	for i, tag := range t.tags {
+		tags[i] = substitute(tag, variables)
+	}
+	tags, err = NormalizeTags(tags)
+	if err != nil {
+		return AddTaskTreeCommand{}, err
+	}
+	checklist := make([]ChecklistItem, len(t.checklist))
+	for i, text := range t.checklist {
+		text, err = validateChecklistItemText(substitute(text, variables))
+		if err != nil {
+			return AddTaskTreeCommand{}, err
+		}
+		checklist[i] = NewChecklistItem(ChecklistItemID(i+1), text, false)
+	}
+	var dueAt *time.Time
+	if t.dueIn != nil {
+		value := now.Add(*t.dueIn)
+		dueAt = &value
+	}
+
+	subtasks := make([]AddTaskTreeCommand, len(t.subtasks))
+	for i, subtask := range t.subtasks {
+		subtasks[i], err = subtask.instantiate(variables, now)
+		if err != nil {
+			return AddTaskTreeCommand{}, err
+		}
+	}
+
+	return AddTaskTreeCommand{
+		task: AddTaskCommand{
+			title:       title,
+			description: substitute(t.description, variables),
+			status:      StatusTodo,
+			dueAt:       dueAt,
+			tags:        tags,
+			checklist:   checklist,
+			createdAt:   now,
+		},
+		subtasks: subtasks,
+	}, nil
+}
+
+func substitute(text string, variables map[string]string) string {
+	return templateVariable.ReplaceAllStringFunc(text, func(match string) string {
+		return variables[templateVariable.FindStringSubmatch(match)[1]]
+	})
+}
+
+// AddTemplateCommand is used for creating new Template. Like AddTaskCommand, it cannot be changed once created.
+type AddTemplateCommand struct {
+	name      string
+	task      TemplateTask
+	createdAt time.Time
+}
+
+func NewAddTemplateCommand(name string, task TemplateTask) (AddTemplateCommand, error) {
+	name, task, err := validateTemplate(name, task)
+	if err != nil {
+		return AddTemplateCommand{}, err
+	}
+
+	return AddTemplateCommand{name: name, task: task, createdAt: time.Now()}, nil
+}
+
+func (a AddTemplateCommand) Name() string {
+	return a.name
+}
+
+func (a AddTemplateCommand) Task() TemplateTask {
+	return a.task
+}
+
+func (a AddTemplateCommand) CreatedAt() time.Time {
+	return a.createdAt
+}
+
+// UpdateTemplateCommand replaces the name and all tasks of existing Template.
+type UpdateTemplateCommand struct {
+	id   TemplateID
+	name string
+	task TemplateTask
+}
+
+func NewUpdateTemplateCommand(id TemplateID, name string, task TemplateTask) (UpdateTemplateCommand, error) {
+	name, task, err := validateTemplate(name, task)
+	if err != nil {
+		return UpdateTemplateCommand{}, err
+	}
+
+	return UpdateTemplateCommand{id: id, name: name, task: task}, nil
+}
+
+func (u UpdateTemplateCommand) Id() TemplateID {
+	return u.id
+}
+
+func (u UpdateTemplateCommand) Name() string {
+	return u.name
+}
+
+func (u UpdateTemplateCommand) Task() TemplateTask {
+	return u.task
+}
+
+// InstantiateTemplateCommand is used for creating tasks from Template.
+type InstantiateTemplateCommand struct {
+	id        TemplateID
+	variables map[string]string
+	parentID  *ID
+	projectID *ProjectID
+	columnID  *ColumnID
+	location  *time.Location
+}
+
+// NewInstantiateTemplateCommand expects values of all variables used by the template, others are ignored.
+// Parent and project apply to the root task. Due dates are computed in the location, which matters for durations
+// spanning a change of daylight saving time.
+func NewInstantiateTemplateCommand(
+	id TemplateID,
+	variables map[string]string,
+	parentID *ID,
+	projectID *ProjectID,
+	columnID *ColumnID,
+	location *time.Location,
+) (InstantiateTemplateCommand, error) {
+	err := validateColumn(projectID, columnID)
+	if err != nil {
+		return InstantiateTemplateCommand{}, err
+	}
+	if location == nil {
+		location = time.UTC
+	}
+	copied := make(map[string]string, len(variables))
+	for name, value := range variables {
+		copied[name] = value
+	}
+
+	return InstantiateTemplateCommand{
+		id:        id,
+		variables: copied,
+		parentID:  parentID,
+		projectID: projectID,
+		columnID:  columnID,
+		location:  location,
+	}, nil
+}
+
+func (i InstantiateTemplateCommand) Id() TemplateID {
+	return i.id
+}
+
+func validateTemplate(name string, task TemplateTask) (string, TemplateTask, error) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return "", TemplateTask{}, fmt.Errorf("%w: name must not be empty", ErrValidation)
+	}
+	if utf8.RuneCountInString(name) > templateNameMaxLength {
+		return "", TemplateTask{}, fmt.Errorf(
+			"%w: name must not be longer than %d characters",
+			ErrValidation,
+			templateNameMaxLength,
+		)
+	}
+	if task.height() > MaxDepth {
+		return "", TemplateTask{}, fmt.Errorf(
+			"%w: subtasks cannot be nested deeper than %d levels",
+			ErrValidation,
+			MaxDepth,
+		)
+	}
+	if task.size() > templateMaxTasks {
+		return "", TemplateTask{}, fmt.Errorf(
+			"%w: template must not have more than %d tasks",
+			ErrValidation,
+			templateMaxTasks,
+		)
+	}
+	task, err := normalizeTemplateTask(task)
+	if err != nil {
+		return "", TemplateTask{}, err
+	}
+	return name, task, nil
+}
+
+// normalizeTemplateTask trims the texts and checks them as far as possible before variables are substituted.
+func normalizeTemplateTask(task TemplateTask) (TemplateTask, error) {
+	title, err := validateTitle(task.title)
+	if err != nil {
+		return TemplateTask{}, err
+	}
+	tags := make([]string, len(task.tags))
+	for i, tag := range task.tags {
+		_, err = NormalizeTag(tag)
+		if err != nil {
+			return TemplateTask{}, err
+		}
+		tags[i] = strings.TrimSpace(tag)
+	}
+	if len(task.checklist) > checklistMaxItems {
+		return TemplateTask{}, fmt.Errorf(
+			"%w: checklist must not have more than %d items",
+			ErrValidation,
+			checklistMaxItems,
+		)
+	}
+	checklist := make([]string, len(task.checklist))
+	for i, text := range task.checklist {
+		checklist[i], err = validateChecklistItemText(text)
+		if err != nil {
+			return TemplateTask{}, err
+		}
+	}
+	if task.dueIn != nil && *task.dueIn < 0 {
+		return TemplateTask{}, fmt.Errorf("%w: due date must not be earlier than creation date", ErrValidation)
+	}
+
+	subtasks := make([]TemplateTask, len(task.subtasks))
+	for i, subtask := range task.subtasks {
+		subtasks[i], err = normalizeTemplateTask(subtask)
+		if err != nil {
+			return TemplateTask{}, err
+		}
+	}
+
+	return NewTemplateTask(title, strings.TrimSpace(task.description), tags, checklist, task.dueIn, subtasks), nil
+}
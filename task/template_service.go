@@ -0,0 +1,73 @@
+package task
+
+import "time"
+
+// templateRepository is expected to return an error matching ErrNotFound when the template does not exist.
+type templateRepository interface {
+	List() ([]Template, error)
+	GetByID(id TemplateID) (Template, error)
+	Add(addTemplate AddTemplateCommand) (Template, error)
+	Save(template Template) error
+	Delete(id TemplateID) error
+}
+
+// TemplateService manages templates and creates tasks from them through Service.
+type TemplateService struct {
+	repository templateRepository
+	tasks      *Service
+}
+
+func NewTemplateService(repository templateRepository, tasks *Service) *TemplateService {
+	return &TemplateService{repository: repository, tasks: tasks}
+}
+
+func (s *TemplateService) ListTemplates() ([]Template, error) {
+	return s.repository.List()
+}
+
+func (s *TemplateService) GetTemplate(id TemplateID) (Template, error) {
+	template, err := s.repository.GetByID(id)
+	if err != nil {
+		return Template{}, notFound(err)
+	}
+
+	return template, nil
+}
+
+func (s *TemplateService) AddTemplate(command AddTemplateCommand) (Template, error) {
+	return s.repository.Add(command)
+}
+
+func (s *TemplateService) UpdateTemplate(command UpdateTemplateCommand) (Template, error) {
+	template, err := s.GetTemplate(command.Id())
+	if err != nil {
+		return Template{}, err
+	}
+	template.Update(command)
+
+	err = s.repository.Save(template)
+	if err != nil {
+		return Template{}, notFound(err)
+	}
+
+	return template, nil
+}
+
+// DeleteTemplate keeps tasks created from the template, they do not refer to it.
+func (s *TemplateService) DeleteTemplate(id TemplateID) error {
+	return notFound(s.repository.Delete(id))
+}
+
+// InstantiateTemplate creates the task tree described by the template, all at once.
+func (s *TemplateService) InstantiateTemplate(command InstantiateTemplateCommand, actor string) (Tree, error) {
+	template, err := s.GetTemplate(command.Id())
+	if err != nil {
+		return Tree{}, err
+	}
+	tree, err := template.instantiate(command, time.Now())
+	if err != nil {
+		return Tree{}, err
+	}
+
+	return s.tasks.addTree(tree, actor)
+}
@@ -0,0 +1,239 @@
+package task_test
+
+import (
+	"demo-app-go/task"
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+// fakeTemplateRepository keeps templates in a map, failing like storage.TemplateRepository when one is missing.
+type fakeTemplateRepository struct {
+	templates map[task.TemplateID]task.Template
+	nextID    task.TemplateID
+}
+
+func newFakeTemplateRepository() *fakeTemplateRepository {
+	return &fakeTemplateRepository{templates: map[task.TemplateID]task.Template{}, nextID: 1}
+}
+
+func (r *fakeTemplateRepository) List() ([]task.Template, error) {
+	result := make([]task.Template, 0, len(r.templates))
+	for id := task.TemplateID(1); id < r.nextID; id++ {
+		if template, ok := r.templates[id]; ok {
+			result = append(result, template)
+		}
+	}
+	return result, nil
+}
+
+func (r *fakeTemplateRepository) GetByID(id task.TemplateID) (task.Template, error) {
+	template, ok := r.templates[id]
+	if !ok {
+		return task.Template{}, wrapNotFound()
+	}
+	return template, nil
+}
+
+func (r *fakeTemplateRepository) Add(addTemplate task.AddTemplateCommand) (task.Template, error) {
+	template := task.NewTemplate(task.TemplateSnapshot{
+		Id:        r.nextID,
+		Name:      addTemplate.Name(),
+		Task:      addTemplate.Task(),
+		CreatedAt: addTemplate.CreatedAt(),
+	})
+	r.templates[template.Id()] = template
+	r.nextID++
+	return template, nil
+}
+
+func (r *fakeTemplateRepository) Save(template task.Template) error {
+	if _, ok := r.templates[template.Id()]; !ok {
+		return wrapNotFound()
+	}
+	r.templates[template.Id()] = template
+	return nil
+}
+
+func (r *fakeTemplateRepository) Delete(id task.TemplateID) error {
+	if _, ok := r.templates[id]; !ok {
+		return wrapNotFound()
+	}
+	delete(r.templates, id)
+	return nil
+}
+
+func TestNewAddTemplateCommand(t *testing.T) {
+	leaf := func(title string) task.TemplateTask {
+		return task.NewTemplateTask(title, "", nil, nil, nil, nil)
+	}
+	chain := func(depth int) task.TemplateTask {
+		result := leaf("leaf")
+		for i := 1; i < depth; i++ {
+			result = task.NewTemplateTask("parent", "", nil, nil, nil, []task.TemplateTask{result})
+		}
+		return result
+	}
+
+	t.Run("trims texts and lists variables", func(t *testing.T) {
+		t.Parallel()
+		result, err := task.NewAddTemplateCommand(" Onboarding ", task.NewTemplateTask(
+			" Onboard {{ name }} ",
+			"Welcome {{name}} to {{team}}",
+			[]string{" {{team}} "},
+			[]string{" Create account "},
+			nil,
+			[]task.TemplateTask{leaf("Meet {{buddy}}")},
+		))
+		require.NoError(t, err, "unexpected error")
+		require.Equal(t, "Onboarding", result.Name())
+		require.Equal(t, "Onboard {{ name }}", result.Task().Title())
+		require.Equal(t, []string{"{{team}}"}, result.Task().Tags())
+		require.Equal(t, []string{"Create account"}, result.Task().Checklist())
+
+		template := task.NewTemplate(task.TemplateSnapshot{Id: 1, Name: result.Name(), Task: result.Task()})
+		require.Equal(t, []string{"buddy", "name", "team"}, template.Variables())
+	})
+
+	negative := -time.Hour
+	samples := map[string]struct {
+		name string
+		task task.TemplateTask
+	}{
+		"empty name":  {name: " ", task: leaf("task")},
+		"empty title": {name: "template", task: leaf(" ")},
+		"empty subtask title": {
+			name: "template",
+			task: task.NewTemplateTask("task", "", nil, nil, nil, []task.TemplateTask{leaf("")}),
+		},
+		"empty tag": {name: "template", task: task.NewTemplateTask("task", "", []string{" "}, nil, nil, nil)},
+		"empty checklist item": {
+			name: "template",
+			task: task.NewTemplateTask("task", "", nil, []string{" "}, nil, nil),
+		},
+		"negative due date": {name: "template", task: task.NewTemplateTask("task", "", nil, nil, &negative, nil)},
+		"too deep":          {name: "template", task: chain(task.MaxDepth + 1)},
+	}
+	for name, sample := range samples {
+		sample := sample
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			_, err := task.NewAddTemplateCommand(sample.name, sample.task)
+			require.ErrorIs(t, err, task.ErrValidation)
+		})
+	}
+}
+
+func TestTemplates(t *testing.T) {
+	day := 24 * time.Hour
+	setup := func(t *testing.T) (*task.TemplateService, *task.Service, *fakeRepository) {
+		service, repository := newFakeService(task.DeleteRefuse)
+		templates := task.NewTemplateService(newFakeTemplateRepository(), service)
+		command, err := task.NewAddTemplateCommand("Onboarding", task.NewTemplateTask(
+			"Onboard {{name}}",
+			"Welcome to {{ team }}",
+			[]string{"onboarding", "{{team}}"},
+			[]string{"Sign contract", "Get laptop for {{name}}"},
+			&day,
+			[]task.TemplateTask{
+				task.NewTemplateTask("Create accounts", "", nil, nil, nil, []task.TemplateTask{
+					task.NewTemplateTask("E-mail for {{name}}", "", nil, nil, nil, nil),
+				}),
+				task.NewTemplateTask("Meet the team", "", nil, nil, nil, nil),
+			},
+		))
+		require.NoError(t, err, "command")
+		_, err = templates.AddTemplate(command)
+		require.NoError(t, err, "add template")
+		return templates, service, repository
+	}
+	instantiate := func(
+		templates *task.TemplateService,
+		variables map[string]string,
+		parentID *task.ID,
+	) (task.Tree, error) {
+		command, err := task.NewInstantiateTemplateCommand(1, variables, parentID, nil, nil, time.UTC)
+		if err != nil {
+			return task.Tree{}, err
+		}
+		return templates.InstantiateTemplate(command, "alice")
+	}
+
+	t.Run("InstantiateTemplate creates task tree with variables substituted", func(t *testing.T) {
+		t.Parallel()
+		templates, _, repository := setup(t)
+
+		before := time.Now()
+		result, err := instantiate(templates, map[string]string{"name": "Bob", "team": "Backend", "unused": "x"}, nil)
+		require.NoError(t, err, "unexpected error")
+
+		root := result.Task()
+		require.Equal(t, "Onboard Bob", root.Title())
+		require.Equal(t, "Welcome to Backend", root.Description())
+		require.Equal(t, []string{"backend", "onboarding"}, root.Tags())
+		require.Len(t, root.Checklist(), 2)
+		require.Equal(t, "Get laptop for Bob", root.Checklist()[1].Text())
+		require.NotNil(t, root.DueAt())
+		require.WithinDuration(t, before.Add(day), *root.DueAt(), time.Minute)
+		require.Len(t, result.Subtasks(), 2)
+		accounts := result.Subtasks()[0]
+		require.Equal(t, root.Id(), *accounts.Task().ParentID())
+		require.Nil(t, accounts.Task().DueAt())
+		require.Equal(t, "E-mail for Bob", accounts.Subtasks()[0].Task().Title())
+		require.Equal(t, accounts.Task().Id(), *accounts.Subtasks()[0].Task().ParentID())
+
+		tasks, err := repository.List(task.ListFilter{})
+		require.NoError(t, err, "list")
+		titles := make([]string, len(tasks))
+		for i, entity := range tasks {
+			titles[i] = entity.Title()
+		}
+		require.Equal(t, []string{"Onboard Bob", "Create accounts", "E-mail for Bob", "Meet the team"}, titles)
+		require.Len(t, repository.events, 4, "TaskCreated for each task")
+	})
+	t.Run("InstantiateTemplate requires all variables", func(t *testing.T) {
+		t.Parallel()
+		templates, _, repository := setup(t)
+
+		_, err := instantiate(templates, map[string]string{"name": "Bob"}, nil)
+		require.ErrorIs(t, err, task.ErrValidation)
+		require.Empty(t, repository.tasks)
+	})
+	t.Run("InstantiateTemplate refuses title left empty", func(t *testing.T) {
+		t.Parallel()
+		templates, _, _ := setup(t)
+		templateTask := task.NewTemplateTask("{{name}}", "", nil, nil, nil, nil)
+		command, err := task.NewUpdateTemplateCommand(1, "Onboarding", templateTask)
+		require.NoError(t, err, "command")
+		_, err = templates.UpdateTemplate(command)
+		require.NoError(t, err, "update template")
+
+		_, err = instantiate(templates, map[string]string{"name": " "}, nil)
+		require.ErrorIs(t, err, task.ErrValidation)
+	})
+	t.Run("InstantiateTemplate refuses tree nested too deep under parent", func(t *testing.T) {
+		t.Parallel()
+		templates, service, repository := setup(t)
+		var parentID *task.ID
+		for i := 0; i < task.MaxDepth-2; i++ {
+			command, err := task.NewAddTaskCommand("parent", "", nil, parentID, nil, nil, nil, nil)
+			require.NoError(t, err, "command")
+			entity, err := service.AddTask(command, "alice")
+			require.NoError(t, err, "add task")
+			id := entity.Id()
+			parentID = &id
+		}
+
+		_, err := instantiate(templates, map[string]string{"name": "Bob", "team": "Backend"}, parentID)
+		require.ErrorIs(t, err, task.ErrValidation)
+		require.Len(t, repository.tasks, task.MaxDepth-2)
+	})
+	t.Run("missing template", func(t *testing.T) {
+		t.Parallel()
+		templates, _, _ := setup(t)
+
+		_, err := templates.GetTemplate(2)
+		require.ErrorIs(t, err, task.ErrNotFound)
+		require.ErrorIs(t, templates.DeleteTemplate(2), task.ErrNotFound)
+	})
+}
@@ -0,0 +1,198 @@
+package task
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+const timeEntryNoteMaxLength = 1000
+
+// ErrTimerRunning is returned when a user starts a timer while another one of theirs is still running.
+var ErrTimerRunning = errors.New("another timer is running")
+
+type TimeEntryID uint
+
+// TimeEntry is time a user worked on a task. It is running, until the timer is stopped and the entry gets its end.
+// Manually logged entries are never running.
+type TimeEntry struct {
+	id        TimeEntryID
+	taskID    ID
+	user      string
+	start     time.Time
+	end       *time.Time
+	note      string
+	createdAt time.Time
+}
+
+// TimeEntrySnapshot holds the entire state of a TimeEntry, just like Snapshot does for Task.
+type TimeEntrySnapshot struct {
+	Id        TimeEntryID
+	TaskID    ID
+	User      string
+	Start     time.Time
+	End       *time.Time
+	Note      string
+	CreatedAt time.Time
+}
+
+func NewTimeEntry(snapshot TimeEntrySnapshot) TimeEntry {
+	return TimeEntry{
+		id:        snapshot.Id,
+		taskID:    snapshot.TaskID,
+		user:      snapshot.User,
+		start:     snapshot.Start,
+		end:       snapshot.End,
+		note:      snapshot.Note,
+		createdAt: snapshot.CreatedAt,
+	}
+}
+
+// stop ends the running entry. End never precedes start, even when the clock goes back.
+func (e *TimeEntry) stop(now time.Time) {
+	if now.Before(e.start) {
+		now = e.start
+	}
+	e.end = &now
+}
+
+func (e TimeEntry) Id() TimeEntryID {
+	return e.id
+}
+
+func (e TimeEntry) TaskID() ID {
+	return e.taskID
+}
+
+func (e TimeEntry) User() string {
+	return e.user
+}
+
+func (e TimeEntry) Start() time.Time {
+	return e.start
+}
+
+// End is nil while the timer is running.
+func (e TimeEntry) End() *time.Time {
+	return e.end
+}
+
+func (e TimeEntry) Running() bool {
+	return e.end == nil
+}
+
+// Duration is zero while the timer is running.
+func (e TimeEntry) Duration() time.Duration {
+	if e.end == nil {
+		return 0
+	}
+	return e.end.Sub(e.start)
+}
+
+func (e TimeEntry) Note() string {
+	return e.note
+}
+
+func (e TimeEntry) CreatedAt() time.Time {
+	return e.createdAt
+}
+
+// AddTimeEntryCommand is used for creating new TimeEntry, either running or logged manually.
+type AddTimeEntryCommand struct {
+	taskID    ID
+	user      string
+	start     time.Time
+	end       *time.Time
+	note      string
+	createdAt time.Time
+}
+
+// NewLogTimeCommand logs work which is already done, so it has to end by now.
+func NewLogTimeCommand(
+	taskID ID,
+	user string,
+	start time.Time,
+	end time.Time,
+	note string,
+) (AddTimeEntryCommand, error) {
+	now := time.Now()
+	if !end.After(start) {
+		return AddTimeEntryCommand{}, fmt.Errorf("%w: end must be after start", ErrValidation)
+	}
+	if end.After(now) {
+		return AddTimeEntryCommand{}, fmt.Errorf("%w: end must not be in the future", ErrValidation)
+	}
+
+	return newAddTimeEntryCommand(taskID, user, start, &end, note, now)
+}
+
+// newStartTimerCommand starts a running entry now.
+func newStartTimerCommand(taskID ID, user string, note string) (AddTimeEntryCommand, error) {
+	now := time.Now()
+	return newAddTimeEntryCommand(taskID, user, now, nil, note, now)
+}
+
+func newAddTimeEntryCommand(
+	taskID ID,
+	user string,
+	start time.Time,
+	end *time.Time,
+	note string,
+	createdAt time.Time,
+) (AddTimeEntryCommand, error) {
+	user = strings.TrimSpace(user)
+	if user == "" {
+		return AddTimeEntryCommand{}, fmt.Errorf("%w: user must not be empty", ErrValidation)
+	}
+	if utf8.RuneCountInString(user) > authorMaxLength {
+		return AddTimeEntryCommand{}, fmt.Errorf(
+			"%w: user must not be longer than %d characters",
+			ErrValidation,
+			authorMaxLength,
+		)
+	}
+	note = strings.TrimSpace(note)
+	if utf8.RuneCountInString(note) > timeEntryNoteMaxLength {
+		return AddTimeEntryCommand{}, fmt.Errorf(
+			"%w: note must not be longer than %d characters",
+			ErrValidation,
+			timeEntryNoteMaxLength,
+		)
+	}
+
+	return AddTimeEntryCommand{
+		taskID:    taskID,
+		user:      user,
+		start:     start,
+		end:       end,
+		note:      note,
+		createdAt: createdAt,
+	}, nil
+}
+
+func (a AddTimeEntryCommand) TaskID() ID {
+	return a.taskID
+}
+
+func (a AddTimeEntryCommand) User() string {
+	return a.user
+}
+
+func (a AddTimeEntryCommand) Start() time.Time {
+	return a.start
+}
+
+// End is nil for a timer being started.
+func (a AddTimeEntryCommand) End() *time.Time {
+	return a.end
+}
+
+func (a AddTimeEntryCommand) Note() string {
+	return a.note
+}
+
+func (a AddTimeEntryCommand) CreatedAt() time.Time {
+	return a.createdAt
+}
@@ -0,0 +1,117 @@
+package task
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"time"
+)
+
+// TimeGrouping decides how logged time is aggregated in the report.
+type TimeGrouping string
+
+const (
+	GroupByTask TimeGrouping = "task"
+	// GroupByDay and GroupByWeek attribute each entry to the day, or ISO week, it started in.
+	GroupByDay  TimeGrouping = "day"
+	GroupByWeek TimeGrouping = "week"
+)
+
+func ParseTimeGrouping(value string) (TimeGrouping, error) {
+	grouping := TimeGrouping(value)
+	switch grouping {
+	case GroupByTask, GroupByDay, GroupByWeek:
+		return grouping, nil
+	default:
+		return "", fmt.Errorf("%w: unknown grouping %q", ErrValidation, value)
+	}
+}
+
+// TimeReportQuery selects finished entries which started within [From, To), optionally of a single user.
+// Days and weeks are taken in Location.
+type TimeReportQuery struct {
+	From     time.Time
+	To       time.Time
+	User     *string
+	GroupBy  TimeGrouping
+	Location *time.Location
+}
+
+func (q TimeReportQuery) validate() error {
+	if !q.To.After(q.From) {
+		return fmt.Errorf("%w: report must end after it starts", ErrValidation)
+	}
+	if q.Location == nil {
+		return fmt.Errorf("%w: report location is missing", ErrValidation)
+	}
+	_, err := ParseTimeGrouping(string(q.GroupBy))
+	return err
+}
+
+// TimeReportRow is logged time of a single group. TaskID and Title are set only when grouped by task.
+type TimeReportRow struct {
+	key      string
+	taskID   *ID
+	title    string
+	duration time.Duration
+	entries  int
+}
+
+// Key identifies the group, e.g. "42" for a task, "2023-01-31" for a day or "2023-W05" for a week.
+func (r TimeReportRow) Key() string {
+	return r.key
+}
+
+func (r TimeReportRow) TaskID() *ID {
+	return r.taskID
+}
+
+func (r TimeReportRow) Title() string {
+	return r.title
+}
+
+func (r TimeReportRow) Duration() time.Duration {
+	return r.duration
+}
+
+// Entries counts time entries in the group.
+func (r TimeReportRow) Entries() int {
+	return r.entries
+}
+
+// buildTimeReport sums up the entries by the query's grouping. Rows are ordered by task ID, or chronologically.
+// Titles of tasks which no longer exist are left empty.
+func buildTimeReport(entries []TimeEntry, query TimeReportQuery, titles map[ID]string) []TimeReportRow {
+	rows := map[string]*TimeReportRow{}
+	for _, entry := range entries {
+		row := TimeReportRow{}
+		switch query.GroupBy {
+		case GroupByTask:
+			taskID := entry.TaskID()
+			row = TimeReportRow{key: strconv.FormatUint(uint64(taskID), 10), taskID: &taskID, title: titles[taskID]}
+		case GroupByDay:
+			row.key = entry.Start().In(query.Location).Format("2006-01-02")
+		case GroupByWeek:
+			year, week := entry.Start().In(query.Location).ISOWeek()
+			row.key = fmt.Sprintf("%d-W%02d", year, week)
+		}
+		if existing, ok := rows[row.key]; ok {
+			row = *existing
+		}
+		row.duration += entry.Duration()
+		row.entries++
+		rows[row.key] = &row
+	}
+
+	result := make([]TimeReportRow, 0, len(rows))
+	for _, row := range rows {
+		result = append(result, *row)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].taskID != nil && result[j].taskID != nil {
+			return *result[i].taskID < *result[j].taskID
+		}
+		return result[i].key < result[j].key
+	})
+	return result
+}
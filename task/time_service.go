@@ -0,0 +1,132 @@
+package task
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// timeEntryRepository is expected to return an error matching ErrNotFound when the entry does not exist.
+type timeEntryRepository interface {
+	// List returns entries of the task, oldest first.
+	List(taskID ID) ([]TimeEntry, error)
+	// GetRunning returns the entry of the user which has no end yet.
+	GetRunning(user string) (TimeEntry, error)
+	// ListFinished returns entries with an end, which started within [from, to), of all users when user is nil.
+	ListFinished(from time.Time, to time.Time, user *string) ([]TimeEntry, error)
+	// Add returns an error matching ErrTimerRunning when it would start a second running entry of the user.
+	Add(addTimeEntry AddTimeEntryCommand) (TimeEntry, error)
+	Save(entry TimeEntry) error
+}
+
+// TimeService tracks time spent on tasks, either by timers or by logging it manually.
+type TimeService struct {
+	repository timeEntryRepository
+	tasks      taskRepository
+}
+
+func NewTimeService(repository timeEntryRepository, tasks taskRepository) *TimeService {
+	return &TimeService{repository: repository, tasks: tasks}
+}
+
+func (s *TimeService) ListTimeEntries(taskID ID) ([]TimeEntry, error) {
+	_, err := s.tasks.GetByID(taskID)
+	if err != nil {
+		return nil, notFound(err)
+	}
+
+	return s.repository.List(taskID)
+}
+
+// StartTimer starts tracking time of the user on the task. Each user can have only a single timer running.
+func (s *TimeService) StartTimer(taskID ID, user string, note string) (TimeEntry, error) {
+	command, err := newStartTimerCommand(taskID, user, note)
+	if err != nil {
+		return TimeEntry{}, err
+	}
+	_, err = s.tasks.GetByID(taskID)
+	if err != nil {
+		return TimeEntry{}, notFound(err)
+	}
+	running, err := s.repository.GetRunning(command.User())
+	if err == nil {
+		return TimeEntry{}, fmt.Errorf("%w: on task %d", ErrTimerRunning, running.TaskID())
+	}
+	if !errors.Is(err, ErrNotFound) {
+		return TimeEntry{}, err
+	}
+
+	return s.repository.Add(command)
+}
+
+// StopTimer reports ErrNotFound also when the user's timer runs on another task.
+func (s *TimeService) StopTimer(taskID ID, user string) (TimeEntry, error) {
+	entry, err := s.repository.GetRunning(user)
+	if err != nil {
+		return TimeEntry{}, notFound(err)
+	}
+	if entry.TaskID() != taskID {
+		return TimeEntry{}, ErrNotFound
+	}
+
+	entry.stop(time.Now())
+	err = s.repository.Save(entry)
+	if err != nil {
+		return TimeEntry{}, notFound(err)
+	}
+
+	return entry, nil
+}
+
+func (s *TimeService) LogTime(command AddTimeEntryCommand) (TimeEntry, error) {
+	_, err := s.tasks.GetByID(command.TaskID())
+	if err != nil {
+		return TimeEntry{}, notFound(err)
+	}
+
+	return s.repository.Add(command)
+}
+
+// ReportTime aggregates finished entries. Time logged on tasks in the trash is included as well.
+func (s *TimeService) ReportTime(query TimeReportQuery) ([]TimeReportRow, error) {
+	err := query.validate()
+	if err != nil {
+		return nil, err
+	}
+	entries, err := s.repository.ListFinished(query.From, query.To, query.User)
+	if err != nil {
+		return nil, err
+	}
+
+	titles := map[ID]string{}
+	if query.GroupBy == GroupByTask {
+		titles, err = s.titles(entries)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return buildTimeReport(entries, query, titles), nil
+}
+
+func (s *TimeService) titles(entries []TimeEntry) (map[ID]string, error) {
+	result := map[ID]string{}
+	for _, entry := range entries {
+		if _, ok := result[entry.TaskID()]; ok {
+			continue
+		}
+		entity, err := s.tasks.GetByID(entry.TaskID())
+		if errors.Is(err, ErrNotFound) {
+			entity, err = s.tasks.GetFromTrash(entry.TaskID())
+		}
+		if errors.Is(err, ErrNotFound) {
+			result[entry.TaskID()] = ""
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		result[entry.TaskID()] = entity.Title()
+	}
+	return result, nil
+}
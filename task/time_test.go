@@ -0,0 +1,210 @@
+package task_test
+
+import (
+	"demo-app-go/task"
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+// fakeTimeEntryRepository keeps entries in a slice and fails the same way as storage.TimeEntryRepository.
+type fakeTimeEntryRepository struct {
+	entries []task.TimeEntry
+}
+
+func (r *fakeTimeEntryRepository) List(taskID task.ID) ([]task.TimeEntry, error) {
+	result := []task.TimeEntry{}
+	for _, entry := range r.entries {
+		if entry.TaskID() == taskID {
+			result = append(result, entry)
+		}
+	}
+	return result, nil
+}
+
+func (r *fakeTimeEntryRepository) GetRunning(user string) (task.TimeEntry, error) {
+	for _, entry := range r.entries {
+		if entry.User() == user && entry.Running() {
+			return entry, nil
+		}
+	}
+	return task.TimeEntry{}, wrapNotFound()
+}
+
+func (r *fakeTimeEntryRepository) ListFinished(from time.Time, to time.Time, user *string) ([]task.TimeEntry, error) {
+	result := []task.TimeEntry{}
+	for _, entry := range r.entries {
+		if entry.Running() || entry.Start().Before(from) || !entry.Start().Before(to) {
+			continue
+		}
+		if user != nil && entry.User() != *user {
+			continue
+		}
+		result = append(result, entry)
+	}
+	return result, nil
+}
+
+func (r *fakeTimeEntryRepository) Add(addTimeEntry task.AddTimeEntryCommand) (task.TimeEntry, error) {
+	if _, err := r.GetRunning(addTimeEntry.User()); err == nil && addTimeEntry.End() == nil {
+		return task.TimeEntry{}, task.ErrTimerRunning
+	}
+	entry := task.NewTimeEntry(task.TimeEntrySnapshot{
+		Id:        task.TimeEntryID(len(r.entries) + 1),
+		TaskID:    addTimeEntry.TaskID(),
+		User:      addTimeEntry.User(),
+		Start:     addTimeEntry.Start(),
+		End:       addTimeEntry.End(),
+		Note:      addTimeEntry.Note(),
+		CreatedAt: addTimeEntry.CreatedAt(),
+	})
+	r.entries = append(r.entries, entry)
+	return entry, nil
+}
+
+func (r *fakeTimeEntryRepository) Save(entry task.TimeEntry) error {
+	for i, existing := range r.entries {
+		if existing.Id() == entry.Id() {
+			r.entries[i] = entry
+			return nil
+		}
+	}
+	return wrapNotFound()
+}
+
+func TestNewLogTimeCommand(t *testing.T) {
+	start := time.Date(2023, 1, 31, 9, 0, 0, 0, time.UTC)
+	samples := map[string]struct {
+		user  string
+		start time.Time
+		end   time.Time
+	}{
+		"empty user":       {user: " ", start: start, end: start.Add(time.Hour)},
+		"end before start": {user: "alice", start: start, end: start.Add(-time.Hour)},
+		"empty duration":   {user: "alice", start: start, end: start},
+		"end in future":    {user: "alice", start: start, end: time.Now().Add(time.Hour)},
+	}
+	for name, sample := range samples {
+		sample := sample
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			_, err := task.NewLogTimeCommand(1, sample.user, sample.start, sample.end, "")
+			require.ErrorIs(t, err, task.ErrValidation)
+		})
+	}
+}
+
+func TestTimeService(t *testing.T) {
+	setup := func(t *testing.T) *task.TimeService {
+		tasks := newFakeRepository()
+		for _, title := range []string{"first", "second"} {
+			command, err := task.NewAddTaskCommand(title, "", nil, nil, nil, nil, nil, nil)
+			require.NoError(t, err, "command")
+			_, err = tasks.Add(command, noRecord)
+			require.NoError(t, err, "add task")
+		}
+		return task.NewTimeService(&fakeTimeEntryRepository{}, tasks)
+	}
+	logTime := func(t *testing.T, service *task.TimeService, taskID task.ID, user string, start string, hours int) {
+		startAt, err := time.Parse(time.RFC3339, start)
+		require.NoError(t, err, "start")
+		command, err := task.NewLogTimeCommand(taskID, user, startAt, startAt.Add(time.Duration(hours)*time.Hour), "")
+		require.NoError(t, err, "command")
+		_, err = service.LogTime(command)
+		require.NoError(t, err, "log time")
+	}
+
+	t.Run("StartTimer allows single running timer per user", func(t *testing.T) {
+		t.Parallel()
+		service := setup(t)
+
+		entry, err := service.StartTimer(1, "alice", "coding")
+		require.NoError(t, err, "unexpected error")
+		require.True(t, entry.Running())
+		require.Equal(t, "coding", entry.Note())
+
+		_, err = service.StartTimer(2, "alice", "")
+		require.ErrorIs(t, err, task.ErrTimerRunning)
+		_, err = service.StartTimer(2, "bob", "")
+		require.NoError(t, err, "timer of another user")
+	})
+	t.Run("StopTimer ends running timer on the task", func(t *testing.T) {
+		t.Parallel()
+		service := setup(t)
+		_, err := service.StartTimer(1, "alice", "")
+		require.NoError(t, err, "start timer")
+
+		_, err = service.StopTimer(2, "alice")
+		require.Equal(t, task.ErrNotFound, err, "timer runs on another task")
+		entry, err := service.StopTimer(1, "alice")
+		require.NoError(t, err, "unexpected error")
+		require.False(t, entry.Running())
+		require.GreaterOrEqual(t, entry.Duration(), time.Duration(0))
+
+		_, err = service.StopTimer(1, "alice")
+		require.Equal(t, task.ErrNotFound, err, "stopped twice")
+		_, err = service.StartTimer(2, "alice", "")
+		require.NoError(t, err, "start another timer")
+	})
+	t.Run("LogTime returns ErrNotFound for missing task", func(t *testing.T) {
+		t.Parallel()
+		service := setup(t)
+		start := time.Now().Add(-time.Hour)
+		command, err := task.NewLogTimeCommand(3, "alice", start, start.Add(time.Minute), "")
+		require.NoError(t, err, "command")
+
+		_, err = service.LogTime(command)
+		require.Equal(t, task.ErrNotFound, err)
+	})
+	t.Run("ReportTime aggregates finished entries", func(t *testing.T) {
+		t.Parallel()
+		service := setup(t)
+		logTime(t, service, 1, "alice", "2023-01-29T22:00:00Z", 1)
+		logTime(t, service, 2, "alice", "2023-01-30T09:00:00Z", 2)
+		logTime(t, service, 1, "bob", "2023-01-30T13:00:00Z", 3)
+		logTime(t, service, 1, "bob", "2023-02-10T13:00:00Z", 4)
+		_, err := service.StartTimer(1, "carol", "")
+		require.NoError(t, err, "running timer")
+		query := task.TimeReportQuery{
+			From:     time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC),
+			To:       time.Date(2023, 2, 1, 0, 0, 0, 0, time.UTC),
+			GroupBy:  task.GroupByTask,
+			Location: time.UTC,
+		}
+		type row struct {
+			key     string
+			title   string
+			hours   int
+			entries int
+		}
+		rows := func(result []task.TimeReportRow) []row {
+			converted := make([]row, len(result))
+			for i, r := range result {
+				converted[i] = row{key: r.Key(), title: r.Title(), hours: int(r.Duration().Hours()), entries: r.Entries()}
+			}
+			return converted
+		}
+
+		result, err := service.ReportTime(query)
+		require.NoError(t, err, "by task")
+		require.Equal(t, []row{{"1", "first", 4, 2}, {"2", "second", 2, 1}}, rows(result))
+
+		query.GroupBy = task.GroupByDay
+		query.Location = time.FixedZone("UTC+3", 3*60*60)
+		result, err = service.ReportTime(query)
+		require.NoError(t, err, "by day")
+		require.Equal(t, []row{{"2023-01-30", "", 6, 3}}, rows(result))
+
+		query.GroupBy = task.GroupByWeek
+		query.Location = time.UTC
+		alice := "alice"
+		query.User = &alice
+		result, err = service.ReportTime(query)
+		require.NoError(t, err, "by week")
+		require.Equal(t, []row{{"2023-W04", "", 1, 1}, {"2023-W05", "", 2, 1}}, rows(result))
+
+		query.To = query.From
+		_, err = service.ReportTime(query)
+		require.ErrorIs(t, err, task.ErrValidation)
+	})
+}